@@ -1,44 +1,157 @@
 package cmd
 
 import (
+	"maps"
+	"strings"
+	"time"
+
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/inline"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/nathan-nicholson/note/internal/templates"
 	"github.com/spf13/cobra"
 )
 
 var (
-	addTags      []string
-	addImportant bool
+	addTags       []string
+	addImportant  bool
+	addSplitLines bool
+	addTemplate   string
+	addVars       []string
+	addDryRun     bool
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add <content>",
+	Use:   "add [content...]",
 	Short: "Create a new note",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Create a new note. Arguments are joined into one note. Use - or pipe
+input in to read the content from standard input, and --split-lines to create
+a note per line.
+
+Without content, $VISUAL or $EDITOR is opened to write the note, with the tags
+and importance in a header above the content.
+
+Tags and importance can also be typed into content given as arguments: a #tag
+anywhere tags the note, and #tags and a lone ! at the end are taken out of the
+text and tag the note or mark it important. Piped and edited content is saved
+as it is. --dry-run shows the result without saving.
+
+With --template, the note starts from a template (see note template), which
+also sets its default tags and importance. Any content given is added below
+the template.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		content := args[0]
+		if addTemplate != "" {
+			return addFromTemplate(args)
+		}
+
+		draft := noteDraft{tags: addTags, important: addImportant}
 
-		activeProject, err := repository.GetActiveProject(database.DB)
+		content, ok, err := readContent(args)
 		if err != nil {
 			return err
 		}
 
-		tags := append(addTags, activeProject.Name)
+		if ok {
+			draft.content = content
+		} else {
+			composed, err := composeNote(draft)
+			if err != nil {
+				return err
+			}
+			draft = composed
+		}
 
-		_, err = repository.CreateNote(database.DB, content, tags, addImportant)
+		items, err := contentItems(draft.content, addSplitLines)
 		if err != nil {
 			return err
 		}
 
-		if err := repository.UpdateProjectLastActivity(database.DB, activeProject.ID); err != nil {
+		return addNotes("add", parseItems(items, false, typedContent(args)), draft.tags, draft.important, addDryRun)
+	},
+}
+
+func addFromTemplate(args []string) error {
+	t, err := findTemplate(addTemplate)
+	if err != nil {
+		return err
+	}
+
+	activeProject, err := repository.GetActiveProject(database.DB)
+	if err != nil {
+		return err
+	}
+
+	vars := templates.Builtins(time.Now(), activeProject.Name)
+	given, err := templates.ParseVars(addVars)
+	if err != nil {
+		return err
+	}
+	maps.Copy(vars, given)
+
+	content, err := templates.Render(t.Content, vars, promptTemplateValue)
+	if err != nil {
+		return err
+	}
+
+	extra, ok, err := readContent(args)
+	if err != nil {
+		return err
+	}
+
+	// Only the content typed as arguments can carry inline metadata, never
+	// the rendered template.
+	tags, important := mergeTags(t.Tags, addTags), t.IsImportant || addImportant
+	if ok && typedContent(args) {
+		typed := inline.Parse(extra, false)
+		extra = typed.Content
+		tags, important = mergeTags(tags, typed.Tags), important || typed.Important
+	}
+	if ok {
+		content = strings.TrimRight(content, "\n") + "\n\n" + extra
+	}
+
+	items, err := contentItems(content, addSplitLines)
+	if err != nil {
+		return err
+	}
+
+	return addNotes("add", parseItems(items, false, false), tags, important, addDryRun)
+}
+
+// addNotes creates a note for each item in the active project as a single
+// operation, so one undo removes them all. With dryRun it only prints them.
+func addNotes(command string, items []inline.Item, tags []string, important bool, dryRun bool) error {
+	if dryRun {
+		return previewItems("note", items, tags, important, nil, nil, "")
+	}
+
+	return journal.Run(database.DB, command, func(tx database.DBTX, op *journal.Recorder) error {
+		activeProject, err := repository.GetActiveProject(tx)
+		if err != nil {
 			return err
 		}
 
-		return nil
-	},
+		if err := op.Track("project", activeProject.ID); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			itemTags := mergeTags(tags, item.Tags, []string{activeProject.Name})
+			if _, err := repository.CreateNote(tx, item.Content, itemTags, important || item.Important); err != nil {
+				return err
+			}
+		}
+
+		return repository.UpdateProjectLastActivity(tx, activeProject.ID)
+	})
 }
 
 func init() {
 	addCmd.Flags().StringSliceVar(&addTags, "tag", []string{}, "Tags for the note")
 	addCmd.Flags().BoolVar(&addImportant, "important", false, "Mark note as important")
+	addCmd.Flags().BoolVar(&addSplitLines, "split-lines", false, "Create a note for each line of the content")
+	addCmd.Flags().StringVar(&addTemplate, "template", "", "Start the note from this template")
+	addCmd.Flags().StringArrayVar(&addVars, "var", []string{}, "Set a template variable (name=value)")
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "Show the notes that would be added without saving them")
 }
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/attachment"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <id> <file>...",
+	Short: "Attach files to a note",
+	Long: `Attach files to a note. A copy of each file is kept with the database, so
+the original can be moved or deleted afterwards.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return attachFiles("note", args)
+	},
+}
+
+var attachmentsCmd = &cobra.Command{
+	Use:   "attachments <id>",
+	Short: "List the files attached to a note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listAttachments("note", args)
+	},
+}
+
+var detachCmd = &cobra.Command{
+	Use:   "detach <id> <attachment>",
+	Short: "Remove an attachment from a note",
+	Long: `Remove an attachment, given by its ID or file name, from a note. The stored
+copy is deleted by 'note trash empty' once nothing refers to it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return detachFile("note", args)
+	},
+}
+
+func attachmentStore() *attachment.Store {
+	return attachment.NewStore(attachment.Dir(rootResolvedDBPath))
+}
+
+func attachFiles(kind string, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Files are copied into the store before the transaction, which may be
+	// retried. A copy left behind by a failed command is removed with the
+	// other unused files.
+	type file struct {
+		hash string
+		name string
+		size int64
+	}
+	var files []file
+	store := attachmentStore()
+	for _, path := range args[1:] {
+		hash, size, err := store.Put(path)
+		if err != nil {
+			return fmt.Errorf("could not attach %s: %w", path, err)
+		}
+		files = append(files, file{hash: hash, name: filepath.Base(path), size: size})
+	}
+
+	err = journal.Run(database.DB, "attach", func(tx database.DBTX, op *journal.Recorder) error {
+		if err := op.Track(kind, id); err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			if _, err := repository.AddAttachment(tx, kind, id, f.hash, f.name, f.size); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fmt.Printf("Attached %s to %s #%d.\n", f.name, kind, id)
+	}
+	return nil
+}
+
+func listAttachments(kind string, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	if kind == "note" {
+		_, err = repository.GetNoteByID(database.DB, id)
+	} else {
+		_, err = repository.GetTodoByID(database.DB, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	attachments, err := repository.ListAttachments(database.DB, kind, id)
+	if err != nil {
+		return err
+	}
+
+	if len(attachments) == 0 {
+		fmt.Printf("No attachments on %s #%d.\n", kind, id)
+		return nil
+	}
+
+	fmt.Println(display.FormatAttachmentList(attachments, attachmentStore().Path))
+	return nil
+}
+
+func detachFile(kind string, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = journal.Run(database.DB, "detach", func(tx database.DBTX, op *journal.Recorder) error {
+		if err := op.Track(kind, id); err != nil {
+			return err
+		}
+
+		a, err := repository.FindAttachment(tx, kind, id, args[1])
+		if err != nil {
+			return err
+		}
+		name = a.Name
+
+		return repository.RemoveAttachment(tx, a.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Detached %s from %s #%d.\n", name, kind, id)
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/editor"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// noteDraft is a note as written in the editor.
+type noteDraft struct {
+	content   string
+	tags      []string
+	important bool
+}
+
+// todoDraft is a todo as written in the editor. An empty due, repeat or
+// priority leaves the todo without one.
+type todoDraft struct {
+	content  string
+	tags     []string
+	due      string
+	repeat   string
+	priority string
+}
+
+func composeNote(draft noteDraft) (noteDraft, error) {
+	text := editor.Compose([]editor.Field{
+		{Name: "tags", Value: strings.Join(draft.tags, ", ")},
+		{Name: "important", Value: strconv.FormatBool(draft.important)},
+	}, draft.content)
+
+	fields, content, err := editInEditor(text, "tags", "important")
+	if err != nil {
+		return noteDraft{}, err
+	}
+
+	result := noteDraft{content: content, tags: editor.SplitTags(fields["tags"])}
+	if value := fields["important"]; value != "" {
+		result.important, err = strconv.ParseBool(value)
+		if err != nil {
+			return noteDraft{}, fmt.Errorf("invalid important value '%s' (expected true or false)", value)
+		}
+	}
+
+	return result, nil
+}
+
+func composeTodo(draft todoDraft) (todoDraft, error) {
+	text := editor.Compose([]editor.Field{
+		{Name: "tags", Value: strings.Join(draft.tags, ", ")},
+		{Name: "due", Value: draft.due},
+		{Name: "repeat", Value: draft.repeat},
+		{Name: "priority", Value: draft.priority},
+	}, draft.content)
+
+	fields, content, err := editInEditor(text, "tags", "due", "repeat", "priority")
+	if err != nil {
+		return todoDraft{}, err
+	}
+
+	return todoDraft{
+		content:  content,
+		tags:     editor.SplitTags(fields["tags"]),
+		due:      fields["due"],
+		repeat:   fields["repeat"],
+		priority: fields["priority"],
+	}, nil
+}
+
+func editInEditor(text string, fields ...string) (map[string]string, string, error) {
+	edited, err := editor.Edit(text)
+	if err != nil {
+		return nil, "", err
+	}
+
+	values, content, err := editor.Parse(edited, fields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return nil, "", fmt.Errorf("Aborting: the content is empty")
+	}
+
+	return values, content, nil
+}
+
+// parseDueDate turns the due header into a date, with nil for no due date.
+func parseDueDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := dateparse.ParseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parseRepeat turns the repeat header into a rule, with nil for none.
+func parseRepeat(value string) (*dateparse.Repeat, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := dateparse.ParseRepeat(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parsePriority turns the priority header into P0 to P3, or empty for none.
+func parsePriority(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	return models.ParsePriority(value)
+}
+
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var dayMarkdown bool
+
+var todayCmd = &cobra.Command{
+	Use:   "today",
+	Short: "Show today's notes and todos as one timeline",
+	Long: `Show today's notes, and the todos created, completed or due today, as one
+timeline. The day's activity is summarised in a single line. Use --markdown for
+output to paste into a daily log.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showDay(time.Now())
+	},
+}
+
+var dayCmd = &cobra.Command{
+	Use:   "day <date>",
+	Short: "Show a day's notes and todos as one timeline",
+	Long: `Show the notes of a day, and the todos created, completed or due that day,
+as one timeline. The date may be YYYY-MM-DD or natural language such as
+yesterday or monday. The day's activity is summarised in a single line. Use
+--markdown for output to paste into a daily log.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		date, err := dateparse.ParseDate(args[0])
+		if err != nil {
+			return err
+		}
+		return showDay(date)
+	},
+}
+
+func showDay(date time.Time) error {
+	notes, err := repository.ListNotes(database.DB, repository.NoteListOptions{StartDate: &date, EndDate: &date})
+	if err != nil {
+		return err
+	}
+
+	todos, err := repository.ListTodos(database.DB, repository.TodoListOptions{Day: &date})
+	if err != nil {
+		return err
+	}
+
+	entries, err := repository.ListActivity(database.DB, repository.ActivityListOptions{StartDate: &date, EndDate: &date})
+	if err != nil {
+		return err
+	}
+
+	day := display.Day{
+		Date:     date,
+		Notes:    notes,
+		Todos:    todos,
+		Activity: activity.Summarize(entries),
+	}
+
+	if dayMarkdown {
+		fmt.Println(display.FormatDayMarkdown(day))
+	} else {
+		fmt.Println(display.FormatDay(day))
+	}
+	return nil
+}
+
+func init() {
+	todayCmd.Flags().BoolVar(&dayMarkdown, "markdown", false, "Print Markdown for a daily log")
+	dayCmd.Flags().BoolVar(&dayMarkdown, "markdown", false, "Print Markdown for a daily log")
+}
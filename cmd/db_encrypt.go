@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/nathan-nicholson/note/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var dbEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt note and todo content",
+	Long: `Encrypt the content of every note, todo and revision, and the activity
+log, with a key derived from a passphrase. Tags, dates and projects stay
+readable, so listing and filtering still work. Full-text search is unavailable while the database is
+encrypted, and the undo history recorded so far is cleared.
+
+The passphrase is read from --key-file, $NOTE_KEY_FILE or $NOTE_PASSPHRASE,
+and otherwise prompted for. Every later command needs it too.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encrypted, err := database.Encrypted(database.DB)
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			return fmt.Errorf("the database is already encrypted")
+		}
+
+		passphrase, err := vault.ReadPassphrase(rootKeyFile, true)
+		if err != nil {
+			return err
+		}
+
+		if err := repository.EncryptContent(database.DB, passphrase); err != nil {
+			return err
+		}
+
+		if err := database.Compact(database.DB); err != nil {
+			return err
+		}
+
+		fmt.Println("Encrypted note and todo content. Keep the passphrase safe: it cannot be recovered.")
+		return nil
+	},
+}
+
+var dbDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Store note and todo content in plaintext again",
+	Long: `Decrypt all note, todo and revision content and remove the encryption
+settings. The search index is rebuilt and the undo history is cleared.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encrypted, err := database.Encrypted(database.DB)
+		if err != nil {
+			return err
+		}
+		if !encrypted {
+			return fmt.Errorf("the database is not encrypted")
+		}
+
+		if err := repository.DecryptContent(database.DB); err != nil {
+			return err
+		}
+
+		if err := database.EnsureSearchIndex(database.DB); err != nil {
+			return err
+		}
+
+		if err := database.Compact(database.DB); err != nil {
+			return err
+		}
+
+		fmt.Println("Decrypted note and todo content.")
+		return nil
+	},
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var dbMigrateStatus bool
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !dbMigrateStatus {
+			if err := database.Migrate(database.DB); err != nil {
+				return err
+			}
+		}
+
+		current, err := database.CurrentVersion(database.DB)
+		if err != nil {
+			return err
+		}
+
+		if !dbMigrateStatus {
+			fmt.Printf("Database is up to date (schema version %d).\n", current)
+			return nil
+		}
+
+		statuses, err := database.GetMigrationStatus(database.DB)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Schema version: %d (latest: %d)\n\n", current, database.LatestVersion())
+		for _, status := range statuses {
+			if status.AppliedAt.Valid {
+				fmt.Printf("  [X] %03d  %s (applied %s)\n", status.Version, status.Description, status.AppliedAt.Time.Format("2006-01-02 03:04 PM"))
+			} else {
+				fmt.Printf("  [ ] %03d  %s (pending)\n", status.Version, status.Description)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	dbMigrateCmd.Flags().BoolVar(&dbMigrateStatus, "status", false, "Show applied and pending migrations without changing anything")
+}
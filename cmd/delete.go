@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,12 @@ var deleteCmd = &cobra.Command{
 			return err
 		}
 
-		return repository.DeleteNote(database.DB, id)
+		return journal.Run(database.DB, "delete", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("note", id); err != nil {
+				return err
+			}
+
+			return repository.DeleteNote(tx, id)
+		})
 	},
 }
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <id> [rev-a] [rev-b]",
+	Short: "Show changes between revisions of a note",
+	Long: `Show a unified diff of a note's content plus tag and importance changes.
+
+With no revisions, compares the latest edit with the one before it. With one
+revision, compares it with the current state.`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showDiff("note", args)
+	},
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the database for inconsistencies",
+	Long: `Check the database for orphaned tag links, unused tags, a missing or
+duplicate active project, a missing home project, and todos still tagged
+with a deleted project. Use --fix to repair what is found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checks, err := repository.CheckIntegrity(database.DB, doctorFix)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(display.FormatIntegrityReport(checks))
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Repair the problems that are found")
+}
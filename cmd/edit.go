@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -17,7 +19,10 @@ var (
 var editCmd = &cobra.Command{
 	Use:   "edit <id>",
 	Short: "Edit a note",
-	Args:  cobra.ExactArgs(1),
+	Long: `Edit a note. Without --content, --tag or --important, the note is opened
+in $VISUAL or $EDITOR with its tags and importance in a header above the
+content.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, err := strconv.Atoi(args[0])
 		if err != nil {
@@ -26,6 +31,7 @@ var editCmd = &cobra.Command{
 
 		var content *string
 		var important *bool
+		tags := editTags
 
 		if cmd.Flags().Changed("content") {
 			content = &editContent
@@ -35,7 +41,43 @@ var editCmd = &cobra.Command{
 			important = &editImportant
 		}
 
-		return repository.UpdateNote(database.DB, id, content, editTags, important)
+		if content == nil && important == nil && len(tags) == 0 {
+			note, err := repository.GetNoteByID(database.DB, id)
+			if err != nil {
+				return err
+			}
+
+			draft, err := composeNote(noteDraft{content: note.Content, tags: note.Tags, important: note.IsImportant})
+			if err != nil {
+				return err
+			}
+
+			if draft.content != note.Content {
+				content = &draft.content
+			}
+			if draft.important != note.IsImportant {
+				important = &draft.important
+			}
+			if !sameTags(draft.tags, note.Tags) {
+				if len(draft.tags) == 0 {
+					return fmt.Errorf("a note's tags can be replaced but not all removed")
+				}
+				tags = draft.tags
+			}
+
+			if content == nil && important == nil && len(tags) == 0 {
+				fmt.Println("No changes.")
+				return nil
+			}
+		}
+
+		return journal.Run(database.DB, "edit", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("note", id); err != nil {
+				return err
+			}
+
+			return repository.UpdateNote(tx, id, content, tags, important)
+		})
 	},
 }
 
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show the edit history of a note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showHistory("note", args)
+	},
+}
+
+func showHistory(kind string, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	revisions, err := repository.ListRevisions(database.DB, kind, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(display.FormatRevisionList(revisions))
+	return nil
+}
+
+func showDiff(kind string, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	revisions, err := repository.ListRevisions(database.DB, kind, id)
+	if err != nil {
+		return err
+	}
+
+	// With no revisions given, compare the latest edit with the one before it;
+	// with one, compare that revision with the current state.
+	from := revisions[0]
+	if len(revisions) > 1 {
+		from = revisions[len(revisions)-2]
+	}
+	to := revisions[len(revisions)-1]
+
+	if len(args) > 1 {
+		from, err = findRevision(revisions, args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 2 {
+		to, err = findRevision(revisions, args[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(display.FormatRevisionDiff(&from, &to))
+	return nil
+}
+
+func findRevision(revisions []models.Revision, arg string) (models.Revision, error) {
+	number, err := parseRevision(arg)
+	if err != nil {
+		return models.Revision{}, err
+	}
+
+	for _, revision := range revisions {
+		if revision.Revision == number {
+			return revision, nil
+		}
+	}
+
+	return models.Revision{}, fmt.Errorf("revision %d not found (latest is r%d)", number, revisions[len(revisions)-1].Revision)
+}
+
+func parseRevision(arg string) (int, error) {
+	if len(arg) > 1 && arg[0] == 'r' {
+		arg = arg[1:]
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid revision '%s' (expected a number such as 2 or r2)", arg)
+	}
+
+	return number, nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/inline"
+	"github.com/nathan-nicholson/note/internal/repository"
+)
+
+// readContent collects the content for new notes and todos. Positional
+// arguments are joined with spaces, so quoting is optional. A lone "-", or no
+// arguments while input is piped in, reads standard input instead. ok is false
+// when there is no content to read at all.
+func readContent(args []string) (content string, ok bool, err error) {
+	if len(args) == 1 && args[0] == "-" || len(args) == 0 && stdinPiped() {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", false, fmt.Errorf("could not read standard input: %w", err)
+		}
+		return string(data), true, nil
+	}
+
+	if len(args) == 0 {
+		return "", false, nil
+	}
+
+	return strings.Join(args, " "), true, nil
+}
+
+// contentItems turns content into the notes or todos to create: one per
+// non-blank line with splitLines, otherwise the whole content as one.
+func contentItems(content string, splitLines bool) ([]string, error) {
+	var items []string
+
+	if splitLines {
+		for _, line := range strings.Split(content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, line)
+			}
+		}
+	} else if content = strings.TrimSpace(content); content != "" {
+		items = append(items, content)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("nothing to add: the content is empty")
+	}
+	return items, nil
+}
+
+// typedContent reports whether readContent takes the content from args, as
+// opposed to standard input or the editor.
+func typedContent(args []string) bool {
+	return len(args) > 0 && !(len(args) == 1 && args[0] == "-")
+}
+
+// parseItems reads the tags, importance and, with dates, the due date typed
+// into each item (see package inline). Only content typed as arguments is
+// parsed: piped input, editor bodies and templates are kept as they are, since
+// a # or a trailing ! in them is text such as a build log, not metadata.
+func parseItems(items []string, dates, typed bool) []inline.Item {
+	parsed := make([]inline.Item, len(items))
+	for i, content := range items {
+		if typed {
+			parsed[i] = inline.Parse(content, dates)
+		} else {
+			parsed[i] = inline.Item{Content: content}
+		}
+	}
+	return parsed
+}
+
+// mergeTags joins tag lists, dropping repeats.
+func mergeTags(lists ...[]string) []string {
+	var tags []string
+	for _, list := range lists {
+		for _, tag := range list {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// previewItems prints what --dry-run would create, a "note" or "todo" per
+// item, without writing anything.
+func previewItems(kind string, items []inline.Item, tags []string, important bool, due *time.Time, repeat *dateparse.Repeat, priority string) error {
+	activeProject, err := repository.GetActiveProject(database.DB)
+	if err != nil {
+		return err
+	}
+
+	noun := kind
+	if len(items) != 1 {
+		noun += "s"
+	}
+	fmt.Printf("Would add %d %s to project %s:\n", len(items), noun, activeProject.Name)
+
+	for _, item := range items {
+		fmt.Println()
+		fmt.Println(item.Content)
+		fmt.Printf("  Tags: #%s\n", strings.Join(mergeTags(tags, item.Tags, []string{activeProject.Name}), ", #"))
+
+		if kind == "todo" {
+			if itemDue := firstDue(dueOrDefault(due, item.Due), repeat); itemDue != nil {
+				fmt.Printf("  Due: %s\n", itemDue.Format("2006-01-02"))
+			}
+			if repeat != nil {
+				fmt.Printf("  Repeats: %s\n", repeat)
+			}
+			if priority != "" {
+				fmt.Printf("  Priority: %s\n", priority)
+			}
+		} else if important || item.Important {
+			fmt.Println("  Important: Yes")
+		}
+	}
+
+	return nil
+}
+
+// dueOrDefault prefers the due date given by flag or editor header over one
+// typed into the content.
+func dueOrDefault(due, inlineDue *time.Time) *time.Time {
+	if due != nil {
+		return due
+	}
+	return inlineDue
+}
+
+// firstDue gives a recurring todo without a due date its first occurrence
+// from today.
+func firstDue(due *time.Time, repeat *dateparse.Repeat) *time.Time {
+	if due != nil || repeat == nil {
+		return due
+	}
+	first := repeat.First(time.Now())
+	return &first
+}
+
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+// pipeStdin makes standard input read content, as when it is piped in.
+func pipeStdin(t *testing.T, content string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	if _, err := w.WriteString(content); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestParseItems_PipedContentUnchanged(t *testing.T) {
+	log := "cc -c main.c\nmain.c:1: #include <missing.h> not found\ncolor #fff\nDone !"
+	pipeStdin(t, log)
+
+	content, ok, err := readContent(nil)
+	if err != nil || !ok {
+		t.Fatalf("readContent() = %v, %v, want the piped content", ok, err)
+	}
+
+	items, err := contentItems(content, false)
+	if err != nil {
+		t.Fatalf("contentItems() error = %v", err)
+	}
+
+	parsed := parseItems(items, true, typedContent(nil))
+	if len(parsed) != 1 {
+		t.Fatalf("parseItems() = %+v, want one item", parsed)
+	}
+	if item := parsed[0]; item.Content != log || len(item.Tags) != 0 || item.Important || item.Due != nil {
+		t.Errorf("parseItems() = %+v, want the piped content unchanged", item)
+	}
+}
+
+func TestParseItems_TypedContent(t *testing.T) {
+	args := []string{"Deploy", "went", "fine", "#release", "!"}
+
+	content, ok, err := readContent(args)
+	if err != nil || !ok {
+		t.Fatalf("readContent() = %v, %v, want the arguments", ok, err)
+	}
+
+	parsed := parseItems([]string{content}, false, typedContent(args))
+	if item := parsed[0]; item.Content != "Deploy went fine" || !slices.Equal(item.Tags, []string{"release"}) || !item.Important {
+		t.Errorf("parseItems() = %+v, want the tag and importance taken out", item)
+	}
+
+	if typedContent([]string{"-"}) {
+		t.Error("typedContent(-) = true, want standard input treated as piped")
+	}
+}
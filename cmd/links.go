@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var linksBroken bool
+
+var linksCmd = &cobra.Command{
+	Use:   "links",
+	Short: "List links between notes, todos and projects",
+	Long: `List the [[...]] references written in notes and todos: [[#42]] or
+[[note:42]] for a note, [[todo:17]] for a todo and [[project:work]] for a
+project. Use --broken to show only links to items that are missing or in the
+trash.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		links, err := repository.ListLinks(database.DB, linksBroken)
+		if err != nil {
+			return err
+		}
+
+		if len(links) == 0 {
+			if linksBroken {
+				fmt.Println("No broken links.")
+			} else {
+				fmt.Println("No links.")
+			}
+			return nil
+		}
+
+		fmt.Println(display.FormatLinkList(links))
+		return nil
+	},
+}
+
+func init() {
+	linksCmd.Flags().BoolVar(&linksBroken, "broken", false, "Show only links to missing or trashed items")
+}
@@ -17,6 +17,11 @@ var (
 	listTags      []string
 	listImportant bool
 	listShowIDs   bool
+	listSort      string
+	listReverse   bool
+	listLimit     int
+	listOffset    int
+	listAfter     int
 )
 
 var listCmd = &cobra.Command{
@@ -26,9 +31,18 @@ var listCmd = &cobra.Command{
 		opts := repository.NoteListOptions{
 			Tags:      listTags,
 			Important: listImportant,
+			Sort:      listSort,
+			Reverse:   listReverse,
+			Limit:     listLimit,
+			Offset:    listOffset,
+			AfterID:   listAfter,
 		}
 
-		if listStart == "" && listEnd == "" {
+		// Without a date range the listing defaults to today, unless it is
+		// paged, in which case it pages through everything.
+		paged := listLimit > 0 || listOffset > 0 || listAfter > 0
+
+		if listStart == "" && listEnd == "" && !paged {
 			today := time.Now()
 			opts.StartDate = &today
 			opts.EndDate = &today
@@ -70,4 +84,9 @@ func init() {
 	listCmd.Flags().StringSliceVar(&listTags, "tag", []string{}, "Filter by tags")
 	listCmd.Flags().BoolVar(&listImportant, "important", false, "Show only important notes")
 	listCmd.Flags().BoolVar(&listShowIDs, "show-ids", false, "Show note IDs")
+	listCmd.Flags().StringVar(&listSort, "sort", "created", "Sort by created, updated or important")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+	listCmd.Flags().IntVar(&listLimit, "limit", 0, "Show at most this many notes")
+	listCmd.Flags().IntVar(&listOffset, "offset", 0, "Skip this many notes")
+	listCmd.Flags().IntVar(&listAfter, "after", 0, "Continue after the note with this ID")
 }
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logKind    string
+	logTodo    int
+	logProject string
+	logEvent   string
+	logStart   string
+	logEnd     string
+	logReverse bool
+	logLimit   int
+)
+
+var logEvents = []string{"created", "updated", "completed", "deleted", "restored", "activated", "deactivated", "closed", "reopened"}
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the activity log",
+	Long: `Show what happened to todos and projects: when each was created, updated,
+completed, deleted or restored, and when projects were activated, closed or
+reopened. Entries are listed oldest first.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repository.ActivityListOptions{
+			Kind:    logKind,
+			Event:   logEvent,
+			Reverse: logReverse,
+			Limit:   logLimit,
+		}
+
+		if logKind != "" && logKind != "todo" && logKind != "project" {
+			return fmt.Errorf("invalid kind '%s' (expected todo or project)", logKind)
+		}
+
+		if logEvent != "" && !slices.Contains(logEvents, logEvent) {
+			return fmt.Errorf("invalid event '%s' (expected one of: %s)", logEvent, strings.Join(logEvents, ", "))
+		}
+
+		if logTodo != 0 && logProject != "" {
+			return fmt.Errorf("--todo and --project cannot be used together")
+		}
+
+		if logTodo != 0 {
+			opts.Kind = "todo"
+			opts.EntityID = logTodo
+		}
+
+		if logProject != "" {
+			project, err := repository.GetProjectByName(database.DB, logProject)
+			if err != nil {
+				return err
+			}
+			opts.Kind = "project"
+			opts.EntityID = project.ID
+		}
+
+		if logStart != "" {
+			start, err := dateparse.ParseDate(logStart)
+			if err != nil {
+				return err
+			}
+			opts.StartDate = &start
+		}
+
+		if logEnd != "" {
+			end, err := dateparse.ParseDate(logEnd)
+			if err != nil {
+				return err
+			}
+			opts.EndDate = &end
+		}
+
+		entries, err := repository.ListActivity(database.DB, opts)
+		if err != nil {
+			return err
+		}
+
+		output := display.FormatActivityLog(entries)
+		if output != "" {
+			fmt.Println(output)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	logCmd.Flags().StringVar(&logKind, "kind", "", "Show only todo or project activity")
+	logCmd.Flags().IntVar(&logTodo, "todo", 0, "Show the activity of this todo")
+	logCmd.Flags().StringVar(&logProject, "project", "", "Show the activity of this project")
+	logCmd.Flags().StringVar(&logEvent, "event", "", "Show only this event, such as created or completed")
+	logCmd.Flags().StringVar(&logStart, "start", "", "Start date (YYYY-MM-DD or natural language)")
+	logCmd.Flags().StringVar(&logEnd, "end", "", "End date (YYYY-MM-DD or natural language)")
+	logCmd.Flags().BoolVar(&logReverse, "reverse", false, "Show the newest entries first")
+	logCmd.Flags().IntVar(&logLimit, "limit", 0, "Show at most this many entries")
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var notebookCmd = &cobra.Command{
+	Use:   "notebook",
+	Short: "Manage notebooks",
+	Long:  `Create, list, and switch between notebooks. Each notebook is a separate SQLite database.`,
+}
+
+func init() {
+	notebookCmd.AddCommand(notebookListCmd)
+	notebookCmd.AddCommand(notebookCreateCmd)
+	notebookCmd.AddCommand(notebookUseCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/notebook"
+	"github.com/spf13/cobra"
+)
+
+var notebookCreateCmd = &cobra.Command{
+	Use:   "create <notebook-name>",
+	Short: "Create a new notebook",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		exists, err := notebook.Exists(name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("Notebook '%s' already exists", name)
+		}
+
+		path, err := notebook.Path(name)
+		if err != nil {
+			return err
+		}
+
+		db, err := database.Open(path)
+		if err != nil {
+			return err
+		}
+
+		return db.Close()
+	},
+}
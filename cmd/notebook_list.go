@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/notebook"
+	"github.com/spf13/cobra"
+)
+
+var notebookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List notebooks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		notebooks, err := notebook.List()
+		if err != nil {
+			return err
+		}
+
+		for _, nb := range notebooks {
+			if nb.IsCurrent {
+				fmt.Printf("  * %s (%s)\n", nb.Name, nb.Path)
+			} else {
+				fmt.Printf("    %s (%s)\n", nb.Name, nb.Path)
+			}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/nathan-nicholson/note/internal/notebook"
+	"github.com/spf13/cobra"
+)
+
+var notebookUseCmd = &cobra.Command{
+	Use:   "use <notebook-name>",
+	Short: "Switch the current notebook",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return notebook.Use(args[0])
+	},
+}
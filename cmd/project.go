@@ -5,6 +5,7 @@ import (
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -38,19 +39,24 @@ var projectCmd = &cobra.Command{
 			return nil
 		}
 
-		if err := activity.LogProjectDeactivated(database.DB, currentActive.Name); err != nil {
-			return err
-		}
+		return journal.Run(database.DB, "project", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("active_project", 0); err != nil {
+				return err
+			}
+			if err := op.Track("project", project.ID); err != nil {
+				return err
+			}
 
-		if err := repository.SetActiveProject(database.DB, project.ID); err != nil {
-			return err
-		}
+			if err := activity.LogProjectDeactivated(tx, currentActive.Name); err != nil {
+				return err
+			}
 
-		if err := activity.LogProjectActivated(database.DB, projectName); err != nil {
-			return err
-		}
+			if err := repository.SetActiveProject(tx, project.ID); err != nil {
+				return err
+			}
 
-		return nil
+			return activity.LogProjectActivated(tx, projectName)
+		})
 	},
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -61,44 +62,60 @@ var projectCloseCmd = &cobra.Command{
 			return err
 		}
 
-		if activeProject.Name == projectName {
-			if err := activity.LogProjectDeactivated(database.DB, projectName); err != nil {
+		err = journal.Run(database.DB, "project close", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("project", project.ID); err != nil {
+				return err
+			}
+			if err := op.Track("active_project", 0); err != nil {
 				return err
 			}
 
-			homeProject, err := repository.GetProjectByName(database.DB, "home")
-			if err != nil {
-				openProjects, err := repository.ListProjects(database.DB, false)
-				if err != nil {
+			if activeProject.Name == projectName {
+				if err := activity.LogProjectDeactivated(tx, projectName); err != nil {
 					return err
 				}
 
-				for _, p := range openProjects {
-					if p.Name != projectName {
-						if err := repository.SetActiveProject(database.DB, p.ID); err != nil {
-							return err
-						}
-						if err := activity.LogProjectActivated(database.DB, p.Name); err != nil {
-							return err
+				homeProject, err := repository.GetProjectByName(tx, "home")
+				if err != nil {
+					openProjects, err := repository.ListProjects(tx, false)
+					if err != nil {
+						return err
+					}
+
+					for _, p := range openProjects {
+						if p.Name != projectName {
+							if err := op.Track("project", p.ID); err != nil {
+								return err
+							}
+							if err := repository.SetActiveProject(tx, p.ID); err != nil {
+								return err
+							}
+							if err := activity.LogProjectActivated(tx, p.Name); err != nil {
+								return err
+							}
+							break
 						}
-						break
 					}
-				}
-			} else if !homeProject.IsClosed {
-				if err := repository.SetActiveProject(database.DB, homeProject.ID); err != nil {
-					return err
-				}
-				if err := activity.LogProjectActivated(database.DB, "home"); err != nil {
-					return err
+				} else if !homeProject.IsClosed {
+					if err := op.Track("project", homeProject.ID); err != nil {
+						return err
+					}
+					if err := repository.SetActiveProject(tx, homeProject.ID); err != nil {
+						return err
+					}
+					if err := activity.LogProjectActivated(tx, "home"); err != nil {
+						return err
+					}
 				}
 			}
-		}
 
-		if err := repository.CloseProject(database.DB, project.ID); err != nil {
-			return err
-		}
+			if err := repository.CloseProject(tx, project.ID); err != nil {
+				return err
+			}
 
-		if err := activity.LogProjectClosed(database.DB, projectName); err != nil {
+			return activity.LogProjectClosed(tx, projectName)
+		})
+		if err != nil {
 			return err
 		}
 
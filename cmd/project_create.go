@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -16,16 +17,14 @@ var projectCreateCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		projectName := args[0]
 
-		project, err := repository.CreateProject(database.DB, projectName, projectCreateTags)
-		if err != nil {
-			return err
-		}
+		return journal.Run(database.DB, "project create", func(tx database.DBTX, op *journal.Recorder) error {
+			project, err := repository.CreateProject(tx, projectName, projectCreateTags)
+			if err != nil {
+				return err
+			}
 
-		if err := activity.LogProjectCreated(database.DB, project); err != nil {
-			return err
-		}
-
-		return nil
+			return activity.LogProjectCreated(tx, project)
+		})
 	},
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -24,33 +25,41 @@ var projectDeleteCmd = &cobra.Command{
 			return err
 		}
 
-		if activeProject.Name == projectName {
-			if err := activity.LogProjectDeactivated(database.DB, projectName); err != nil {
+		return journal.Run(database.DB, "project delete", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("project", project.ID); err != nil {
 				return err
 			}
-
-			homeProject, err := repository.GetProjectByName(database.DB, "home")
-			if err != nil {
+			if err := op.Track("active_project", 0); err != nil {
 				return err
 			}
 
-			if err := repository.SetActiveProject(database.DB, homeProject.ID); err != nil {
-				return err
+			if activeProject.Name == projectName {
+				if err := activity.LogProjectDeactivated(tx, projectName); err != nil {
+					return err
+				}
+
+				homeProject, err := repository.GetProjectByName(tx, "home")
+				if err != nil {
+					return err
+				}
+
+				if err := op.Track("project", homeProject.ID); err != nil {
+					return err
+				}
+				if err := repository.SetActiveProject(tx, homeProject.ID); err != nil {
+					return err
+				}
+
+				if err := activity.LogProjectActivated(tx, "home"); err != nil {
+					return err
+				}
 			}
 
-			if err := activity.LogProjectActivated(database.DB, "home"); err != nil {
+			if err := activity.LogProjectDeleted(tx, project); err != nil {
 				return err
 			}
-		}
-
-		if err := activity.LogProjectDeleted(database.DB, project); err != nil {
-			return err
-		}
-
-		if err := repository.DeleteProject(database.DB, projectName); err != nil {
-			return err
-		}
 
-		return nil
+			return repository.DeleteProject(tx, projectName)
+		})
 	},
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -23,23 +24,29 @@ var projectEditCmd = &cobra.Command{
 			return err
 		}
 
-		if len(projectEditTags) > 0 {
-			if err := repository.UpdateProjectTags(database.DB, project.ID, projectEditTags); err != nil {
+		return journal.Run(database.DB, "project edit", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("project", project.ID); err != nil {
 				return err
 			}
 
-			formattedTags := make([]string, len(projectEditTags))
-			for i, tag := range projectEditTags {
-				formattedTags[i] = "#" + tag
-			}
-			changes := "Updated tags to " + strings.Join(formattedTags, " ")
+			if len(projectEditTags) > 0 {
+				if err := repository.UpdateProjectTags(tx, project.ID, projectEditTags); err != nil {
+					return err
+				}
 
-			if err := activity.LogProjectUpdated(database.DB, projectName, changes); err != nil {
-				return err
+				formattedTags := make([]string, len(projectEditTags))
+				for i, tag := range projectEditTags {
+					formattedTags[i] = "#" + tag
+				}
+				changes := "Updated tags to " + strings.Join(formattedTags, " ")
+
+				if err := activity.LogProjectUpdated(tx, project, changes); err != nil {
+					return err
+				}
 			}
-		}
 
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -19,14 +20,16 @@ var projectReopenCmd = &cobra.Command{
 			return err
 		}
 
-		if err := repository.ReopenProject(database.DB, project.ID); err != nil {
-			return err
-		}
+		return journal.Run(database.DB, "project reopen", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("project", project.ID); err != nil {
+				return err
+			}
 
-		if err := activity.LogProjectReopened(database.DB, projectName); err != nil {
-			return err
-		}
+			if err := repository.ReopenProject(tx, project.ID); err != nil {
+				return err
+			}
 
-		return nil
+			return activity.LogProjectReopened(tx, projectName)
+		})
 	},
 }
@@ -21,7 +21,16 @@ var projectShowCmd = &cobra.Command{
 			return err
 		}
 
+		backlinks, err := repository.GetBacklinks(database.DB, "project", project.Name)
+		if err != nil {
+			return err
+		}
+
 		fmt.Println(display.FormatProject(project))
+		if links := display.FormatLinks(nil, backlinks); links != "" {
+			fmt.Println()
+			fmt.Println(links)
+		}
 		return nil
 	},
 }
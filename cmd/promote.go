@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var promoteDue string
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <note-id>",
+	Short: "Create a todo from a note",
+	Long: `Create a todo with the content and tags of a note. The note is kept, and
+note todo show links back to it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		dueDate, err := parseDueDate(promoteDue)
+		if err != nil {
+			return err
+		}
+
+		var todo *models.Todo
+		err = journal.Run(database.DB, "promote", func(tx database.DBTX, op *journal.Recorder) error {
+			var err error
+			todo, err = repository.PromoteNote(tx, id, dueDate)
+			if err != nil {
+				return err
+			}
+
+			return activity.LogTodoCreated(tx, todo)
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Promoted note #%d to todo #%d.\n", id, todo.ID)
+		return nil
+	},
+}
+
+func init() {
+	promoteCmd.Flags().StringVar(&promoteDue, "due", "", "Due date (YYYY-MM-DD or natural language)")
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+var redoCmd = &cobra.Command{
+	Use:   "redo [n]",
+	Short: "Redo the last n undone operations",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseOperationCount(args)
+		if err != nil {
+			return err
+		}
+
+		operations, err := journal.Redo(database.DB, n)
+		printOperations("Redid", operations)
+		return err
+	},
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var revertCmd = &cobra.Command{
+	Use:   "revert <id> <rev>",
+	Short: "Restore a note to an earlier revision",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		return journal.Run(database.DB, "revert", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("note", id); err != nil {
+				return err
+			}
+
+			revision, err := parseRevision(args[1])
+			if err != nil {
+				return err
+			}
+
+			return repository.RevertNote(tx, id, revision)
+		})
+	},
+}
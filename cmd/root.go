@@ -1,62 +1,174 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/notebook"
 	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/nathan-nicholson/note/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 var (
-	rootTags      []string
-	rootImportant bool
+	rootTags       []string
+	rootImportant  bool
+	rootDBPath     string
+	rootNotebook   string
+	rootBusyWait   time.Duration
+	rootKeyFile    string
+	rootSplitLines bool
+	rootDryRun     bool
+
+	// rootResolvedDBPath is the database the command runs against.
+	rootResolvedDBPath string
 )
 
+// skipUnlock marks commands that never read or write note and todo content,
+// so they run without asking for the passphrase of an encrypted database.
+const skipUnlock = "skipUnlock"
+
 var rootCmd = &cobra.Command{
-	Use:   "note [content]",
+	Use:   "note [content...]",
 	Short: "A lightweight CLI tool for capturing notes and managing todos",
 	Long:  `note is a fast, keyboard-driven tool for capturing thoughts and tasks with project-based organization.`,
 	Args:  cobra.ArbitraryArgs,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return cmd.Help()
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, err := notebook.ResolveDBPath(rootDBPath, rootNotebook)
+		if err != nil {
+			return err
 		}
 
-		content := args[0]
-
-		activeProject, err := repository.GetActiveProject(database.DB)
+		busyTimeout, err := resolveBusyTimeout(cmd)
 		if err != nil {
 			return err
 		}
+		database.BusyTimeout = busyTimeout
+		rootResolvedDBPath = dbPath
 
-		tags := append(rootTags, activeProject.Name)
+		if err := database.InitDB(dbPath); err != nil {
+			return err
+		}
 
-		_, err = repository.CreateNote(database.DB, content, tags, rootImportant)
+		if !needsUnlock(cmd) {
+			return nil
+		}
+		return unlockDatabase()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		content, ok, err := readContent(args)
 		if err != nil {
 			return err
 		}
+		if !ok {
+			return cmd.Help()
+		}
 
-		if err := repository.UpdateProjectLastActivity(database.DB, activeProject.ID); err != nil {
+		items, err := contentItems(content, rootSplitLines)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		return addNotes("note", parseItems(items, false, typedContent(args)), rootTags, rootImportant, rootDryRun)
 	},
 }
 
+// resolveBusyTimeout prefers --busy-timeout, then $NOTE_BUSY_TIMEOUT, then the
+// default.
+func resolveBusyTimeout(cmd *cobra.Command) (time.Duration, error) {
+	if cmd.Flags().Changed("busy-timeout") {
+		if rootBusyWait < 0 {
+			return 0, fmt.Errorf("--busy-timeout cannot be negative")
+		}
+		return rootBusyWait, nil
+	}
+
+	value := os.Getenv("NOTE_BUSY_TIMEOUT")
+	if value == "" {
+		return database.DefaultBusyTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid NOTE_BUSY_TIMEOUT '%s' (expected a duration such as 10s)", value)
+	}
+	return timeout, nil
+}
+
+func needsUnlock(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	}
+
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[skipUnlock] == "true" {
+			return false
+		}
+	}
+	return true
+}
+
+func unlockDatabase() error {
+	encrypted, err := database.Encrypted(database.DB)
+	if err != nil || !encrypted {
+		return err
+	}
+
+	passphrase, err := vault.ReadPassphrase(rootKeyFile, false)
+	if err != nil {
+		return err
+	}
+
+	return repository.Unlock(database.DB, passphrase)
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&rootDBPath, "db", "", "Path to the database file (overrides --notebook and $NOTE_DB)")
+	rootCmd.PersistentFlags().StringVar(&rootNotebook, "notebook", "", "Notebook to use for this command")
+	rootCmd.PersistentFlags().DurationVar(&rootBusyWait, "busy-timeout", database.DefaultBusyTimeout, "How long to wait for another process to release the database (overrides $NOTE_BUSY_TIMEOUT)")
+	rootCmd.PersistentFlags().StringVar(&rootKeyFile, "key-file", "", "File holding the passphrase of an encrypted database (overrides $NOTE_KEY_FILE)")
+
 	rootCmd.Flags().StringSliceVar(&rootTags, "tag", []string{}, "Tags for the note")
 	rootCmd.Flags().BoolVar(&rootImportant, "important", false, "Mark note as important")
+	rootCmd.Flags().BoolVar(&rootSplitLines, "split-lines", false, "Create a note for each line of the content")
+	rootCmd.Flags().BoolVar(&rootDryRun, "dry-run", false, "Show the notes that would be added without saving them")
 
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(todayCmd)
+	rootCmd.AddCommand(dayCmd)
+	rootCmd.AddCommand(logCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(revertCmd)
+	rootCmd.AddCommand(promoteCmd)
 	rootCmd.AddCommand(tagsCmd)
+	rootCmd.AddCommand(linksCmd)
+	rootCmd.AddCommand(attachCmd)
+	rootCmd.AddCommand(attachmentsCmd)
+	rootCmd.AddCommand(detachCmd)
 	rootCmd.AddCommand(todoCmd)
 	rootCmd.AddCommand(projectCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(notebookCmd)
+	rootCmd.AddCommand(templateCmd)
+
+	for _, c := range []*cobra.Command{versionCmd, updateCmd, notebookCmd, templateCmd, doctorCmd, dbMigrateCmd, dbEncryptCmd} {
+		c.Annotations = map[string]string{skipUnlock: "true"}
+	}
 }
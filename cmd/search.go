@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchStart     string
+	searchEnd       string
+	searchTags      []string
+	searchImportant bool
+	searchLimit     int
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search notes and todos",
+	Long: `Full-text search across notes and todos, best matches first.
+
+Use quotes for phrases ("release plan"), a trailing * for prefixes (deploy*),
+and AND, OR or NOT to combine terms.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := repository.SearchOptions{
+			NoteListOptions: repository.NoteListOptions{
+				Tags:      searchTags,
+				Important: searchImportant,
+			},
+			Limit: searchLimit,
+		}
+
+		if searchStart != "" {
+			start, err := dateparse.ParseDate(searchStart)
+			if err != nil {
+				return err
+			}
+			opts.StartDate = &start
+		}
+
+		if searchEnd != "" {
+			end, err := dateparse.ParseDate(searchEnd)
+			if err != nil {
+				return err
+			}
+			opts.EndDate = &end
+		}
+
+		results, err := repository.Search(database.DB, strings.Join(args, " "), opts)
+		if err != nil {
+			return err
+		}
+
+		output := display.FormatSearchResults(results)
+		if output != "" {
+			fmt.Println(output)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchStart, "start", "", "Start date (YYYY-MM-DD or natural language)")
+	searchCmd.Flags().StringVar(&searchEnd, "end", "", "End date (YYYY-MM-DD or natural language)")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", []string{}, "Filter by tags")
+	searchCmd.Flags().BoolVar(&searchImportant, "important", false, "Show only important notes")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Maximum number of results (0 for all)")
+}
@@ -25,7 +25,21 @@ var showCmd = &cobra.Command{
 			return err
 		}
 
+		outgoing, err := repository.GetLinks(database.DB, "note", id)
+		if err != nil {
+			return err
+		}
+
+		backlinks, err := repository.GetBacklinks(database.DB, "note", strconv.Itoa(id))
+		if err != nil {
+			return err
+		}
+
 		fmt.Println(display.FormatNote(note))
+		if links := display.FormatLinks(outgoing, backlinks); links != "" {
+			fmt.Println()
+			fmt.Println(links)
+		}
 		return nil
 	},
 }
@@ -18,11 +18,7 @@ var tagsCmd = &cobra.Command{
 			return err
 		}
 
-		output, err := display.FormatTagList(database.DB, tags)
-		if err != nil {
-			return err
-		}
-
+		output := display.FormatTagList(tags)
 		if output != "" {
 			fmt.Println(output)
 		}
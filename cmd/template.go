@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/notebook"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/nathan-nicholson/note/internal/templates"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage note templates",
+	Long: `Create, list, edit and delete templates for notes, used with
+note add --template <name>.
+
+Templates may use {{date}}, {{time}}, {{weekday}} and {{project}}, any
+variable given with --var name=value, and {{prompt "Label"}} to ask for a
+value when the note is created. Templates can also be kept as files in
+~/.note/templates/<name>.md; a template in the database takes precedence over
+a file of the same name.`,
+}
+
+func templateDir() (string, error) {
+	dataDir, err := notebook.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "templates"), nil
+}
+
+// findTemplate looks for a template in the database, then in the template
+// directory.
+func findTemplate(name string) (*models.Template, error) {
+	t, err := repository.GetTemplate(database.DB, name)
+	if err != nil || t != nil {
+		return t, err
+	}
+
+	dir, err := templateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err = templates.Load(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("Template '%s' not found", name)
+	}
+	return t, nil
+}
+
+// requireStoredTemplate refuses to change a template that is read from a
+// file, which should be edited in place instead.
+func requireStoredTemplate(name string) error {
+	t, err := findTemplate(name)
+	if err != nil {
+		return err
+	}
+	if t.Path != "" {
+		return fmt.Errorf("Template '%s' is read from %s; change that file instead", name, t.Path)
+	}
+	return nil
+}
+
+// promptTemplateValue asks for the value of a {{prompt "Label"}} placeholder
+// on the terminal.
+func promptTemplateValue(label string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("the template asks for %q; give it with --var \"%s=...\"", label, strings.ToLower(label))
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("could not read %s: %w", label, err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func init() {
+	templateCmd.AddCommand(templateCreateCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateEditCmd)
+	templateCmd.AddCommand(templateDeleteCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateCreateContent   string
+	templateCreateTags      []string
+	templateCreateImportant bool
+)
+
+var templateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a template",
+	Long: `Create a template. Without --content, $VISUAL or $EDITOR is opened to write
+it, with the default tags and importance of its notes in a header above the
+content.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		draft := noteDraft{content: templateCreateContent, tags: templateCreateTags, important: templateCreateImportant}
+
+		if !cmd.Flags().Changed("content") {
+			composed, err := composeNote(draft)
+			if err != nil {
+				return err
+			}
+			draft = composed
+		}
+
+		if _, err := repository.CreateTemplate(database.DB, name, draft.content, draft.tags, draft.important); err != nil {
+			return err
+		}
+
+		fmt.Printf("Created template '%s'.\n", name)
+		return nil
+	},
+}
+
+func init() {
+	templateCreateCmd.Flags().StringVar(&templateCreateContent, "content", "", "Content of the template")
+	templateCreateCmd.Flags().StringSliceVar(&templateCreateTags, "tag", []string{}, "Tags for notes created from the template")
+	templateCreateCmd.Flags().BoolVar(&templateCreateImportant, "important", false, "Mark notes created from the template as important")
+}
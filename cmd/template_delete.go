@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var templateDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a template",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if err := requireStoredTemplate(name); err != nil {
+			return err
+		}
+
+		if err := repository.DeleteTemplate(database.DB, name); err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted template '%s'.\n", name)
+		return nil
+	},
+}
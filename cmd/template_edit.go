@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	templateEditContent   string
+	templateEditTags      []string
+	templateEditImportant bool
+)
+
+var templateEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a template",
+	Long: `Edit a template. Without --content, --tag or --important, the template is
+opened in $VISUAL or $EDITOR.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if err := requireStoredTemplate(name); err != nil {
+			return err
+		}
+
+		var content *string
+		var important *bool
+		var tags []string
+
+		if cmd.Flags().Changed("content") {
+			content = &templateEditContent
+		}
+		if cmd.Flags().Changed("important") {
+			important = &templateEditImportant
+		}
+		if cmd.Flags().Changed("tag") {
+			tags = templateEditTags
+		}
+
+		if content == nil && important == nil && tags == nil {
+			t, err := repository.GetTemplate(database.DB, name)
+			if err != nil {
+				return err
+			}
+
+			draft, err := composeNote(noteDraft{content: t.Content, tags: t.Tags, important: t.IsImportant})
+			if err != nil {
+				return err
+			}
+
+			if draft.content != t.Content {
+				content = &draft.content
+			}
+			if draft.important != t.IsImportant {
+				important = &draft.important
+			}
+			if !sameTags(draft.tags, t.Tags) {
+				tags = append([]string{}, draft.tags...)
+			}
+
+			if content == nil && important == nil && tags == nil {
+				fmt.Println("No changes.")
+				return nil
+			}
+		}
+
+		if err := repository.UpdateTemplate(database.DB, name, content, tags, important); err != nil {
+			return err
+		}
+
+		fmt.Printf("Updated template '%s'.\n", name)
+		return nil
+	},
+}
+
+func init() {
+	templateEditCmd.Flags().StringVar(&templateEditContent, "content", "", "New content for the template")
+	templateEditCmd.Flags().StringSliceVar(&templateEditTags, "tag", []string{}, "Replace the tags for notes created from the template")
+	templateEditCmd.Flags().BoolVar(&templateEditImportant, "important", false, "Mark notes created from the template as important")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/nathan-nicholson/note/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stored, err := repository.ListTemplates(database.DB)
+		if err != nil {
+			return err
+		}
+
+		dir, err := templateDir()
+		if err != nil {
+			return err
+		}
+		files, err := templates.LoadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		// Files hidden by a template of the same name are left out.
+		names := map[string]bool{}
+		for _, t := range stored {
+			names[t.Name] = true
+		}
+		list := stored
+		for _, t := range files {
+			if !names[t.Name] {
+				list = append(list, t)
+			}
+		}
+
+		if len(list) == 0 {
+			fmt.Println("No templates.")
+			return nil
+		}
+
+		slices.SortFunc(list, func(a, b models.Template) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		fmt.Println(display.FormatTemplateList(list))
+		return nil
+	},
+}
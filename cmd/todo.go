@@ -18,13 +18,30 @@ func init() {
 	todoCmd.AddCommand(todoShowCmd)
 	todoCmd.AddCommand(todoCompleteCmd)
 	todoCmd.AddCommand(todoUncompleteCmd)
+	todoCmd.AddCommand(todoSkipCmd)
+	todoCmd.AddCommand(todoNextCmd)
+	todoCmd.AddCommand(todoBlockCmd)
+	todoCmd.AddCommand(todoUnblockCmd)
+	todoCmd.AddCommand(todoGraphCmd)
+	todoCmd.AddCommand(todoHistoryCmd)
+	todoCmd.AddCommand(todoDiffCmd)
+	todoCmd.AddCommand(todoRevertCmd)
+	todoCmd.AddCommand(todoNoteCmd)
+	todoCmd.AddCommand(todoAttachCmd)
+	todoCmd.AddCommand(todoAttachmentsCmd)
+	todoCmd.AddCommand(todoDetachCmd)
 
 	todoCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if len(args) > 0 {
+		if len(args) > 0 || stdinPiped() {
 			return todoAddCmd.RunE(cmd, args)
 		}
 		return cmd.Help()
 	}
 	todoCmd.Flags().StringSliceVar(&todoAddTags, "tag", []string{}, "Tags for the todo")
 	todoCmd.Flags().StringVar(&todoAddDue, "due", "", "Due date (YYYY-MM-DD or natural language)")
+	todoCmd.Flags().StringVar(&todoAddRepeat, "repeat", "", "Make the todo recurring (daily, weekly:mon,thu, monthly:last, every 2 weeks or an RRULE)")
+	todoCmd.Flags().StringVar(&todoAddPriority, "priority", "", "Priority: P0 to P3, or critical, high, medium or low")
+	todoCmd.Flags().IntVar(&todoAddParent, "parent", 0, "Add the todo as a subtask of this todo")
+	todoCmd.Flags().BoolVar(&todoAddSplitLines, "split-lines", false, "Create a todo for each line of the content")
+	todoCmd.Flags().BoolVar(&todoAddDryRun, "dry-run", false, "Show the todos that would be added without saving them")
 }
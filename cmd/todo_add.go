@@ -1,61 +1,160 @@
 package cmd
 
 import (
-	"time"
+	"fmt"
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
-	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
 
 var (
-	todoAddTags []string
-	todoAddDue  string
+	todoAddTags       []string
+	todoAddDue        string
+	todoAddRepeat     string
+	todoAddParent     int
+	todoAddPriority   string
+	todoAddSplitLines bool
+	todoAddDryRun     bool
 )
 
 var todoAddCmd = &cobra.Command{
-	Use:   "add <content>",
+	Use:   "add [content...]",
 	Short: "Create a new todo",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Create a new todo. Arguments are joined into one todo. Use - or pipe
+input in to read the content from standard input, and --split-lines to create
+a todo per line.
+
+Without content, $VISUAL or $EDITOR is opened to write the todo, with the tags
+and due date in a header above the content.
+
+Tags and a due date can also be typed into content given as arguments: a #tag
+anywhere tags the todo, and #tags and an @date (such as @tomorrow or
+@2025-12-31) at the end are taken out of the text. Piped and edited content is
+saved as it is. --due takes precedence over an @date. --dry-run
+shows the result without saving.
+
+--repeat makes the todo recurring: daily, weekly, weekly:mon,thu, monthly,
+monthly:1,15 or monthly:last, yearly, weekdays, every 2 weeks (optionally with
+days, as in every 2 weeks:mon,thu), or an RRULE using FREQ, INTERVAL, BYDAY and
+BYMONTHDAY such as FREQ=WEEKLY;BYDAY=MO,TH. Completing a recurring todo creates
+the next one, due on the next occurrence. Without a due date, a recurring todo
+is due on its first occurrence from today.
+
+--priority sets how urgent the todo is, from P0 to P3 or as critical, high,
+medium or low. Todos are listed by priority within each due date group.
+
+--parent adds the todo as a subtask of another; with --split-lines, each line
+becomes a step of that todo's checklist.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		content := args[0]
+		draft := todoDraft{tags: todoAddTags, due: todoAddDue, repeat: todoAddRepeat, priority: todoAddPriority}
 
-		activeProject, err := repository.GetActiveProject(database.DB)
+		content, ok, err := readContent(args)
 		if err != nil {
 			return err
 		}
 
-		tags := append(todoAddTags, activeProject.Name)
-
-		var dueDate *time.Time
-		if todoAddDue != "" {
-			parsed, err := dateparse.ParseDate(todoAddDue)
+		if ok {
+			draft.content = content
+		} else {
+			composed, err := composeTodo(draft)
 			if err != nil {
 				return err
 			}
-			dueDate = &parsed
+			draft = composed
 		}
 
-		todo, err := repository.CreateTodo(database.DB, content, tags, dueDate)
+		items, err := contentItems(draft.content, todoAddSplitLines)
 		if err != nil {
 			return err
 		}
 
-		if err := repository.UpdateProjectLastActivity(database.DB, activeProject.ID); err != nil {
+		dueDate, err := parseDueDate(draft.due)
+		if err != nil {
 			return err
 		}
 
-		if err := activity.LogTodoCreated(database.DB, todo); err != nil {
+		repeat, err := parseRepeat(draft.repeat)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		priority, err := parsePriority(draft.priority)
+		if err != nil {
+			return err
+		}
+
+		parsed := parseItems(items, true, typedContent(args))
+		if todoAddDryRun {
+			return previewItems("todo", parsed, draft.tags, false, dueDate, repeat, priority)
+		}
+
+		return journal.Run(database.DB, "todo add", func(tx database.DBTX, op *journal.Recorder) error {
+			activeProject, err := repository.GetActiveProject(tx)
+			if err != nil {
+				return err
+			}
+
+			if todoAddParent != 0 {
+				parent, err := repository.GetTodoByID(tx, todoAddParent)
+				if err != nil {
+					return err
+				}
+				if parent.IsComplete {
+					return fmt.Errorf("Todo #%d is complete; uncomplete it before adding subtasks", parent.ID)
+				}
+			}
+
+			if err := op.Track("project", activeProject.ID); err != nil {
+				return err
+			}
+
+			for _, item := range parsed {
+				tags := mergeTags(draft.tags, item.Tags, []string{activeProject.Name})
+				todo, err := repository.CreateTodo(tx, item.Content, tags, firstDue(dueOrDefault(dueDate, item.Due), repeat))
+				if err != nil {
+					return err
+				}
+
+				if repeat != nil {
+					if err := repository.SetTodoRepeat(tx, todo.ID, repeat.String()); err != nil {
+						return err
+					}
+					todo.Repeat = repeat.String()
+				}
+
+				if priority != "" {
+					if err := repository.SetTodoPriority(tx, todo.ID, priority); err != nil {
+						return err
+					}
+					todo.Priority = priority
+				}
+
+				if todoAddParent != 0 {
+					if err := repository.SetTodoParent(tx, todo.ID, todoAddParent); err != nil {
+						return err
+					}
+					todo.ParentID = todoAddParent
+				}
+
+				if err := activity.LogTodoCreated(tx, todo); err != nil {
+					return err
+				}
+			}
+
+			return repository.UpdateProjectLastActivity(tx, activeProject.ID)
+		})
 	},
 }
 
 func init() {
 	todoAddCmd.Flags().StringSliceVar(&todoAddTags, "tag", []string{}, "Tags for the todo")
 	todoAddCmd.Flags().StringVar(&todoAddDue, "due", "", "Due date (YYYY-MM-DD or natural language)")
+	todoAddCmd.Flags().StringVar(&todoAddRepeat, "repeat", "", "Make the todo recurring (daily, weekly:mon,thu, monthly:last, every 2 weeks or an RRULE)")
+	todoAddCmd.Flags().StringVar(&todoAddPriority, "priority", "", "Priority: P0 to P3, or critical, high, medium or low")
+	todoAddCmd.Flags().IntVar(&todoAddParent, "parent", 0, "Add the todo as a subtask of this todo")
+	todoAddCmd.Flags().BoolVar(&todoAddSplitLines, "split-lines", false, "Create a todo for each line of the content")
+	todoAddCmd.Flags().BoolVar(&todoAddDryRun, "dry-run", false, "Show the todos that would be added without saving them")
 }
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var todoAttachCmd = &cobra.Command{
+	Use:   "attach <id> <file>...",
+	Short: "Attach files to a todo",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return attachFiles("todo", args)
+	},
+}
+
+var todoAttachmentsCmd = &cobra.Command{
+	Use:   "attachments <id>",
+	Short: "List the files attached to a todo",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listAttachments("todo", args)
+	},
+}
+
+var todoDetachCmd = &cobra.Command{
+	Use:   "detach <id> <attachment>",
+	Short: "Remove an attachment from a todo",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return detachFile("todo", args)
+	},
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	todoBlockBy   int
+	todoUnblockBy int
+)
+
+var todoBlockCmd = &cobra.Command{
+	Use:   "block <id> --by <other-id>",
+	Short: "Record that a todo can't start until another is done",
+	Long: `Record that a todo can't start until another is done. The todo shows as
+blocked and is left out of 'note todo next' until the other todo is complete.
+A todo can wait on several others, but never on itself, directly or through
+the todos it waits on.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = journal.Run(database.DB, "todo block", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			before, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := repository.AddDependency(tx, id, todoBlockBy); err != nil {
+				return err
+			}
+
+			return activity.LogTodoUpdated(tx, before, []string{fmt.Sprintf("Blocked by #%d", todoBlockBy)})
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Todo #%d is blocked by #%d.\n", id, todoBlockBy)
+		return nil
+	},
+}
+
+var todoUnblockCmd = &cobra.Command{
+	Use:   "unblock <id> --by <other-id>",
+	Short: "Remove a dependency recorded with block",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = journal.Run(database.DB, "todo unblock", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			before, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := repository.RemoveDependency(tx, id, todoUnblockBy); err != nil {
+				return err
+			}
+
+			return activity.LogTodoUpdated(tx, before, []string{fmt.Sprintf("No longer blocked by #%d", todoUnblockBy)})
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Todo #%d is no longer blocked by #%d.\n", id, todoUnblockBy)
+		return nil
+	},
+}
+
+func init() {
+	todoBlockCmd.Flags().IntVar(&todoBlockBy, "by", 0, "The todo that has to be done first")
+	todoBlockCmd.MarkFlagRequired("by")
+	todoUnblockCmd.Flags().IntVar(&todoUnblockBy, "by", 0, "The todo it no longer waits on")
+	todoUnblockCmd.MarkFlagRequired("by")
+}
@@ -1,37 +1,108 @@
 package cmd
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/models"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
 
+var todoCompleteCascade bool
+
 var todoCompleteCmd = &cobra.Command{
 	Use:   "complete <id>",
 	Short: "Mark a todo as complete",
-	Args:  cobra.ExactArgs(1),
+	Long: `Mark a todo as complete. A todo with open subtasks cannot be completed
+until they are, unless --cascade is given to complete them all with it. Todos
+that were waiting only on the completed todos are reported as unblocked.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, err := strconv.Atoi(args[0])
 		if err != nil {
 			return err
 		}
 
-		todo, err := repository.GetTodoByID(database.DB, id)
+		var next []*models.Todo
+		var unblocked []models.Todo
+
+		err = journal.Run(database.DB, "todo complete", func(tx database.DBTX, op *journal.Recorder) error {
+			next, unblocked = nil, nil
+
+			todo, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			subtasks, err := repository.OpenSubtasks(tx, id)
+			if err != nil {
+				return err
+			}
+
+			if len(subtasks) > 0 && !todoCompleteCascade {
+				return fmt.Errorf("Todo #%d has %d open subtasks. Complete them first, or use --cascade to complete them too", id, len(subtasks))
+			}
+
+			// Subtasks come after their parents, so each parent is complete
+			// before its subtasks are, and a recurring subtask's next instance
+			// is not filed under a parent that is done.
+			todos := append([]models.Todo{*todo}, subtasks...)
+			for i := range todos {
+				if err := op.Track("todo", todos[i].ID); err != nil {
+					return err
+				}
+
+				created, err := repository.CompleteTodo(tx, todos[i].ID)
+				if err != nil {
+					return err
+				}
+
+				if err := activity.LogTodoCompleted(tx, &todos[i]); err != nil {
+					return err
+				}
+
+				if created != nil {
+					if err := activity.LogTodoCreated(tx, created); err != nil {
+						return err
+					}
+					next = append(next, created)
+				}
+			}
+
+			seen := make(map[int]bool)
+			for _, completed := range todos {
+				waiting, err := repository.Unblocked(tx, completed.ID)
+				if err != nil {
+					return err
+				}
+				for _, todo := range waiting {
+					if !seen[todo.ID] {
+						seen[todo.ID] = true
+						unblocked = append(unblocked, todo)
+					}
+				}
+			}
+
+			return nil
+		})
 		if err != nil {
 			return err
 		}
 
-		if err := repository.CompleteTodo(database.DB, id); err != nil {
-			return err
+		for _, todo := range next {
+			fmt.Printf("Next: todo #%d, due %s.\n", todo.ID, todo.DueDate.Time.Format("2006-01-02"))
 		}
-
-		if err := activity.LogTodoCompleted(database.DB, todo); err != nil {
-			return err
+		for _, todo := range unblocked {
+			fmt.Printf("Unblocked: todo #%d %s\n", todo.ID, todo.Content)
 		}
-
 		return nil
 	},
 }
+
+func init() {
+	todoCompleteCmd.Flags().BoolVar(&todoCompleteCascade, "cascade", false, "Also complete the todo's open subtasks")
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -19,19 +20,21 @@ var todoDeleteCmd = &cobra.Command{
 			return err
 		}
 
-		todo, err := repository.GetTodoByID(database.DB, id)
-		if err != nil {
-			return err
-		}
+		return journal.Run(database.DB, "todo delete", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
 
-		if err := repository.DeleteTodo(database.DB, id); err != nil {
-			return err
-		}
+			todo, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
 
-		if err := activity.LogTodoDeleted(database.DB, todo); err != nil {
-			return err
-		}
+			if err := repository.DeleteTodo(tx, id); err != nil {
+				return err
+			}
 
-		return nil
+			return activity.LogTodoDeleted(tx, todo)
+		})
 	},
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -8,76 +9,194 @@ import (
 	"github.com/nathan-nicholson/note/internal/activity"
 	"github.com/nathan-nicholson/note/internal/database"
 	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
 
 var (
-	todoEditContent string
-	todoEditTags    []string
-	todoEditDue     string
+	todoEditContent  string
+	todoEditTags     []string
+	todoEditDue      string
+	todoEditRepeat   string
+	todoEditPriority string
 )
 
 var todoEditCmd = &cobra.Command{
 	Use:   "edit <id>",
 	Short: "Edit a todo",
-	Args:  cobra.ExactArgs(1),
+	Long: `Edit a todo. Without --content, --tag, --due, --repeat or --priority, the
+todo is opened in $VISUAL or $EDITOR with its tags, due date, recurrence and
+priority in a header above the content. An empty --repeat stops the todo
+recurring and an empty --priority clears its priority.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		id, err := strconv.Atoi(args[0])
 		if err != nil {
 			return err
 		}
 
-		oldTodo, err := repository.GetTodoByID(database.DB, id)
-		if err != nil {
-			return err
-		}
-
 		var content *string
 		var dueDate *time.Time
 		clearDueDate := false
+		var repeat *string
+		var priority *string
+		tags := todoEditTags
 
 		var changes []string
 
 		if cmd.Flags().Changed("content") {
 			content = &todoEditContent
-			changes = append(changes, "Updated content to \""+todoEditContent+"\"")
 		}
 
 		if cmd.Flags().Changed("due") {
 			if todoEditDue == "" {
 				clearDueDate = true
-				changes = append(changes, "Removed due date")
 			} else {
 				parsed, err := dateparse.ParseDate(todoEditDue)
 				if err != nil {
 					return err
 				}
 				dueDate = &parsed
-				changes = append(changes, "due date to "+parsed.Format("2006-01-02"))
 			}
 		}
 
-		if len(todoEditTags) > 0 {
-			changes = append(changes, "tags to "+strings.Join(formatTags(todoEditTags), " "))
+		if cmd.Flags().Changed("repeat") {
+			parsed, err := parseRepeat(todoEditRepeat)
+			if err != nil {
+				return err
+			}
+			value := ""
+			if parsed != nil {
+				value = parsed.String()
+			}
+			repeat = &value
 		}
 
-		if err := repository.UpdateTodo(database.DB, id, content, todoEditTags, dueDate, clearDueDate); err != nil {
-			return err
+		if cmd.Flags().Changed("priority") {
+			parsed, err := parsePriority(todoEditPriority)
+			if err != nil {
+				return err
+			}
+			priority = &parsed
 		}
 
-		if len(changes) > 0 {
-			newTodo, err := repository.GetTodoByID(database.DB, id)
+		if content == nil && !cmd.Flags().Changed("due") && repeat == nil && priority == nil && len(tags) == 0 {
+			todo, err := repository.GetTodoByID(database.DB, id)
+			if err != nil {
+				return err
+			}
+
+			currentDue := ""
+			if todo.DueDate.Valid {
+				currentDue = todo.DueDate.Time.Format("2006-01-02")
+			}
+
+			draft, err := composeTodo(todoDraft{content: todo.Content, tags: todo.Tags, due: currentDue, repeat: todo.Repeat, priority: todo.Priority})
 			if err != nil {
 				return err
 			}
-			_ = oldTodo
-			if err := activity.LogTodoUpdated(database.DB, newTodo, changes); err != nil {
+
+			if draft.content != todo.Content {
+				content = &draft.content
+			}
+
+			parsed, err := parseDueDate(draft.due)
+			if err != nil {
 				return err
 			}
+			if parsed == nil && currentDue != "" {
+				clearDueDate = true
+			} else if parsed != nil && parsed.Format("2006-01-02") != currentDue {
+				dueDate = parsed
+			}
+
+			parsedRepeat, err := parseRepeat(draft.repeat)
+			if err != nil {
+				return err
+			}
+			if parsedRepeat == nil && todo.Repeat != "" {
+				repeat = new(string)
+			} else if parsedRepeat != nil && parsedRepeat.String() != todo.Repeat {
+				value := parsedRepeat.String()
+				repeat = &value
+			}
+
+			parsedPriority, err := parsePriority(draft.priority)
+			if err != nil {
+				return err
+			}
+			if parsedPriority != todo.Priority {
+				priority = &parsedPriority
+			}
+
+			if !sameTags(draft.tags, todo.Tags) {
+				if len(draft.tags) == 0 {
+					return fmt.Errorf("a todo's tags can be replaced but not all removed")
+				}
+				tags = draft.tags
+			}
+
+			if content == nil && dueDate == nil && !clearDueDate && repeat == nil && priority == nil && len(tags) == 0 {
+				fmt.Println("No changes.")
+				return nil
+			}
+		}
+
+		if content != nil {
+			changes = append(changes, "Updated content to \""+*content+"\"")
+		}
+
+		if clearDueDate {
+			changes = append(changes, "Removed due date")
+		} else if dueDate != nil {
+			changes = append(changes, "due date to "+dueDate.Format("2006-01-02"))
 		}
 
-		return nil
+		if repeat != nil && *repeat == "" {
+			changes = append(changes, "Stopped repeating")
+		} else if repeat != nil {
+			changes = append(changes, "repeat to "+*repeat)
+		}
+
+		if priority != nil && *priority == "" {
+			changes = append(changes, "Removed priority")
+		} else if priority != nil {
+			changes = append(changes, "priority to "+*priority)
+		}
+
+		if len(tags) > 0 {
+			changes = append(changes, "tags to "+strings.Join(formatTags(tags), " "))
+		}
+
+		return journal.Run(database.DB, "todo edit", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			before, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := repository.UpdateTodo(tx, id, content, tags, dueDate, clearDueDate); err != nil {
+				return err
+			}
+
+			if repeat != nil {
+				if err := repository.SetTodoRepeat(tx, id, *repeat); err != nil {
+					return err
+				}
+			}
+
+			if priority != nil {
+				if err := repository.SetTodoPriority(tx, id, *priority); err != nil {
+					return err
+				}
+			}
+
+			return activity.LogTodoUpdated(tx, before, changes)
+		})
 	},
 }
 
@@ -93,4 +212,6 @@ func init() {
 	todoEditCmd.Flags().StringVar(&todoEditContent, "content", "", "New content for the todo")
 	todoEditCmd.Flags().StringSliceVar(&todoEditTags, "tag", []string{}, "Replace tags")
 	todoEditCmd.Flags().StringVar(&todoEditDue, "due", "", "Due date (YYYY-MM-DD or natural language)")
+	todoEditCmd.Flags().StringVar(&todoEditRepeat, "repeat", "", "How often the todo recurs, or empty to stop it recurring")
+	todoEditCmd.Flags().StringVar(&todoEditPriority, "priority", "", "Priority (P0 to P3, or critical, high, medium or low), or empty to clear it")
 }
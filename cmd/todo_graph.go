@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var todoGraphAll bool
+
+var todoGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show which todos are waiting on which",
+	Long: `Show the dependencies recorded with 'note todo block' as a tree: each todo
+that waits on nothing, with the todos waiting on it below. Dependencies of
+completed todos are left out unless --all is given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dependencies, err := repository.ListDependencies(database.DB)
+		if err != nil {
+			return err
+		}
+
+		// Dependencies only join todos that are not trashed, all of which
+		// ListTodos returns.
+		all, err := repository.ListTodos(database.DB, repository.TodoListOptions{})
+		if err != nil {
+			return err
+		}
+
+		todos := make(map[int]models.Todo, len(all))
+		for _, todo := range all {
+			todos[todo.ID] = todo
+		}
+
+		var shown []models.TodoDependency
+		for _, d := range dependencies {
+			if todoGraphAll || !todos[d.TodoID].IsComplete {
+				shown = append(shown, d)
+			}
+		}
+
+		if len(shown) == 0 {
+			fmt.Println("No dependencies between todos.")
+			return nil
+		}
+
+		fmt.Println(display.FormatDependencyGraph(todos, shown))
+		return nil
+	},
+}
+
+func init() {
+	todoGraphCmd.Flags().BoolVar(&todoGraphAll, "all", false, "Include dependencies of completed todos")
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var todoHistoryCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show the edit history of a todo",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showHistory("todo", args)
+	},
+}
+
+var todoDiffCmd = &cobra.Command{
+	Use:   "diff <id> [rev-a] [rev-b]",
+	Short: "Show changes between revisions of a todo",
+	Args:  cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showDiff("todo", args)
+	},
+}
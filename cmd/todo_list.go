@@ -14,17 +14,39 @@ var (
 	todoListIncomplete bool
 	todoListTags       []string
 	todoListOverdue    bool
+	todoListPriority   string
+	todoListSort       string
+	todoListReverse    bool
+	todoListLimit      int
+	todoListOffset     int
+	todoListAfter      int
 )
 
 var todoListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List todos",
+	Long: `List todos grouped into overdue, due today, upcoming and without a due date.
+
+Sorting by anything but due date, or reversing the order, lists the todos in
+that order instead, without grouping them. Todos have no important flag, so
+--sort important sorts by priority, as --sort priority does.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		priority, err := parsePriority(todoListPriority)
+		if err != nil {
+			return err
+		}
+
 		opts := repository.TodoListOptions{
 			Complete:   todoListComplete,
 			Incomplete: todoListIncomplete,
 			Tags:       todoListTags,
 			Overdue:    todoListOverdue,
+			Priority:   priority,
+			Sort:       todoListSort,
+			Reverse:    todoListReverse,
+			Limit:      todoListLimit,
+			Offset:     todoListOffset,
+			AfterID:    todoListAfter,
 		}
 
 		todos, err := repository.ListTodos(database.DB, opts)
@@ -32,7 +54,13 @@ var todoListCmd = &cobra.Command{
 			return err
 		}
 
-		output := display.FormatTodoList(todos)
+		// Grouping by due date would undo any other order.
+		var output string
+		if todoListSort == "due" && !todoListReverse {
+			output = display.FormatTodoList(todos)
+		} else {
+			output = display.FormatFlatTodoList(todos)
+		}
 		if output != "" {
 			fmt.Println(output)
 		}
@@ -46,4 +74,10 @@ func init() {
 	todoListCmd.Flags().BoolVar(&todoListIncomplete, "incomplete", false, "Show only incomplete todos")
 	todoListCmd.Flags().StringSliceVar(&todoListTags, "tag", []string{}, "Filter by tags")
 	todoListCmd.Flags().BoolVar(&todoListOverdue, "overdue", false, "Show only overdue todos")
+	todoListCmd.Flags().StringVar(&todoListPriority, "priority", "", "Show only todos of this priority (P0 to P3, or critical, high, medium or low)")
+	todoListCmd.Flags().StringVar(&todoListSort, "sort", "due", "Sort by due, created, updated, priority or important (an alias of priority)")
+	todoListCmd.Flags().BoolVar(&todoListReverse, "reverse", false, "Reverse the sort order")
+	todoListCmd.Flags().IntVar(&todoListLimit, "limit", 0, "Show at most this many todos")
+	todoListCmd.Flags().IntVar(&todoListOffset, "offset", 0, "Skip this many todos")
+	todoListCmd.Flags().IntVar(&todoListAfter, "after", 0, "Continue after the todo with this ID")
 }
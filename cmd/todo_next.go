@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	todoNextTags  []string
+	todoNextLimit int
+)
+
+var todoNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "List the todos that can be worked on now",
+	Long: `List the open todos that can be worked on now, by priority and then due
+date. Todos blocked by an open todo are left out, and so are todos with open
+subtasks, whose subtasks are listed instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		todos, err := repository.ListTodos(database.DB, repository.TodoListOptions{
+			Actionable: true,
+			Tags:       todoNextTags,
+			Sort:       "priority",
+			Limit:      todoNextLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(todos) == 0 {
+			fmt.Println("Nothing to do next.")
+			return nil
+		}
+
+		fmt.Println(display.FormatFlatTodoList(todos))
+		return nil
+	},
+}
+
+func init() {
+	todoNextCmd.Flags().StringSliceVar(&todoNextTags, "tag", []string{}, "Filter by tags")
+	todoNextCmd.Flags().IntVar(&todoNextLimit, "limit", 0, "Show at most this many todos")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var todoNoteCmd = &cobra.Command{
+	Use:   "note <todo-id> [content...]",
+	Short: "Add a comment to a todo",
+	Long: `Add a comment to a todo. Comments are notes with the todo's tags, listed
+under the todo in note todo show. Without content, $VISUAL or $EDITOR is opened
+to write the comment.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		todo, err := repository.GetTodoByID(database.DB, id)
+		if err != nil {
+			return err
+		}
+
+		draft := noteDraft{tags: todo.Tags}
+
+		content, ok, err := readContent(args[1:])
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			draft.content = content
+		} else {
+			composed, err := composeNote(draft)
+			if err != nil {
+				return err
+			}
+			draft = composed
+		}
+
+		var comment *models.Note
+		err = journal.Run(database.DB, "todo note", func(tx database.DBTX, op *journal.Recorder) error {
+			var err error
+			comment, err = repository.CreateComment(tx, id, draft.content, draft.tags, draft.important)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Added note #%d to todo #%d.\n", comment.ID, id)
+		return nil
+	},
+}
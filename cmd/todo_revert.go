@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var todoRevertCmd = &cobra.Command{
+	Use:   "revert <id> <rev>",
+	Short: "Restore a todo to an earlier revision",
+	Long: `Restore a todo's content, tags and due date to an earlier revision. Its
+priority, recurrence, subtasks and dependencies are not kept in revisions and
+stay as they are.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		return journal.Run(database.DB, "todo revert", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			revision, err := parseRevision(args[1])
+			if err != nil {
+				return err
+			}
+
+			before, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			if err := repository.RevertTodo(tx, id, revision); err != nil {
+				return err
+			}
+
+			return activity.LogTodoUpdated(tx, before, []string{fmt.Sprintf("Reverted to revision %d", revision)})
+		})
+	},
+}
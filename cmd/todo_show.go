@@ -25,7 +25,30 @@ var todoShowCmd = &cobra.Command{
 			return err
 		}
 
+		outgoing, err := repository.GetLinks(database.DB, "todo", id)
+		if err != nil {
+			return err
+		}
+
+		backlinks, err := repository.GetBacklinks(database.DB, "todo", strconv.Itoa(id))
+		if err != nil {
+			return err
+		}
+
+		comments, err := repository.ListComments(database.DB, id)
+		if err != nil {
+			return err
+		}
+
 		fmt.Println(display.FormatTodo(todo))
+		if notes := display.FormatComments(comments); notes != "" {
+			fmt.Println()
+			fmt.Println(notes)
+		}
+		if links := display.FormatLinks(outgoing, backlinks); links != "" {
+			fmt.Println()
+			fmt.Println(links)
+		}
 		return nil
 	},
 }
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var todoSkipCmd = &cobra.Command{
+	Use:   "skip <id>",
+	Short: "Move a recurring todo on to its next occurrence without completing it",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+
+		var nextDue time.Time
+
+		err = journal.Run(database.DB, "todo skip", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			before, err := repository.GetTodoByID(tx, id)
+			if err != nil {
+				return err
+			}
+
+			nextDue, err = repository.SkipTodo(tx, id)
+			if err != nil {
+				return err
+			}
+
+			return activity.LogTodoUpdated(tx, before, []string{"Skipped to " + nextDue.Format("2006-01-02")})
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Skipped todo #%d to %s.\n", id, nextDue.Format("2006-01-02"))
+		return nil
+	},
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
 	"github.com/nathan-nicholson/note/internal/repository"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,12 @@ var todoUncompleteCmd = &cobra.Command{
 			return err
 		}
 
-		return repository.UncompleteTodo(database.DB, id)
+		return journal.Run(database.DB, "todo uncomplete", func(tx database.DBTX, op *journal.Recorder) error {
+			if err := op.Track("todo", id); err != nil {
+				return err
+			}
+
+			return repository.UncompleteTodo(tx, id)
+		})
 	},
 }
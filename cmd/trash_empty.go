@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var trashEmptyOlderThan string
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete items in the trash",
+	Long: `Permanently delete items in the trash. This cannot be undone, and it clears
+the undo history, whose snapshots would otherwise keep a copy of what was
+deleted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var cutoff time.Time
+		if trashEmptyOlderThan != "" {
+			age, err := dateparse.ParseAge(trashEmptyOlderThan)
+			if err != nil {
+				return err
+			}
+			cutoff = time.Now().Add(-age)
+		}
+
+		// Emptying the trash is for good, so it is not journaled, and undo
+		// stops here rather than bringing back what it deleted.
+		var count int
+		err := journal.RunIrreversible(database.DB, "trash empty", func(tx database.DBTX) error {
+			var err error
+			count, err = repository.EmptyTrash(tx, cutoff)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Permanently deleted %d item(s) from the trash.\n", count)
+
+		// Stored files are only removed once nothing refers to them.
+		keep, err := repository.ReferencedBlobs(database.DB)
+		if err != nil {
+			return err
+		}
+		removed, err := attachmentStore().Prune(keep)
+		if err != nil {
+			return err
+		}
+		if removed > 0 {
+			fmt.Printf("Removed %d unused attachment file(s).\n", removed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	trashEmptyCmd.Flags().StringVar(&trashEmptyOlderThan, "older-than", "", "Only delete items trashed longer ago than this (e.g. 30d, 2w)")
+}
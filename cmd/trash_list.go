@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/display"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List items in the trash",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := repository.ListTrash(database.DB)
+		if err != nil {
+			return err
+		}
+
+		output := display.FormatTrashList(items)
+		if output != "" {
+			fmt.Println(output)
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <note|todo|project> <id>",
+	Short: "Restore an item from the trash",
+	Long:  `Restore a trashed note, todo or project. Projects may be given by ID or by name.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind := args[0]
+
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			if kind != "project" {
+				return err
+			}
+
+			id, err = repository.GetTrashedProjectID(database.DB, args[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		return journal.Run(database.DB, "trash restore", func(tx database.DBTX, op *journal.Recorder) error {
+			switch kind {
+			case "note":
+				if err := op.Track("note", id); err != nil {
+					return err
+				}
+
+				if err := repository.RestoreNote(tx, id); err != nil {
+					return err
+				}
+
+			case "todo":
+				if err := op.Track("todo", id); err != nil {
+					return err
+				}
+
+				if err := repository.RestoreTodo(tx, id); err != nil {
+					return err
+				}
+
+				todo, err := repository.GetTodoByID(tx, id)
+				if err != nil {
+					return err
+				}
+
+				if err := activity.LogTodoRestored(tx, todo); err != nil {
+					return err
+				}
+
+			case "project":
+				if err := op.Track("project", id); err != nil {
+					return err
+				}
+
+				if err := repository.RestoreProject(tx, id); err != nil {
+					return err
+				}
+
+				project, err := repository.GetProjectByID(tx, id)
+				if err != nil {
+					return err
+				}
+
+				if err := activity.LogProjectRestored(tx, project); err != nil {
+					return err
+				}
+
+			default:
+				return fmt.Errorf("unknown kind '%s' (expected note, todo or project)", kind)
+			}
+
+			return nil
+		})
+	},
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/journal"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [n]",
+	Short: "Undo the last n operations",
+	Long:  `Reverse the last n commands that changed notes, todos or projects, including the activity notes they created. Defaults to 1.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseOperationCount(args)
+		if err != nil {
+			return err
+		}
+
+		operations, err := journal.Undo(database.DB, n)
+		printOperations("Undid", operations)
+		return err
+	},
+}
+
+func parseOperationCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid count '%s': must be a positive number", args[0])
+	}
+	return n, nil
+}
+
+func printOperations(verb string, operations []models.Operation) {
+	for _, op := range operations {
+		fmt.Printf("%s: %s (%s)\n", verb, op.Command, op.CreatedAt.Format("2006-01-02 03:04 PM"))
+	}
+}
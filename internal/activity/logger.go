@@ -1,108 +1,173 @@
 package activity
 
 import (
-	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/nathan-nicholson/note/internal/database"
 	"github.com/nathan-nicholson/note/internal/models"
 	"github.com/nathan-nicholson/note/internal/repository"
 )
 
-func LogTodoCreated(db *sql.DB, todo *models.Todo) error {
-	content := fmt.Sprintf("Created todo: %s", todo.Content)
+// Every lifecycle event of a todo or project is written to the activity log
+// with the item's state before and after it, as todoState or projectState.
 
-	if todo.DueDate.Valid {
-		content += fmt.Sprintf(" (due: %s)", todo.DueDate.Time.Format("2006-01-02"))
-	}
+type todoState struct {
+	Content    string   `json:"content"`
+	DueDate    string   `json:"due_date,omitempty"`
+	IsComplete bool     `json:"is_complete"`
+	Repeat     string   `json:"repeat,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	Tags       []string `json:"tags"`
+}
 
-	tags := append([]string{"todo", "create"}, todo.Tags...)
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+type projectState struct {
+	Name     string   `json:"name"`
+	IsClosed bool     `json:"is_closed"`
+	Tags     []string `json:"tags"`
 }
 
-func LogTodoUpdated(db *sql.DB, todo *models.Todo, changes []string) error {
+func LogTodoCreated(db database.DBTX, todo *models.Todo) error {
+	return logTodo(db, "created", todo.ID, todoSummary(todo), nil, todo)
+}
+
+// LogTodoUpdated records changes made to a todo, given as it was before them.
+func LogTodoUpdated(db database.DBTX, before *models.Todo, changes []string) error {
 	if len(changes) == 0 {
 		return nil
 	}
 
-	content := fmt.Sprintf("Updated todo: %s", strings.Join(changes, ", "))
+	after, err := repository.GetTodoByID(db, before.ID)
+	if err != nil {
+		return err
+	}
 
-	tags := []string{"todo", "update"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+	return logTodo(db, "updated", before.ID, strings.Join(changes, ", "), before, after)
 }
 
-func LogTodoCompleted(db *sql.DB, todo *models.Todo) error {
-	content := fmt.Sprintf("Completed todo: %s", todo.Content)
-
-	if todo.DueDate.Valid {
-		content += fmt.Sprintf(" (due: %s)", todo.DueDate.Time.Format("2006-01-02"))
+// LogTodoCompleted records the completion of a todo, given as it was before.
+func LogTodoCompleted(db database.DBTX, todo *models.Todo) error {
+	after, err := repository.GetTodoByID(db, todo.ID)
+	if err != nil {
+		return err
 	}
 
-	tags := append([]string{"todo", "complete"}, todo.Tags...)
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+	return logTodo(db, "completed", todo.ID, todoSummary(todo), todo, after)
 }
 
-func LogTodoDeleted(db *sql.DB, todo *models.Todo) error {
-	content := fmt.Sprintf("Deleted todo: %s", todo.Content)
+func LogTodoDeleted(db database.DBTX, todo *models.Todo) error {
+	return logTodo(db, "deleted", todo.ID, todoSummary(todo), todo, nil)
+}
 
-	if todo.DueDate.Valid {
-		content += fmt.Sprintf(" (due: %s)", todo.DueDate.Time.Format("2006-01-02"))
+func LogTodoRestored(db database.DBTX, todo *models.Todo) error {
+	return logTodo(db, "restored", todo.ID, todo.Content, nil, todo)
+}
+
+func LogProjectCreated(db database.DBTX, project *models.Project) error {
+	return logProject(db, "created", project.ID, project.Name, nil, project)
+}
+
+func LogProjectActivated(db database.DBTX, projectName string) error {
+	return logProjectByName(db, "activated", projectName, projectName)
+}
+
+func LogProjectDeactivated(db database.DBTX, projectName string) error {
+	return logProjectByName(db, "deactivated", projectName, projectName)
+}
+
+// LogProjectUpdated records changes made to a project, given as it was before
+// them.
+func LogProjectUpdated(db database.DBTX, before *models.Project, changes string) error {
+	after, err := repository.GetProjectByName(db, before.Name)
+	if err != nil {
+		return err
 	}
 
-	tags := append([]string{"todo", "delete"}, todo.Tags...)
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+	return logProject(db, "updated", before.ID, fmt.Sprintf("%s - %s", before.Name, changes), before, after)
+}
+
+func LogProjectClosed(db database.DBTX, projectName string) error {
+	return logProjectByName(db, "closed", projectName, projectName)
+}
+
+func LogProjectReopened(db database.DBTX, projectName string) error {
+	return logProjectByName(db, "reopened", projectName, projectName)
 }
 
-func LogProjectCreated(db *sql.DB, project *models.Project) error {
-	content := fmt.Sprintf("Created project: %s", project.Name)
+func LogProjectDeleted(db database.DBTX, project *models.Project) error {
+	return logProject(db, "deleted", project.ID, project.Name, project, nil)
+}
 
-	tags := append([]string{"project", "create"}, project.Tags...)
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+func LogProjectRestored(db database.DBTX, project *models.Project) error {
+	return logProject(db, "restored", project.ID, project.Name, nil, project)
 }
 
-func LogProjectActivated(db *sql.DB, projectName string) error {
-	content := fmt.Sprintf("Activated project: %s", projectName)
-	tags := []string{"project", "activate"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+// todoSummary describes a todo as "content (due: 2006-01-02)".
+func todoSummary(todo *models.Todo) string {
+	if todo.DueDate.Valid {
+		return fmt.Sprintf("%s (due: %s)", todo.Content, todo.DueDate.Time.Format("2006-01-02"))
+	}
+	return todo.Content
 }
 
-func LogProjectDeactivated(db *sql.DB, projectName string) error {
-	content := fmt.Sprintf("Deactivated project: %s", projectName)
-	tags := []string{"project", "deactivate"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+func logTodo(db database.DBTX, event string, id int, summary string, before, after *models.Todo) error {
+	entry := models.Activity{Event: event, EntityKind: "todo", EntityID: id, Summary: summary}
+
+	var err error
+	if entry.Before, err = todoJSON(before); err != nil {
+		return err
+	}
+	if entry.After, err = todoJSON(after); err != nil {
+		return err
+	}
+
+	return repository.CreateActivity(db, entry)
 }
 
-func LogProjectUpdated(db *sql.DB, projectName string, changes string) error {
-	content := fmt.Sprintf("Updated project: %s - %s", projectName, changes)
-	tags := []string{"project", "update"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+// logProjectByName records an event on a project that leaves it as it is now.
+func logProjectByName(db database.DBTX, event, projectName, summary string) error {
+	project, err := repository.GetProjectByName(db, projectName)
+	if err != nil {
+		return err
+	}
+
+	return logProject(db, event, project.ID, summary, nil, project)
 }
 
-func LogProjectClosed(db *sql.DB, projectName string) error {
-	content := fmt.Sprintf("Closed project: %s", projectName)
-	tags := []string{"project", "close"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+func logProject(db database.DBTX, event string, id int, summary string, before, after *models.Project) error {
+	entry := models.Activity{Event: event, EntityKind: "project", EntityID: id, Summary: summary}
+
+	var err error
+	if entry.Before, err = projectJSON(before); err != nil {
+		return err
+	}
+	if entry.After, err = projectJSON(after); err != nil {
+		return err
+	}
+
+	return repository.CreateActivity(db, entry)
 }
 
-func LogProjectReopened(db *sql.DB, projectName string) error {
-	content := fmt.Sprintf("Reopened project: %s", projectName)
-	tags := []string{"project", "reopen"}
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+func todoJSON(todo *models.Todo) (string, error) {
+	if todo == nil {
+		return "", nil
+	}
+
+	state := todoState{Content: todo.Content, IsComplete: todo.IsComplete, Repeat: todo.Repeat, Priority: todo.Priority, Tags: todo.Tags}
+	if todo.DueDate.Valid {
+		state.DueDate = todo.DueDate.Time.Format("2006-01-02")
+	}
+
+	data, err := json.Marshal(state)
+	return string(data), err
 }
 
-func LogProjectDeleted(db *sql.DB, project *models.Project) error {
-	content := fmt.Sprintf("Deleted project: %s", project.Name)
-	tags := append([]string{"project", "delete"}, project.Tags...)
-	_, err := repository.CreateNote(db, content, tags, false)
-	return err
+func projectJSON(project *models.Project) (string, error) {
+	if project == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(projectState{Name: project.Name, IsClosed: project.IsClosed, Tags: project.Tags})
+	return string(data), err
 }
@@ -0,0 +1,39 @@
+package activity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// event is what Summarize counts: a subject, todo or project, and the action
+// taken on it.
+type event struct {
+	subject string
+	action  string
+}
+
+// Summarize counts activity entries by event in the order they first
+// happened, as in "2 todos created, 1 project activated".
+func Summarize(entries []models.Activity) string {
+	var order []event
+	counts := map[event]int{}
+	for _, entry := range entries {
+		e := event{subject: entry.EntityKind, action: entry.Event}
+		if counts[e] == 0 {
+			order = append(order, e)
+		}
+		counts[e]++
+	}
+
+	parts := make([]string, len(order))
+	for i, e := range order {
+		subject := e.subject
+		if counts[e] > 1 {
+			subject += "s"
+		}
+		parts[i] = fmt.Sprintf("%d %s %s", counts[e], subject, e.action)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,23 @@
+package activity
+
+import (
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func TestSummarize(t *testing.T) {
+	entries := []models.Activity{
+		{Event: "created", EntityKind: "todo", EntityID: 1},
+		{Event: "activated", EntityKind: "project", EntityID: 2},
+		{Event: "created", EntityKind: "todo", EntityID: 3},
+	}
+
+	if got, want := Summarize(entries), "2 todos created, 1 project activated"; got != want {
+		t.Errorf("Summarize() = %q, want %q", got, want)
+	}
+
+	if got := Summarize(nil); got != "" {
+		t.Errorf("Summarize(nil) = %q, want empty", got)
+	}
+}
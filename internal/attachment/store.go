@@ -0,0 +1,138 @@
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Attached files are stored once per database under a name taken from the
+// SHA-256 of their content, so attaching the same file twice, or to several
+// items, keeps a single copy. The database records which items refer to
+// which blob; blobs nothing refers to are removed by Store.Prune.
+
+var hashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type Store struct {
+	dir string
+}
+
+// Dir returns the directory that holds the attachments of the database at
+// dbPath: attachments/<name> next to the database file.
+func Dir(dbPath string) string {
+	name := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	return filepath.Join(filepath.Dir(dbPath), "attachments", name)
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Path returns where the blob with the given hash is stored.
+func (s *Store) Path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Put copies the file at path into the store and returns the hash of its
+// content and its size.
+func (s *Store) Put(path string) (string, int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	if info.IsDir() {
+		return "", 0, fmt.Errorf("%s is a directory", path)
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", 0, fmt.Errorf("could not create directory %s: %w", s.dir, err)
+	}
+
+	// Copy to a temporary file first, so a blob is never visible under its
+	// hash until it is complete.
+	tmp, err := os.CreateTemp(s.dir, "incoming-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	dest := s.Path(hash)
+	if _, err := os.Stat(dest); err == nil {
+		return hash, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", 0, err
+	}
+	return hash, size, nil
+}
+
+// Has reports whether the blob with the given hash is stored.
+func (s *Store) Has(hash string) bool {
+	_, err := os.Stat(s.Path(hash))
+	return err == nil
+}
+
+// Prune removes the blobs whose hash is not in keep and returns how many it
+// removed.
+func (s *Store) Prune(keep map[string]bool) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		blobs, err := os.ReadDir(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return removed, err
+		}
+
+		for _, blob := range blobs {
+			hash := blob.Name()
+			if !hashRegex.MatchString(hash) || keep[hash] {
+				continue
+			}
+			if err := os.Remove(s.Path(hash)); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+
+		// Leaves the directory in place if it still holds blobs.
+		os.Remove(filepath.Join(s.dir, entry.Name()))
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,98 @@
+package attachment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	return path
+}
+
+func TestDir(t *testing.T) {
+	got := Dir(filepath.Join("home", ".note", "notebooks", "work.db"))
+	want := filepath.Join("home", ".note", "notebooks", "attachments", "work")
+	if got != want {
+		t.Errorf("Dir() = %q, want %q", got, want)
+	}
+}
+
+func TestPut_DeduplicatesContent(t *testing.T) {
+	src := t.TempDir()
+	store := NewStore(t.TempDir())
+
+	first, size, err := store.Put(writeFile(t, src, "a.log", "same bytes"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if size != int64(len("same bytes")) {
+		t.Errorf("Put() size = %d, want %d", size, len("same bytes"))
+	}
+
+	second, _, err := store.Put(writeFile(t, src, "b.log", "same bytes"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if first != second {
+		t.Errorf("Put() hashes differ for identical content: %s, %s", first, second)
+	}
+
+	data, err := os.ReadFile(store.Path(first))
+	if err != nil {
+		t.Fatalf("stored blob missing: %v", err)
+	}
+	if string(data) != "same bytes" {
+		t.Errorf("stored blob = %q, want %q", data, "same bytes")
+	}
+}
+
+func TestPut_RejectsDirectory(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	if _, _, err := store.Put(t.TempDir()); err == nil {
+		t.Error("Put() of a directory succeeded, want an error")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	src := t.TempDir()
+	store := NewStore(t.TempDir())
+
+	kept, _, err := store.Put(writeFile(t, src, "keep.txt", "keep"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	dropped, _, err := store.Put(writeFile(t, src, "drop.txt", "drop"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	removed, err := store.Prune(map[string]bool{kept: true})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d blobs, want 1", removed)
+	}
+	if !store.Has(kept) {
+		t.Error("Prune() removed a blob that is still referenced")
+	}
+	if store.Has(dropped) {
+		t.Error("Prune() kept an unreferenced blob")
+	}
+}
+
+func TestPrune_MissingDirectory(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "absent"))
+
+	if removed, err := store.Prune(nil); err != nil || removed != 0 {
+		t.Errorf("Prune() = %d, %v, want 0, nil", removed, err)
+	}
+}
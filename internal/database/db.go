@@ -5,40 +5,78 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
 
-func InitDB() error {
-	homeDir, err := os.UserHomeDir()
+// DefaultBusyTimeout is how long a statement waits for another process to
+// release its lock on the database before failing with "database is locked".
+const DefaultBusyTimeout = 5 * time.Second
+
+// BusyTimeout applies to databases opened after it is set.
+var BusyTimeout = DefaultBusyTimeout
+
+func InitDB(dbPath string) error {
+	db, err := Open(dbPath)
 	if err != nil {
-		return fmt.Errorf("could not find home directory: %w", err)
+		return err
 	}
 
-	dbDir := filepath.Join(homeDir, ".note")
+	DB = db
+	return nil
+}
+
+func Open(dbPath string) (*sql.DB, error) {
+	dbDir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		return fmt.Errorf("could not create directory %s: %w", dbDir, err)
+		return nil, fmt.Errorf("could not create directory %s: %w", dbDir, err)
 	}
 
-	dbPath := filepath.Join(dbDir, "notes.db")
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", dsn(dbPath))
 	if err != nil {
-		return fmt.Errorf("could not connect to database at %s: %w", dbPath, err)
+		return nil, fmt.Errorf("could not connect to database at %s: %w", dbPath, err)
 	}
 
-	DB = db
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not run migrations: %w", err)
+	}
 
-	if err := runMigrations(db); err != nil {
-		return fmt.Errorf("could not run migrations: %w", err)
+	if err := EnsureSearchIndex(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not prepare search index: %w", err)
 	}
 
 	if err := ensureHomeProject(db); err != nil {
-		return fmt.Errorf("could not ensure home project exists: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("could not ensure home project exists: %w", err)
 	}
 
-	return nil
+	return db, nil
+}
+
+// dsn configures every connection in the pool. Foreign keys make the ON
+// DELETE CASCADE clauses in the schema apply. WAL lets readers carry on while
+// another process writes, the busy timeout makes a writer wait for the lock
+// instead of failing at once, and immediate transactions take the write lock
+// up front so two writers never deadlock upgrading from a read.
+func dsn(dbPath string) string {
+	return fmt.Sprintf("%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate",
+		dbPath, BusyTimeout.Milliseconds())
+}
+
+// Compact rebuilds the database file without its free pages and empties the
+// write-ahead log, so content that has been overwritten is no longer in
+// either file.
+func Compact(db *sql.DB) error {
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return err
+	}
+	_, err := db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
 }
 
 func CloseDB() error {
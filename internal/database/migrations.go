@@ -3,94 +3,740 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/links"
+	"github.com/nathan-nicholson/note/internal/vault"
 )
 
-func runMigrations(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS notes (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		is_important BOOLEAN NOT NULL DEFAULT 0
-	);
-
-	CREATE TABLE IF NOT EXISTS todos (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		content TEXT NOT NULL,
-		is_complete BOOLEAN NOT NULL DEFAULT 0,
-		due_date DATE,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		completed_at TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS tags (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE
-	);
-
-	CREATE TABLE IF NOT EXISTS note_tags (
-		note_id INTEGER NOT NULL,
-		tag_id INTEGER NOT NULL,
-		FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
-		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
-		PRIMARY KEY (note_id, tag_id)
-	);
-
-	CREATE TABLE IF NOT EXISTS todo_tags (
-		todo_id INTEGER NOT NULL,
-		tag_id INTEGER NOT NULL,
-		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
-		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
-		PRIMARY KEY (todo_id, tag_id)
-	);
-
-	CREATE TABLE IF NOT EXISTS projects (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		first_activated_at TIMESTAMP,
-		last_activity_at TIMESTAMP,
-		closed_at TIMESTAMP,
-		is_closed BOOLEAN DEFAULT 0
-	);
-
-	CREATE TABLE IF NOT EXISTS project_tags (
-		project_id INTEGER NOT NULL,
-		tag_id INTEGER NOT NULL,
-		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
-		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
-		PRIMARY KEY (project_id, tag_id)
-	);
-
-	CREATE TABLE IF NOT EXISTS active_project (
-		project_id INTEGER NOT NULL UNIQUE,
-		activated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
-	);
-	`
-
-	if _, err := db.Exec(schema); err != nil {
-		return fmt.Errorf("failed to create schema: %w", err)
+// migration is a single numbered schema change. Migrations are applied in
+// order, each in its own transaction, and must never be edited once released:
+// add a new migration instead.
+type migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "initial schema",
+		Up: execSQL(`
+			CREATE TABLE IF NOT EXISTS notes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				content TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				is_important BOOLEAN NOT NULL DEFAULT 0
+			);
+
+			CREATE TABLE IF NOT EXISTS todos (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				content TEXT NOT NULL,
+				is_complete BOOLEAN NOT NULL DEFAULT 0,
+				due_date DATE,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				completed_at TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS tags (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE
+			);
+
+			CREATE TABLE IF NOT EXISTS note_tags (
+				note_id INTEGER NOT NULL,
+				tag_id INTEGER NOT NULL,
+				FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+				FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+				PRIMARY KEY (note_id, tag_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS todo_tags (
+				todo_id INTEGER NOT NULL,
+				tag_id INTEGER NOT NULL,
+				FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+				FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+				PRIMARY KEY (todo_id, tag_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS projects (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				first_activated_at TIMESTAMP,
+				last_activity_at TIMESTAMP,
+				closed_at TIMESTAMP,
+				is_closed BOOLEAN DEFAULT 0
+			);
+
+			CREATE TABLE IF NOT EXISTS project_tags (
+				project_id INTEGER NOT NULL,
+				tag_id INTEGER NOT NULL,
+				FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+				FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+				PRIMARY KEY (project_id, tag_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS active_project (
+				project_id INTEGER NOT NULL UNIQUE,
+				activated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+			);
+		`),
+	},
+	{
+		Version:     2,
+		Description: "soft deletion for notes, todos and projects",
+		Up: execSQL(`
+			ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMP;
+			ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMP;
+			ALTER TABLE projects ADD COLUMN deleted_at TIMESTAMP;
+		`),
+	},
+	{
+		Version:     3,
+		Description: "revision history for notes and todos",
+		Up: execSQL(`
+			CREATE TABLE revisions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				entity_kind TEXT NOT NULL,
+				entity_id INTEGER NOT NULL,
+				revision INTEGER NOT NULL,
+				content TEXT NOT NULL,
+				tags TEXT NOT NULL DEFAULT '[]',
+				is_important BOOLEAN NOT NULL DEFAULT 0,
+				due_date DATE,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (entity_kind, entity_id, revision)
+			);
+		`),
+	},
+	{
+		Version:     4,
+		Description: "operation journal for undo and redo",
+		Up: execSQL(`
+			CREATE TABLE operations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				command TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				undone_at TIMESTAMP
+			);
+
+			CREATE TABLE operation_changes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				operation_id INTEGER NOT NULL,
+				entity_kind TEXT NOT NULL,
+				entity_id INTEGER NOT NULL,
+				before TEXT,
+				after TEXT,
+				FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE
+			);
+		`),
+	},
+	{
+		Version:     5,
+		Description: "indexes for date listings and tag lookups",
+		Up: execSQL(`
+			CREATE INDEX idx_notes_created_at ON notes(created_at);
+			CREATE INDEX idx_todos_due_date_is_complete ON todos(due_date, is_complete);
+			CREATE INDEX idx_note_tags_tag_id ON note_tags(tag_id);
+			CREATE INDEX idx_todo_tags_tag_id ON todo_tags(tag_id);
+			CREATE INDEX idx_project_tags_tag_id ON project_tags(tag_id);
+			CREATE INDEX idx_operation_changes_operation_id ON operation_changes(operation_id);
+		`),
+	},
+	{
+		Version:     6,
+		Description: "settings for encrypting note and todo content",
+		Up: execSQL(`
+			CREATE TABLE encryption (
+				id INTEGER PRIMARY KEY CHECK (id = 1),
+				kdf TEXT NOT NULL,
+				salt BLOB NOT NULL,
+				time_cost INTEGER NOT NULL,
+				memory_kib INTEGER NOT NULL,
+				threads INTEGER NOT NULL,
+				check_value TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+		`),
+	},
+	{
+		Version:     7,
+		Description: "links between notes, todos and projects",
+		Up: func(tx *sql.Tx) error {
+			err := execSQL(`
+				CREATE TABLE links (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					source_kind TEXT NOT NULL,
+					source_id INTEGER NOT NULL,
+					target_kind TEXT NOT NULL,
+					target_ref TEXT NOT NULL,
+					UNIQUE (source_kind, source_id, target_kind, target_ref)
+				);
+
+				CREATE INDEX idx_links_target ON links(target_kind, target_ref);
+			`)(tx)
+			if err != nil {
+				return err
+			}
+
+			return backfillLinks(tx)
+		},
+	},
+	{
+		Version:     8,
+		Description: "file attachments on notes and todos",
+		Up: execSQL(`
+			CREATE TABLE attachments (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				owner_kind TEXT NOT NULL,
+				owner_id INTEGER NOT NULL,
+				hash TEXT NOT NULL,
+				name TEXT NOT NULL,
+				size INTEGER NOT NULL,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_attachments_owner ON attachments(owner_kind, owner_id);
+		`),
+	},
+	{
+		Version:     9,
+		Description: "note templates",
+		Up: execSQL(`
+			CREATE TABLE templates (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				content TEXT NOT NULL,
+				tags TEXT NOT NULL DEFAULT '[]',
+				is_important BOOLEAN NOT NULL DEFAULT 0,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+		`),
+	},
+	{
+		Version:     10,
+		Description: "todos promoted from notes and comment notes on todos",
+		// Deferred so that undo can put rows back in any order within its
+		// transaction.
+		Up: execSQL(`
+			ALTER TABLE todos ADD COLUMN promoted_from INTEGER
+				REFERENCES notes(id) ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+			ALTER TABLE notes ADD COLUMN todo_id INTEGER
+				REFERENCES todos(id) ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+
+			CREATE INDEX idx_notes_todo_id ON notes(todo_id);
+		`),
+	},
+	{
+		Version:     11,
+		Description: "activity log separate from notes",
+		Up: func(tx *sql.Tx) error {
+			err := execSQL(`
+				CREATE TABLE activity (
+					id INTEGER PRIMARY KEY AUTOINCREMENT,
+					event TEXT NOT NULL,
+					entity_kind TEXT NOT NULL,
+					entity_id INTEGER,
+					summary TEXT NOT NULL,
+					before TEXT,
+					after TEXT,
+					created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+				);
+
+				CREATE INDEX idx_activity_created_at ON activity(created_at);
+				CREATE INDEX idx_activity_entity ON activity(entity_kind, entity_id);
+			`)(tx)
+			if err != nil {
+				return err
+			}
+
+			return moveActivityNotes(tx, nil, nil)
+		},
+	},
+	{
+		Version:     12,
+		Description: "recurring todos",
+		Up: execSQL(`
+			ALTER TABLE todos ADD COLUMN repeat TEXT;
+		`),
+	},
+	{
+		Version:     13,
+		Description: "subtasks",
+		Up: execSQL(`
+			ALTER TABLE todos ADD COLUMN parent_id INTEGER
+				REFERENCES todos(id) ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED;
+
+			CREATE INDEX idx_todos_parent_id ON todos(parent_id);
+		`),
+	},
+	{
+		Version:     14,
+		Description: "todo priorities",
+		Up: execSQL(`
+			ALTER TABLE todos ADD COLUMN priority TEXT;
+		`),
+	},
+	{
+		Version:     15,
+		Description: "todo dependencies",
+		Up: execSQL(`
+			CREATE TABLE todo_dependencies (
+				todo_id INTEGER NOT NULL
+					REFERENCES todos(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+				blocked_by INTEGER NOT NULL
+					REFERENCES todos(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+				created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (todo_id, blocked_by)
+			);
+
+			CREATE INDEX idx_todo_dependencies_blocked_by ON todo_dependencies(blocked_by);
+		`),
+	},
+}
+
+// backfillLinks records the references already written in notes and todos.
+// Encrypted content cannot be read here, so its links are recorded the next
+// time it is saved.
+func backfillLinks(tx *sql.Tx) error {
+	for kind, table := range map[string]string{"note": "notes", "todo": "todos"} {
+		rows, err := tx.Query("SELECT id, content FROM " + table)
+		if err != nil {
+			return err
+		}
+
+		type sourceLinks struct {
+			id      int
+			targets []links.Target
+		}
+		var sources []sourceLinks
+		for rows.Next() {
+			var id int
+			var content string
+			if err := rows.Scan(&id, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			if vault.IsSealed(content) {
+				continue
+			}
+			if targets := links.Parse(content); len(targets) > 0 {
+				sources = append(sources, sourceLinks{id: id, targets: targets})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, source := range sources {
+			for _, target := range source.targets {
+				_, err := tx.Exec(`
+					INSERT INTO links (source_kind, source_id, target_kind, target_ref)
+					VALUES (?, ?, ?, ?)
+				`, kind, source.id, target.Kind, target.Ref)
+				if err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	return nil
 }
 
+// Activity used to be written as notes such as "Created todo: Ship it" tagged
+// todo and create. activityNoteRegex and activityNoteTags recognise them.
+var (
+	activityNoteRegex = regexp.MustCompile(`(?s)^(Created|Updated|Completed|Deleted|Restored|Activated|Deactivated|Closed|Reopened) (todo|project): (.*)$`)
+	activityNoteTags  = map[string]string{
+		"Created":     "create",
+		"Updated":     "update",
+		"Completed":   "complete",
+		"Deleted":     "delete",
+		"Restored":    "restore",
+		"Activated":   "activate",
+		"Deactivated": "deactivate",
+		"Closed":      "close",
+		"Reopened":    "reopen",
+	}
+	activityDueRegex = regexp.MustCompile(` \(due: \d{4}-\d{2}-\d{2}\)$`)
+)
+
+// activityNote is an activity note recognised by findActivityNotes.
+type activityNote struct {
+	id        int
+	event     string
+	kind      string
+	summary   string
+	createdAt time.Time
+}
+
+// moveActivityNotes moves activity notes into the activity log. The notes
+// never recorded the todo or project they were about, so it is looked up by
+// content or name and left unknown when that is ambiguous. Trashed notes are
+// left as notes, and so are encrypted ones unless open and seal are given to
+// read and write content. Undo entries for the moved notes are dropped, as
+// are tags only they used.
+func moveActivityNotes(tx DBTX, open, seal func(string) (string, error)) error {
+	moved, err := findActivityNotes(tx, open)
+	if err != nil {
+		return err
+	}
+
+	for _, note := range moved {
+		entityID, err := findActivityEntity(tx, note.kind, note.event, note.summary, open)
+		if err != nil {
+			return err
+		}
+
+		summary := note.summary
+		if seal != nil {
+			if summary, err = seal(summary); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO activity (event, entity_kind, entity_id, summary, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, note.event, note.kind, entityID, summary, note.createdAt)
+		if err != nil {
+			return err
+		}
+
+		for _, statement := range []string{
+			"DELETE FROM operation_changes WHERE entity_kind = 'note' AND entity_id = ?",
+			"DELETE FROM revisions WHERE entity_kind = 'note' AND entity_id = ?",
+			"DELETE FROM links WHERE source_kind = 'note' AND source_id = ?",
+			"DELETE FROM note_tags WHERE note_id = ?",
+			"DELETE FROM notes WHERE id = ?",
+		} {
+			if _, err := tx.Exec(statement, note.id); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM operations WHERE id NOT IN (SELECT operation_id FROM operation_changes);
+
+		DELETE FROM tags
+		WHERE name IN ('todo', 'project', 'create', 'update', 'complete', 'delete', 'restore', 'activate', 'deactivate', 'close', 'reopen')
+			AND id NOT IN (SELECT tag_id FROM note_tags)
+			AND id NOT IN (SELECT tag_id FROM todo_tags)
+			AND id NOT IN (SELECT tag_id FROM project_tags);
+	`)
+	return err
+}
+
+// MoveSealedActivityNotes finishes migration 11 for a database that was
+// encrypted when it ran, once open and seal can read and write its content.
+// Only notes written before the migration are considered, and the write lock
+// is taken only when one of them is still to be moved.
+func MoveSealedActivityNotes(db DBTX, open, seal func(string) (string, error)) error {
+	pending, err := findActivityNotes(db, open)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	return WithTx(db, func(tx DBTX) error {
+		return moveActivityNotes(tx, open, seal)
+	})
+}
+
+// findActivityNotes returns the notes that moveActivityNotes would move. Once
+// migration 11 has been applied, notes written after it are the user's own.
+// The times are compared as instants, since applied_at is stored with its UTC
+// offset and created_at may not be.
+func findActivityNotes(db DBTX, open func(string) (string, error)) ([]activityNote, error) {
+	rows, err := db.Query(`
+		SELECT n.id, n.content, n.created_at, COALESCE(GROUP_CONCAT(t.name, ' '), '')
+		FROM notes n
+		LEFT JOIN note_tags nt ON nt.note_id = n.id
+		LEFT JOIN tags t ON t.id = nt.tag_id
+		WHERE n.deleted_at IS NULL
+			AND n.id IN (
+				SELECT nt.note_id FROM note_tags nt
+				JOIN tags t ON t.id = nt.tag_id
+				WHERE t.name IN ('todo', 'project')
+			)
+			AND (
+				NOT EXISTS (SELECT 1 FROM schema_version WHERE version = 11)
+				OR julianday(n.created_at) < (SELECT julianday(applied_at) FROM schema_version WHERE version = 11)
+			)
+		GROUP BY n.id
+		ORDER BY n.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []activityNote
+	for rows.Next() {
+		var note activityNote
+		var content, tags string
+		if err := rows.Scan(&note.id, &content, &note.createdAt, &tags); err != nil {
+			return nil, err
+		}
+
+		if vault.IsSealed(content) {
+			if open == nil {
+				continue
+			}
+			if content, err = open(content); err != nil {
+				return nil, fmt.Errorf("note #%d: %w", note.id, err)
+			}
+		}
+
+		match := activityNoteRegex.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+		noteTags := strings.Fields(tags)
+		if !slices.Contains(noteTags, match[2]) || !slices.Contains(noteTags, activityNoteTags[match[1]]) {
+			continue
+		}
+
+		note.event = strings.ToLower(match[1])
+		note.kind = match[2]
+		note.summary = match[3]
+		found = append(found, note)
+	}
+
+	return found, rows.Err()
+}
+
+// findActivityEntity returns the ID of the only todo or project an activity
+// note can be about, or nil. Sealed todo content cannot be matched in SQL, so
+// when open is given every todo is opened and compared instead.
+func findActivityEntity(tx DBTX, kind, event, summary string, open func(string) (string, error)) (interface{}, error) {
+	var query, value string
+	switch {
+	case kind == "project":
+		query = "SELECT id, name FROM projects WHERE name = ?"
+		value, _, _ = strings.Cut(summary, " - ")
+	case event != "updated":
+		query = "SELECT id, content FROM todos WHERE content = ?"
+		value = activityDueRegex.ReplaceAllString(summary, "")
+	default:
+		return nil, nil
+	}
+
+	args := []interface{}{value}
+	if open != nil && kind == "todo" {
+		query, args = "SELECT id, content FROM todos", nil
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for len(ids) < 2 && rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, err
+		}
+
+		if open != nil && vault.IsSealed(content) {
+			if content, err = open(content); err != nil {
+				return nil, fmt.Errorf("%s #%d: %w", kind, id, err)
+			}
+		}
+
+		if content == value {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) != 1 {
+		return nil, nil
+	}
+	return ids[0], nil
+}
+
+func execSQL(statements string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(statements)
+		return err
+	}
+}
+
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   sql.NullTime
+}
+
+type SchemaTooNewError struct {
+	Version int
+	Latest  int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than this version of note supports (%d). Upgrade note with: note update", e.Version, e.Latest)
+}
+
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func Migrate(db *sql.DB) error {
+	if err := ensureSchemaVersionTable(db); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if current > LatestVersion() {
+		return &SchemaTooNewError{Version: current, Latest: LatestVersion()}
+	}
+
+	// Migrations that rewrite notes or todos fire the search index triggers,
+	// which fail in a build without FTS5. EnsureSearchIndex would drop them
+	// afterwards, so drop them before migrating instead.
+	if current < LatestVersion() {
+		available, err := SearchAvailable(db)
+		if err != nil {
+			return err
+		}
+		if !available {
+			if err := dropSearchTriggers(db); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %03d (%s) failed: %w", m.Version, m.Description, err)
+		}
+	}
+
+	return nil
+}
+
+func CurrentVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	rows, err := db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = MigrationStatus{Version: m.Version, Description: m.Description}
+		if appliedAt, ok := applied[m.Version]; ok {
+			statuses[i].AppliedAt = sql.NullTime{Time: appliedAt, Valid: true}
+		}
+	}
+
+	return statuses, nil
+}
+
+func ensureSchemaVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Another process may have applied this migration while we waited for
+	// the write lock.
+	var applied bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM schema_version WHERE version = ?)", m.Version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO schema_version (version, description, applied_at)
+		VALUES (?, ?, ?)
+	`, m.Version, m.Description, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func ensureHomeProject(db *sql.DB) error {
 	var exists bool
 	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE name = 'home')").Scan(&exists)
-	if err != nil {
+	if err != nil || exists {
 		return err
 	}
 
-	if !exists {
-		tx, err := db.Begin()
-		if err != nil {
+	return WithTx(db, func(tx DBTX) error {
+		// Check again now that we hold the write lock, in case another
+		// process created it first.
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE name = 'home')").Scan(&exists); err != nil || exists {
 			return err
 		}
-		defer tx.Rollback()
 
 		result, err := tx.Exec(`
 			INSERT INTO projects (name, created_at, first_activated_at, is_closed)
@@ -109,14 +755,6 @@ func ensureHomeProject(db *sql.DB) error {
 			INSERT INTO active_project (project_id, activated_at)
 			VALUES (?, CURRENT_TIMESTAMP)
 		`, projectID)
-		if err != nil {
-			return err
-		}
-
-		if err := tx.Commit(); err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return err
+	})
 }
@@ -0,0 +1,440 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nathan-nicholson/note/internal/vault"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?mode=memory&cache=shared&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func TestMigrate_FreshDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	version, err := CurrentVersion(db)
+	if err != nil {
+		t.Fatalf("CurrentVersion() error = %v", err)
+	}
+
+	if version != LatestVersion() {
+		t.Errorf("CurrentVersion() = %d, want %d", version, LatestVersion())
+	}
+
+	for _, table := range []string{"notes", "todos", "tags", "note_tags", "todo_tags", "projects", "project_tags", "active_project"} {
+		var name string
+		err := db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&name)
+		if err != nil {
+			t.Errorf("table %q missing after Migrate(): %v", table, err)
+		}
+	}
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("first Migrate() error = %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&count); err != nil {
+		t.Fatalf("failed to count schema_version rows: %v", err)
+	}
+
+	if count != len(migrations) {
+		t.Errorf("schema_version has %d rows, want %d", count, len(migrations))
+	}
+}
+
+func TestMigrate_LegacyDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// Databases created before versioned migrations have the tables but no
+	// schema_version row.
+	if _, err := db.Exec(`
+		CREATE TABLE notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			is_important BOOLEAN NOT NULL DEFAULT 0
+		);
+		INSERT INTO notes (content) VALUES ('existing note');
+	`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var content string
+	if err := db.QueryRow("SELECT content FROM notes WHERE id = 1").Scan(&content); err != nil {
+		t.Fatalf("existing note lost after Migrate(): %v", err)
+	}
+
+	if content != "existing note" {
+		t.Errorf("existing note content = %q, want %q", content, "existing note")
+	}
+}
+
+func TestMigrate_BackfillsLinks(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.Exec(`
+		CREATE TABLE notes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			content TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			is_important BOOLEAN NOT NULL DEFAULT 0
+		);
+		INSERT INTO notes (content) VALUES ('first');
+		INSERT INTO notes (content) VALUES ('see [[#1]] and [[project:home]]');
+	`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM links WHERE source_kind = 'note' AND source_id = 2").Scan(&count); err != nil {
+		t.Fatalf("Failed to count links: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("links from note 2 = %d, want 2", count)
+	}
+}
+
+// activityNoteSchema is a database from before the numbered migrations, when
+// activity was still written as notes.
+const activityNoteSchema = `
+	CREATE TABLE notes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		is_important BOOLEAN NOT NULL DEFAULT 0
+	);
+	CREATE TABLE todos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		content TEXT NOT NULL,
+		is_complete BOOLEAN NOT NULL DEFAULT 0,
+		due_date DATE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP
+	);
+	CREATE TABLE tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE note_tags (
+		note_id INTEGER NOT NULL,
+		tag_id INTEGER NOT NULL,
+		FOREIGN KEY (note_id) REFERENCES notes(id) ON DELETE CASCADE,
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+		PRIMARY KEY (note_id, tag_id)
+	);
+`
+
+func TestMigrate_MovesActivityNotes(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.Exec(activityNoteSchema + `
+		INSERT INTO todos (content) VALUES ('Ship it');
+		INSERT INTO notes (content, created_at) VALUES ('Created todo: Ship it (due: 2025-11-03)', '2025-11-01 09:00:00');
+		INSERT INTO notes (content) VALUES ('Created todo: written by hand');
+		INSERT INTO notes (content) VALUES ('Updated todo: Removed due date');
+		INSERT INTO tags (name) VALUES ('todo'), ('create'), ('work'), ('update');
+		INSERT INTO note_tags (note_id, tag_id) VALUES (1, 1), (1, 2), (1, 3), (2, 3), (3, 1), (3, 4);
+	`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var notes, noteID int
+	if err := db.QueryRow("SELECT COUNT(*), COALESCE(MAX(id), 0) FROM notes").Scan(&notes, &noteID); err != nil {
+		t.Fatalf("Failed to count notes: %v", err)
+	}
+	if notes != 1 || noteID != 2 {
+		t.Errorf("%d notes left, want only note 2, written by hand", notes)
+	}
+
+	rows, err := db.Query("SELECT event, entity_kind, entity_id, summary, created_at FROM activity ORDER BY id")
+	if err != nil {
+		t.Fatalf("Failed to read activity: %v", err)
+	}
+	defer rows.Close()
+
+	type entry struct {
+		event, kind string
+		entityID    sql.NullInt64
+		summary     string
+		createdAt   string
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.event, &e.kind, &e.entityID, &e.summary, &e.createdAt); err != nil {
+			t.Fatalf("Failed to read activity: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("activity has %d entries, want 2", len(entries))
+	}
+	if e := entries[0]; e.event != "created" || e.kind != "todo" || e.entityID.Int64 != 1 || e.summary != "Ship it (due: 2025-11-03)" {
+		t.Errorf("activity[0] = %+v, want todo 1 created", e)
+	}
+	if e := entries[0]; e.createdAt[:10] != "2025-11-01" {
+		t.Errorf("activity[0] created at %s, want the note's timestamp", e.createdAt)
+	}
+	if e := entries[1]; e.event != "updated" || e.entityID.Valid {
+		t.Errorf("activity[1] = %+v, want an update of an unknown todo", e)
+	}
+
+	var tags int
+	if err := db.QueryRow("SELECT COUNT(*) FROM tags WHERE name IN ('todo', 'create', 'update')").Scan(&tags); err != nil {
+		t.Fatalf("Failed to count tags: %v", err)
+	}
+	if tags != 0 {
+		t.Errorf("%d activity tags left, want them removed", tags)
+	}
+}
+
+func TestMigrate_DropsSearchTriggersFirst(t *testing.T) {
+	db := openTestDB(t)
+
+	available, err := SearchAvailable(db)
+	if err != nil {
+		t.Fatalf("SearchAvailable() error = %v", err)
+	}
+	if available {
+		t.Skip("the search triggers only fail in a build without FTS5")
+	}
+
+	// A search-enabled build left its triggers behind. Without FTS5 they
+	// fail as soon as migration 11 deletes an activity note.
+	if _, err := db.Exec(activityNoteSchema + `
+		CREATE TRIGGER notes_fts_delete AFTER DELETE ON notes BEGIN
+			INSERT INTO notes_fts (notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		END;
+
+		INSERT INTO notes (content) VALUES ('Created todo: Ship it');
+		INSERT INTO tags (name) VALUES ('todo'), ('create');
+		INSERT INTO note_tags (note_id, tag_id) VALUES (1, 1), (1, 2);
+	`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var notes int
+	if err := db.QueryRow("SELECT COUNT(*) FROM notes").Scan(&notes); err != nil {
+		t.Fatalf("Failed to count notes: %v", err)
+	}
+	if notes != 0 {
+		t.Errorf("%d notes left, want the activity note moved", notes)
+	}
+}
+
+func TestMoveSealedActivityNotes(t *testing.T) {
+	db := openTestDB(t)
+
+	c, err := vault.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	seal := func(plaintext string) string {
+		t.Helper()
+		sealed, err := c.Seal(plaintext)
+		if err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+		return sealed
+	}
+
+	if _, err := db.Exec(activityNoteSchema+`
+		INSERT INTO todos (content) VALUES (?);
+		INSERT INTO notes (content, created_at) VALUES (?, '2025-11-01 09:00:00');
+		INSERT INTO tags (name) VALUES ('todo'), ('create');
+		INSERT INTO note_tags (note_id, tag_id) VALUES (1, 1), (1, 2);
+	`, seal("Ship it"), seal("Created todo: Ship it")); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// Written by the user after the migration, so not activity.
+	if _, err := db.Exec(`
+		INSERT INTO notes (content, created_at) VALUES (?, '2099-01-01 00:00:00');
+		INSERT INTO note_tags (note_id, tag_id) VALUES (2, 1), (2, 2);
+	`, seal("Created todo: Ship it")); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	var notes int
+	if err := db.QueryRow("SELECT COUNT(*) FROM notes").Scan(&notes); err != nil {
+		t.Fatalf("Failed to count notes: %v", err)
+	}
+	if notes != 2 {
+		t.Fatalf("%d notes left after migrating, want the sealed notes kept", notes)
+	}
+
+	for range 2 {
+		if err := MoveSealedActivityNotes(db, c.Open, c.Seal); err != nil {
+			t.Fatalf("MoveSealedActivityNotes() error = %v", err)
+		}
+	}
+
+	var noteID int
+	if err := db.QueryRow("SELECT COUNT(*), COALESCE(MAX(id), 0) FROM notes").Scan(&notes, &noteID); err != nil {
+		t.Fatalf("Failed to count notes: %v", err)
+	}
+	if notes != 1 || noteID != 2 {
+		t.Errorf("%d notes left, want only note 2, written after the migration", notes)
+	}
+
+	var entries int
+	var entityID sql.NullInt64
+	var summary string
+	err = db.QueryRow("SELECT COUNT(*), MAX(entity_id), MAX(summary) FROM activity WHERE event = 'created'").Scan(&entries, &entityID, &summary)
+	if err != nil {
+		t.Fatalf("Failed to read activity: %v", err)
+	}
+	if entries != 1 || entityID.Int64 != 1 {
+		t.Errorf("activity has %d entries for todo %v, want one for todo 1", entries, entityID)
+	}
+	if !vault.IsSealed(summary) {
+		t.Errorf("activity summary %q is stored in plaintext", summary)
+	}
+	if opened, err := c.Open(summary); err != nil || opened != "Ship it" {
+		t.Errorf("activity summary = %q, %v, want %q", opened, err, "Ship it")
+	}
+}
+
+func TestFindActivityNotes_ComparesTimes(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// 08:00:00.5 UTC, stored with its offset as the driver writes it.
+	applied := time.Date(2025, 11, 1, 10, 0, 0, 500000000, time.FixedZone("", 2*60*60))
+	if _, err := db.Exec("UPDATE schema_version SET applied_at = ? WHERE version = 11", applied); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	// Note 1 was written before the migration and note 2 after it, though its
+	// timestamp sorts first as text.
+	if _, err := db.Exec(`
+		INSERT INTO notes (content, created_at) VALUES
+			('Created todo: Ship it', '2025-11-01 07:59:59'),
+			('Created todo: Ship it', '2025-11-01 09:00:00');
+		INSERT OR IGNORE INTO tags (name) VALUES ('todo'), ('create');
+		INSERT INTO note_tags (note_id, tag_id)
+			SELECT n.id, t.id FROM notes n, tags t WHERE t.name IN ('todo', 'create');
+	`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	found, err := findActivityNotes(db, nil)
+	if err != nil {
+		t.Fatalf("findActivityNotes() error = %v", err)
+	}
+	if len(found) != 1 || found[0].id != 1 {
+		t.Errorf("findActivityNotes() = %+v, want only note 1", found)
+	}
+}
+
+func TestMigrate_RejectsNewerSchema(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	if _, err := db.Exec("INSERT INTO schema_version (version, description) VALUES (?, 'from the future')", LatestVersion()+1); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	err := Migrate(db)
+
+	var tooNew *SchemaTooNewError
+	if !errors.As(err, &tooNew) {
+		t.Fatalf("Migrate() error = %v, want *SchemaTooNewError", err)
+	}
+
+	if tooNew.Version != LatestVersion()+1 {
+		t.Errorf("SchemaTooNewError.Version = %d, want %d", tooNew.Version, LatestVersion()+1)
+	}
+}
+
+func TestGetMigrationStatus(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() error = %v", err)
+	}
+
+	if len(statuses) != len(migrations) {
+		t.Fatalf("GetMigrationStatus() returned %d entries, want %d", len(statuses), len(migrations))
+	}
+
+	for _, status := range statuses {
+		if !status.AppliedAt.Valid {
+			t.Errorf("migration %d reported as pending after Migrate()", status.Version)
+		}
+	}
+}
+
+func TestMigrations_ContiguousVersions(t *testing.T) {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migrations[%d].Version = %d, want %d", i, m.Version, i+1)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// The full-text index lives outside the numbered migrations because FTS5 is a
+// compile-time option of go-sqlite3 (build with -tags sqlite_fts5). A binary
+// built without it must still be able to open and write to a database that a
+// search-enabled build has indexed, so the sync triggers are dropped when the
+// module is missing and recreated, with a full rebuild, once it is back.
+//
+// An encrypted database has no index at all: indexing ciphertext is useless,
+// and indexing the plaintext would write it to disk.
+const searchIndexSchema = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(
+		content,
+		content='notes',
+		content_rowid='id',
+		tokenize='unicode61'
+	);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+		content,
+		content='todos',
+		content_rowid='id',
+		tokenize='unicode61'
+	);
+`
+
+const searchTriggers = `
+	CREATE TRIGGER IF NOT EXISTS notes_fts_insert AFTER INSERT ON notes BEGIN
+		INSERT INTO notes_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS notes_fts_delete AFTER DELETE ON notes BEGIN
+		INSERT INTO notes_fts (notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS notes_fts_update AFTER UPDATE OF content ON notes BEGIN
+		INSERT INTO notes_fts (notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO notes_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+		INSERT INTO todos_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+		INSERT INTO todos_fts (todos_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF content ON todos BEGIN
+		INSERT INTO todos_fts (todos_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO todos_fts (rowid, content) VALUES (new.id, new.content);
+	END;
+`
+
+var searchTriggerNames = []string{
+	"notes_fts_insert", "notes_fts_delete", "notes_fts_update",
+	"todos_fts_insert", "todos_fts_delete", "todos_fts_update",
+}
+
+func SearchAvailable(db DBTX) (bool, error) {
+	var available bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available)
+	return available, err
+}
+
+// Encrypted reports whether note and todo content in db is encrypted.
+func Encrypted(db DBTX) (bool, error) {
+	var encrypted bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM encryption)").Scan(&encrypted)
+	return encrypted, err
+}
+
+func EnsureSearchIndex(db *sql.DB) error {
+	encrypted, err := Encrypted(db)
+	if err != nil {
+		return err
+	}
+
+	if encrypted {
+		return DropSearchIndex(db)
+	}
+
+	available, err := SearchAvailable(db)
+	if err != nil {
+		return err
+	}
+
+	if !available {
+		return dropSearchTriggers(db)
+	}
+
+	var triggerCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'trigger' AND name IN ('notes_fts_insert', 'notes_fts_delete', 'notes_fts_update',
+			'todos_fts_insert', 'todos_fts_delete', 'todos_fts_update')
+	`).Scan(&triggerCount)
+	if err != nil {
+		return err
+	}
+
+	if triggerCount == len(searchTriggerNames) {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(searchIndexSchema); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(searchTriggers); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO notes_fts (notes_fts) VALUES ('rebuild')"); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO todos_fts (todos_fts) VALUES ('rebuild')"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DropSearchIndex removes the index tables along with their triggers. Only a
+// build with FTS5 can drop the tables, so without it an existing index is an
+// error rather than being left behind.
+func DropSearchIndex(db DBTX) error {
+	if err := dropSearchTriggers(db); err != nil {
+		return err
+	}
+
+	available, err := SearchAvailable(db)
+	if err != nil {
+		return err
+	}
+
+	if !available {
+		var indexed bool
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name IN ('notes_fts', 'todos_fts'))").Scan(&indexed)
+		if err != nil {
+			return err
+		}
+		if indexed {
+			return fmt.Errorf("the search index can only be removed by a build of note with full-text search (build with -tags sqlite_fts5)")
+		}
+		return nil
+	}
+
+	_, err = db.Exec("DROP TABLE IF EXISTS notes_fts; DROP TABLE IF EXISTS todos_fts")
+	return err
+}
+
+func dropSearchTriggers(db DBTX) error {
+	for _, name := range searchTriggerNames {
+		if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
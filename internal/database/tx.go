@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// maxTxAttempts bounds how often WithTx retries a transaction that failed
+// because another process held the database.
+const maxTxAttempts = 5
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx, so repository functions can
+// run on their own or as part of a caller's transaction.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// WithTx runs fn in a transaction on db, committing if fn succeeds and rolling
+// back otherwise. When db is already a transaction fn joins it, and the
+// outermost caller decides whether to commit.
+//
+// A transaction that fails with SQLITE_BUSY or SQLITE_LOCKED is rolled back
+// and run again after a short randomized backoff, so fn must not have side
+// effects outside the database that cannot be repeated.
+func WithTx(db DBTX, fn func(tx DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(conn, fn)
+		if err == nil || !IsBusy(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		backoff := time.Duration(attempt*attempt) * 20 * time.Millisecond
+		time.Sleep(backoff + rand.N(backoff))
+	}
+}
+
+func runTx(db *sql.DB, fn func(tx DBTX) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsBusy reports whether err means another connection held a lock that this
+// one needed.
+func IsBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
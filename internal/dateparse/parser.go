@@ -2,6 +2,8 @@ package dateparse
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -38,10 +40,61 @@ func ParseDate(input string) (time.Time, error) {
 		return time.Date(nextMonth.Year(), nextMonth.Month(), nextMonth.Day(), 0, 0, 0, 0, nextMonth.Location()), nil
 
 	default:
+		if weekday, ok := weekdays[strings.ToLower(input)]; ok {
+			return nextWeekday(now, weekday), nil
+		}
+
 		t, err := time.Parse("2006-01-02", input)
 		if err != nil {
-			return time.Time{}, fmt.Errorf("Invalid date '%s'. Use YYYY-MM-DD or: today, tomorrow, end-of-week, end-of-month, next-week, next-month", input)
+			return time.Time{}, fmt.Errorf("Invalid date '%s'. Use YYYY-MM-DD, a weekday name or: today, tomorrow, end-of-week, end-of-month, next-week, next-month", input)
 		}
 		return t, nil
 	}
 }
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// nextWeekday returns the next day after now that falls on weekday, so that,
+// like end-of-week, naming today's weekday means a week from today.
+func nextWeekday(now time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	next := now.AddDate(0, 0, days)
+	return time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, next.Location())
+}
+
+// ParseAge parses a lookback period such as "30d", "2w" or "12h". Days and
+// weeks are not supported by time.ParseDuration, so they are handled here.
+func ParseAge(input string) (time.Duration, error) {
+	invalid := fmt.Errorf("Invalid age '%s'. Use a number followed by h, d or w (e.g. 12h, 30d, 2w)", input)
+
+	if len(input) < 2 {
+		return 0, invalid
+	}
+
+	n, err := strconv.Atoi(input[:len(input)-1])
+	if err != nil || n < 0 {
+		return 0, invalid
+	}
+
+	switch input[len(input)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, invalid
+	}
+}
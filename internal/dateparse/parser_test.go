@@ -178,6 +178,32 @@ func TestParseDate_SpecificDate_Invalid(t *testing.T) {
 	}
 }
 
+func TestParseDate_Weekday(t *testing.T) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for name, weekday := range weekdays {
+		t.Run(name, func(t *testing.T) {
+			result, err := ParseDate(name)
+			if err != nil {
+				t.Fatalf("ParseDate(%q) returned error: %v", name, err)
+			}
+
+			if result.Weekday() != weekday {
+				t.Errorf("ParseDate(%q) returned %v (weekday: %v)", name, result, result.Weekday())
+			}
+
+			if !result.After(today) || result.After(today.AddDate(0, 0, 7)) {
+				t.Errorf("ParseDate(%q) = %v, want a day within the next week", name, result)
+			}
+		})
+	}
+
+	if _, err := ParseDate("Friday"); err != nil {
+		t.Errorf("ParseDate(\"Friday\") returned error: %v", err)
+	}
+}
+
 func TestParseDate_AllKeywords(t *testing.T) {
 	keywords := []string{
 		"today",
@@ -205,3 +231,38 @@ func TestParseDate_AllKeywords(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{input: "12h", want: 12 * time.Hour},
+		{input: "30d", want: 30 * 24 * time.Hour},
+		{input: "2w", want: 14 * 24 * time.Hour},
+		{input: "0d", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseAge(tt.input)
+			if err != nil {
+				t.Fatalf("ParseAge(%q) returned error: %v", tt.input, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseAge(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAge_Invalid(t *testing.T) {
+	for _, input := range []string{"", "d", "30", "30m", "-1d", "1.5d", "thirty-days"} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := ParseAge(input); err == nil {
+				t.Errorf("ParseAge(%q) expected error, got nil", input)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package dateparse
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repeat is how often a recurring todo comes back: every Interval days,
+// weeks, months or years. Weekly repeats may name the weekdays and monthly
+// repeats the days of the month, with -1 for the last day.
+type Repeat struct {
+	Freq      string
+	Interval  int
+	Weekdays  []time.Weekday
+	MonthDays []int
+}
+
+var repeatUnits = map[string]string{
+	"day": "daily", "days": "daily",
+	"week": "weekly", "weeks": "weekly",
+	"month": "monthly", "months": "monthly",
+	"year": "yearly", "years": "yearly",
+}
+
+var freqUnits = map[string]string{"daily": "days", "weekly": "weeks", "monthly": "months", "yearly": "years"}
+
+var rruleFreqs = map[string]string{"DAILY": "daily", "WEEKLY": "weekly", "MONTHLY": "monthly", "YEARLY": "yearly"}
+
+var rruleDays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRepeat accepts daily, weekly, monthly, yearly and weekdays; a list of
+// days after a colon, as in weekly:mon,thu or monthly:1,15 or monthly:last;
+// every N days, weeks, months or years, optionally with such a list; and the
+// FREQ, INTERVAL, BYDAY and BYMONTHDAY parts of an RRULE.
+func ParseRepeat(input string) (Repeat, error) {
+	invalid := fmt.Errorf("Invalid repeat '%s'. Use daily, weekly, weekly:mon,thu, monthly, monthly:last, yearly, every 2 weeks or an RRULE such as FREQ=WEEKLY;BYDAY=MO,TH", input)
+
+	value := strings.ToLower(strings.TrimSpace(input))
+	if strings.HasPrefix(value, "rrule:") || strings.HasPrefix(value, "freq=") {
+		r, err := parseRRule(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(input)), "RRULE:"))
+		if err != nil {
+			return Repeat{}, invalid
+		}
+		return r, nil
+	}
+
+	if value == "weekdays" {
+		value = "weekly:mon,tue,wed,thu,fri"
+	}
+
+	base, list, hasList := strings.Cut(value, ":")
+
+	r := Repeat{Interval: 1}
+	if _, ok := freqUnits[base]; ok {
+		r.Freq = base
+	} else if words := strings.Fields(base); len(words) >= 2 && len(words) <= 3 && words[0] == "every" {
+		if len(words) == 3 {
+			n, err := strconv.Atoi(words[1])
+			if err != nil || n < 1 {
+				return Repeat{}, invalid
+			}
+			r.Interval = n
+		}
+		r.Freq = repeatUnits[words[len(words)-1]]
+	}
+	if r.Freq == "" {
+		return Repeat{}, invalid
+	}
+
+	if hasList {
+		for _, item := range strings.Split(list, ",") {
+			item = strings.TrimSpace(item)
+			switch r.Freq {
+			case "weekly":
+				weekday, ok := parseWeekday(item)
+				if !ok {
+					return Repeat{}, invalid
+				}
+				r.Weekdays = append(r.Weekdays, weekday)
+			case "monthly":
+				day, ok := parseMonthDay(item)
+				if !ok {
+					return Repeat{}, invalid
+				}
+				r.MonthDays = append(r.MonthDays, day)
+			default:
+				return Repeat{}, invalid
+			}
+		}
+	}
+
+	return r.normalized(), nil
+}
+
+func parseRRule(rule string) (Repeat, error) {
+	r := Repeat{Interval: 1}
+
+	for _, part := range strings.Split(strings.TrimSuffix(rule, ";"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Repeat{}, fmt.Errorf("invalid RRULE part '%s'", part)
+		}
+
+		switch key {
+		case "FREQ":
+			r.Freq = rruleFreqs[value]
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Repeat{}, fmt.Errorf("invalid INTERVAL '%s'", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				weekday, ok := rruleDays[code]
+				if !ok {
+					return Repeat{}, fmt.Errorf("invalid BYDAY '%s'", code)
+				}
+				r.Weekdays = append(r.Weekdays, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, ok := parseMonthDay(item)
+				if !ok {
+					return Repeat{}, fmt.Errorf("invalid BYMONTHDAY '%s'", item)
+				}
+				r.MonthDays = append(r.MonthDays, day)
+			}
+		default:
+			return Repeat{}, fmt.Errorf("unsupported RRULE part '%s'", key)
+		}
+	}
+
+	if r.Freq == "" ||
+		len(r.Weekdays) > 0 && r.Freq != "weekly" ||
+		len(r.MonthDays) > 0 && r.Freq != "monthly" {
+		return Repeat{}, fmt.Errorf("unsupported RRULE '%s'", rule)
+	}
+
+	return r.normalized(), nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for full, weekday := range weekdays {
+		if name == full || len(name) >= 3 && strings.HasPrefix(full, name) {
+			return weekday, true
+		}
+	}
+	return 0, false
+}
+
+func parseMonthDay(value string) (int, bool) {
+	if value == "last" {
+		return -1, true
+	}
+	day, err := strconv.Atoi(value)
+	if err != nil || day != -1 && (day < 1 || day > 31) {
+		return 0, false
+	}
+	return day, true
+}
+
+// normalized sorts the day lists, Monday first and the last day of the month
+// last, and drops repeats, so that equal rules print the same.
+func (r Repeat) normalized() Repeat {
+	slices.SortFunc(r.Weekdays, func(a, b time.Weekday) int { return (int(a)+6)%7 - (int(b)+6)%7 })
+	r.Weekdays = slices.Compact(r.Weekdays)
+
+	slices.SortFunc(r.MonthDays, func(a, b int) int { return monthDaySortKey(a) - monthDaySortKey(b) })
+	r.MonthDays = slices.Compact(r.MonthDays)
+
+	return r
+}
+
+func monthDaySortKey(day int) int {
+	if day == -1 {
+		return 32
+	}
+	return day
+}
+
+// String writes the rule in the form ParseRepeat reads, such as weekly,
+// every 2 weeks:mon,thu or monthly:1,last.
+func (r Repeat) String() string {
+	s := r.Freq
+	if r.Interval > 1 {
+		s = fmt.Sprintf("every %d %s", r.Interval, freqUnits[r.Freq])
+	}
+
+	var list []string
+	for _, weekday := range r.Weekdays {
+		list = append(list, strings.ToLower(weekday.String()[:3]))
+	}
+	for _, day := range r.MonthDays {
+		if day == -1 {
+			list = append(list, "last")
+		} else {
+			list = append(list, strconv.Itoa(day))
+		}
+	}
+
+	if len(list) > 0 {
+		s += ":" + strings.Join(list, ",")
+	}
+	return s
+}
+
+// First returns the first occurrence on or after from.
+func (r Repeat) First(from time.Time) time.Time {
+	from = midnight(from)
+	if r.matches(from) {
+		return from
+	}
+	return r.Next(from)
+}
+
+// Next returns the first occurrence after the given day. Intervals count from
+// that day, so every 2 weeks:mon,thu after a Monday gives the Thursday of the
+// same week and then the Monday two weeks on.
+func (r Repeat) Next(after time.Time) time.Time {
+	after = midnight(after)
+
+	switch r.Freq {
+	case "weekly":
+		if len(r.Weekdays) == 0 {
+			return after.AddDate(0, 0, 7*r.Interval)
+		}
+		weekStart := after.AddDate(0, 0, -((int(after.Weekday()) + 6) % 7))
+		for day := after.AddDate(0, 0, 1); ; day = day.AddDate(0, 0, 1) {
+			week := int(day.Sub(weekStart).Hours()/24+0.5) / 7
+			if week%r.Interval == 0 && slices.Contains(r.Weekdays, day.Weekday()) {
+				return day
+			}
+		}
+
+	case "monthly":
+		if len(r.MonthDays) == 0 {
+			return addMonths(after, r.Interval, after.Day())
+		}
+		for months := 0; ; months += r.Interval {
+			for _, day := range r.MonthDays {
+				if candidate := addMonths(after, months, day); candidate.After(after) {
+					return candidate
+				}
+			}
+		}
+
+	case "yearly":
+		return addMonths(after, 12*r.Interval, after.Day())
+
+	default:
+		return after.AddDate(0, 0, r.Interval)
+	}
+}
+
+// Advance returns the next due date of a recurring todo due on due: the first
+// occurrence after it that is not before today, so a todo left overdue for a
+// while does not come back already overdue.
+func (r Repeat) Advance(due, today time.Time) time.Time {
+	today = midnight(today)
+	next := r.Next(due)
+	for next.Before(today) {
+		next = r.Next(next)
+	}
+	return next
+}
+
+func (r Repeat) matches(day time.Time) bool {
+	switch {
+	case len(r.Weekdays) > 0:
+		return slices.Contains(r.Weekdays, day.Weekday())
+	case len(r.MonthDays) > 0:
+		for _, monthDay := range r.MonthDays {
+			if addMonths(day, 0, monthDay).Equal(day) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// addMonths moves to the given day of the month months after t, using the
+// last day of the month for -1 and for days the month does not have.
+func addMonths(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if day == -1 || day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,137 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseRepeat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"daily", "daily"},
+		{"Weekly", "weekly"},
+		{"weekly:thu,MON,monday", "weekly:mon,thu"},
+		{"weekdays", "weekly:mon,tue,wed,thu,fri"},
+		{"monthly:last", "monthly:last"},
+		{"monthly:last,15,1", "monthly:1,15,last"},
+		{"yearly", "yearly"},
+		{"every day", "daily"},
+		{"every 2 weeks", "every 2 weeks"},
+		{"every 2 weeks:sun,wed", "every 2 weeks:wed,sun"},
+		{"every 3 months:-1", "every 3 months:last"},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", "weekly:mon,thu"},
+		{"RRULE:FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=-1", "every 2 months:last"},
+		{"freq=daily;interval=3", "every 3 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := ParseRepeat(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRepeat(%q) error = %v", tt.input, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("ParseRepeat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+
+			again, err := ParseRepeat(r.String())
+			if err != nil || again.String() != tt.want {
+				t.Errorf("ParseRepeat(%q) does not read back: %v, %v", r.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestParseRepeat_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"sometimes",
+		"every",
+		"every 0 days",
+		"every -1 weeks",
+		"every 2 fortnights",
+		"weekly:funday",
+		"daily:mon",
+		"monthly:32",
+		"monthly:0",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;COUNT=3",
+		"INTERVAL=2",
+	} {
+		if _, err := ParseRepeat(input); err == nil {
+			t.Errorf("ParseRepeat(%q) should fail", input)
+		}
+	}
+}
+
+func TestRepeatNext(t *testing.T) {
+	tests := []struct {
+		rule  string
+		after string
+		want  string
+	}{
+		{"daily", "2025-11-03", "2025-11-04"},
+		{"every 3 days", "2025-11-03", "2025-11-06"},
+		{"weekly", "2025-11-03", "2025-11-10"},
+		// 2025-11-03 is a Monday.
+		{"weekly:mon,thu", "2025-11-03", "2025-11-06"},
+		{"weekly:mon,thu", "2025-11-06", "2025-11-10"},
+		{"every 2 weeks:mon,thu", "2025-11-06", "2025-11-17"},
+		{"every 2 weeks:mon,thu", "2025-11-09", "2025-11-17"},
+		{"monthly", "2025-01-31", "2025-02-28"},
+		{"monthly:last", "2025-01-31", "2025-02-28"},
+		{"monthly:last", "2025-02-10", "2025-02-28"},
+		{"monthly:1,15", "2025-02-10", "2025-02-15"},
+		{"monthly:1,15", "2025-02-15", "2025-03-01"},
+		{"monthly:31", "2025-03-31", "2025-04-30"},
+		{"every 2 months:last", "2025-01-31", "2025-03-31"},
+		{"yearly", "2024-02-29", "2025-02-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+" after "+tt.after, func(t *testing.T) {
+			r, err := ParseRepeat(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRepeat() error = %v", err)
+			}
+			if got := r.Next(date(tt.after)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatFirstAndAdvance(t *testing.T) {
+	r, err := ParseRepeat("weekly:mon,thu")
+	if err != nil {
+		t.Fatalf("ParseRepeat() error = %v", err)
+	}
+
+	if got := r.First(date("2025-11-03")).Format("2006-01-02"); got != "2025-11-03" {
+		t.Errorf("First(Monday) = %s, want the same day", got)
+	}
+	if got := r.First(date("2025-11-04")).Format("2006-01-02"); got != "2025-11-06" {
+		t.Errorf("First(Tuesday) = %s, want Thursday", got)
+	}
+
+	// Due a month ago: the next occurrence is the first one from today.
+	if got := r.Advance(date("2025-10-02"), date("2025-11-04")).Format("2006-01-02"); got != "2025-11-06" {
+		t.Errorf("Advance() = %s, want 2025-11-06", got)
+	}
+	// Due in the future: the occurrence after it.
+	if got := r.Advance(date("2025-11-10"), date("2025-11-04")).Format("2006-01-02"); got != "2025-11-13" {
+		t.Errorf("Advance() = %s, want 2025-11-13", got)
+	}
+}
@@ -0,0 +1,155 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+type Line struct {
+	Op   Op
+	Text string
+}
+
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Lines returns the shortest edit script turning a into b, computed from the
+// longest common subsequence. Notes are short, so the quadratic table is fine.
+func Lines(a, b []string) []Line {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: Delete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Op: Delete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Op: Insert, Text: b[j]})
+	}
+
+	return lines
+}
+
+// Unified renders the differences between a and b in unified diff format with
+// the given number of context lines. It returns an empty string when the
+// inputs are identical.
+func Unified(fromName, toName, a, b string, context int) string {
+	lines := Lines(SplitLines(a), SplitLines(b))
+
+	// aPos[k] and bPos[k] count the lines of a and b consumed before lines[k].
+	aPos := make([]int, len(lines)+1)
+	bPos := make([]int, len(lines)+1)
+	for k, line := range lines {
+		aPos[k+1], bPos[k+1] = aPos[k], bPos[k]
+		if line.Op != Insert {
+			aPos[k+1]++
+		}
+		if line.Op != Delete {
+			bPos[k+1]++
+		}
+	}
+
+	var output strings.Builder
+
+	i := 0
+	for i < len(lines) {
+		for i < len(lines) && lines[i].Op == Equal {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		lastChange := i
+		for j := i; j < len(lines) && j-lastChange <= 2*context; j++ {
+			if lines[j].Op != Equal {
+				lastChange = j
+			}
+		}
+
+		end := lastChange + context + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		if output.Len() == 0 {
+			output.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+		}
+
+		aCount := aPos[end] - aPos[start]
+		bCount := bPos[end] - bPos[start]
+		aStart := aPos[start]
+		if aCount > 0 {
+			aStart++
+		}
+		bStart := bPos[start]
+		if bCount > 0 {
+			bStart++
+		}
+
+		output.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount))
+
+		for _, line := range lines[start:end] {
+			switch line.Op {
+			case Equal:
+				output.WriteString(" ")
+			case Delete:
+				output.WriteString("-")
+			case Insert:
+				output.WriteString("+")
+			}
+			output.WriteString(line.Text + "\n")
+		}
+
+		i = end
+	}
+
+	return output.String()
+}
@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	a := []string{"one", "two", "three"}
+	b := []string{"one", "2", "three", "four"}
+
+	got := Lines(a, b)
+	want := []Line{
+		{Op: Equal, Text: "one"},
+		{Op: Delete, Text: "two"},
+		{Op: Insert, Text: "2"},
+		{Op: Equal, Text: "three"},
+		{Op: Insert, Text: "four"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Lines() returned %d lines, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Lines()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "same\ntext",
+			b:    "same\ntext",
+			want: "",
+		},
+		{
+			name: "single line change",
+			a:    "Meeting at 2pm",
+			b:    "Meeting at 3pm",
+			want: "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-Meeting at 2pm\n+Meeting at 3pm\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "new",
+			want: "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+new\n",
+		},
+		{
+			name: "context is limited",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8",
+			b:    "1\n2\n3\n4\n5\n6\n7\neight",
+			want: "--- a\n+++ b\n@@ -6,3 +6,3 @@\n 6\n 7\n-8\n+eight\n",
+		},
+		{
+			name: "distant changes produce separate hunks",
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\nb",
+			b:    "A\n1\n2\n3\n4\n5\n6\n7\nB",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n-a\n+A\n 1\n 2\n@@ -7,3 +7,3 @@\n 6\n 7\n-b\n+B\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("a", "b", tt.a, tt.b, 2)
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
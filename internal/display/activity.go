@@ -0,0 +1,31 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// FormatActivityLog lists activity entries one per line, as in
+// "2025-11-03 09:15 AM  Completed todo #12: Ship it (due: 2025-11-03)".
+func FormatActivityLog(entries []models.Activity) string {
+	var output strings.Builder
+
+	for _, entry := range entries {
+		subject := entry.EntityKind
+		if entry.EntityKind == "todo" && entry.EntityID != 0 {
+			subject = fmt.Sprintf("todo #%d", entry.EntityID)
+		}
+
+		event := entry.Event
+		if event != "" {
+			event = strings.ToUpper(event[:1]) + event[1:]
+		}
+
+		summary := strings.ReplaceAll(entry.Summary, "\n", " ")
+		output.WriteString(fmt.Sprintf("%s  %s %s: %s\n", entry.CreatedAt.Format("2006-01-02 03:04 PM"), event, subject, summary))
+	}
+
+	return strings.TrimSpace(output.String())
+}
@@ -0,0 +1,47 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// FormatAttachmentList lists attachments with the path of the stored copy of
+// each, as returned by path.
+func FormatAttachmentList(attachments []models.Attachment, path func(hash string) string) string {
+	var output strings.Builder
+
+	for _, a := range attachments {
+		output.WriteString(fmt.Sprintf("[%d] %s  %s  %s\n", a.ID, a.Name, formatSize(a.Size), a.CreatedAt.Format("2006-01-02 03:04 PM")))
+		output.WriteString("    " + path(a.Hash) + "\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func formatAttachments(attachments []models.Attachment) string {
+	var output strings.Builder
+
+	output.WriteString("Attachments:\n")
+	for _, a := range attachments {
+		output.WriteString(fmt.Sprintf("  [%d] %s (%s)\n", a.ID, a.Name, formatSize(a.Size)))
+	}
+
+	return strings.TrimSuffix(output.String(), "\n")
+}
+
+func formatSize(size int64) string {
+	const kb, mb, gb = 1 << 10, 1 << 20, 1 << 30
+
+	switch {
+	case size < kb:
+		return fmt.Sprintf("%d B", size)
+	case size < mb:
+		return fmt.Sprintf("%.1f KB", float64(size)/kb)
+	case size < gb:
+		return fmt.Sprintf("%.1f MB", float64(size)/mb)
+	default:
+		return fmt.Sprintf("%.1f GB", float64(size)/gb)
+	}
+}
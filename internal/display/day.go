@@ -0,0 +1,156 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Day is everything recorded on one day: its notes, the todos created,
+// completed or due that day, and a summary of the day's activity log.
+type Day struct {
+	Date     time.Time
+	Notes    []models.Note
+	Todos    []models.Todo
+	Activity string
+}
+
+type dayEntry struct {
+	at      time.Time
+	label   string
+	id      int
+	content string
+	tags    []string
+}
+
+// timeline merges the notes with the creation and completion of todos,
+// oldest first.
+func (d Day) timeline() []dayEntry {
+	var entries []dayEntry
+	for _, note := range d.Notes {
+		label := ""
+		if note.IsImportant {
+			label = "[!]"
+		}
+		entries = append(entries, dayEntry{at: note.CreatedAt, label: label, id: note.ID, content: note.Content, tags: note.Tags})
+	}
+
+	for _, todo := range d.Todos {
+		if sameDay(todo.CreatedAt, d.Date) {
+			entries = append(entries, dayEntry{at: todo.CreatedAt, label: "Added todo", id: todo.ID, content: todo.Content, tags: todo.Tags})
+		}
+		if todo.CompletedAt.Valid && sameDay(todo.CompletedAt.Time, d.Date) {
+			entries = append(entries, dayEntry{at: todo.CompletedAt.Time, label: "Completed todo", id: todo.ID, content: todo.Content, tags: todo.Tags})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	return entries
+}
+
+func (d Day) due() []models.Todo {
+	var due []models.Todo
+	for _, todo := range d.Todos {
+		if todo.DueDate.Valid && sameDay(todo.DueDate.Time, d.Date) {
+			due = append(due, todo)
+		}
+	}
+	return due
+}
+
+func FormatDay(d Day) string {
+	var output strings.Builder
+
+	output.WriteString(d.Date.Format("Monday, 2006-01-02") + "\n")
+
+	due := d.due()
+	entries := d.timeline()
+	if len(due) == 0 && len(entries) == 0 && d.Activity == "" {
+		output.WriteString("\nNothing recorded.")
+		return output.String()
+	}
+
+	if todos := FormatTodoList(due); todos != "" {
+		output.WriteString("\n" + todos + "\n")
+	}
+
+	if len(entries) > 0 {
+		output.WriteString("\nTIMELINE\n")
+		for _, entry := range entries {
+			output.WriteString("  " + entry.at.Format("03:04 PM") + "  ")
+			if entry.label != "" {
+				output.WriteString(entry.label + " ")
+			}
+			output.WriteString(fmt.Sprintf("[#%d] ", entry.id))
+			output.WriteString(strings.ReplaceAll(entry.content, "\n", "\n"+strings.Repeat(" ", 12)))
+			for _, tag := range entry.tags {
+				output.WriteString(" #" + tag)
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if d.Activity != "" {
+		output.WriteString("\nActivity: " + d.Activity + "\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+// FormatDayMarkdown renders the day as Markdown for pasting into a daily log.
+func FormatDayMarkdown(d Day) string {
+	var output strings.Builder
+
+	output.WriteString("## " + d.Date.Format("Monday, 2006-01-02") + "\n")
+
+	if groups := GroupTodos(d.due()); len(groups) > 0 {
+		output.WriteString("\n### Due\n\n")
+		for _, group := range groups {
+			for _, todo := range group.Todos {
+				box := "[ ]"
+				if todo.IsComplete {
+					box = "[x]"
+				}
+				output.WriteString(fmt.Sprintf("- %s %s\n", box, markdownItem(todo.Content, todo.Tags)))
+			}
+		}
+	}
+
+	if entries := d.timeline(); len(entries) > 0 {
+		output.WriteString("\n### Timeline\n\n")
+		for _, entry := range entries {
+			label := ""
+			switch entry.label {
+			case "[!]":
+				label = "**Important:** "
+			case "":
+			default:
+				label = entry.label + ": "
+			}
+			output.WriteString(fmt.Sprintf("- %s %s%s\n", entry.at.Format("03:04 PM"), label, markdownItem(entry.content, entry.tags)))
+		}
+	}
+
+	if d.Activity != "" {
+		output.WriteString("\n_Activity: " + d.Activity + "_\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+// markdownItem indents continuation lines so multi-line content stays inside
+// its list item.
+func markdownItem(content string, tags []string) string {
+	item := strings.ReplaceAll(strings.TrimRight(content, "\n"), "\n", "\n  ")
+	for _, tag := range tags {
+		item += " #" + tag
+	}
+	return item
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
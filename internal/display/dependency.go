@@ -0,0 +1,94 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// FormatDependencyGraph draws every todo that nothing blocks with the todos
+// waiting on it indented below. A todo waiting on several others appears
+// under each of them.
+func FormatDependencyGraph(todos map[int]models.Todo, dependencies []models.TodoDependency) string {
+	waiting := make(map[int][]int)
+	blocked := make(map[int]bool)
+	for _, d := range dependencies {
+		waiting[d.BlockedBy] = append(waiting[d.BlockedBy], d.TodoID)
+		blocked[d.TodoID] = true
+	}
+
+	var roots []int
+	for id := range waiting {
+		if !blocked[id] {
+			roots = append(roots, id)
+		}
+	}
+	sort.Ints(roots)
+
+	var output strings.Builder
+
+	var draw func(id int, prefix string, last bool)
+	draw = func(id int, prefix string, last bool) {
+		branch, indent := "├─ ", "│  "
+		if last {
+			branch, indent = "└─ ", "   "
+		}
+		output.WriteString(prefix + branch + todoLine(todos[id]) + "\n")
+
+		children := waiting[id]
+		sort.Ints(children)
+		for i, child := range children {
+			draw(child, prefix+indent, i == len(children)-1)
+		}
+	}
+
+	for i, root := range roots {
+		if i > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(todoLine(todos[root]) + "\n")
+
+		children := waiting[root]
+		sort.Ints(children)
+		for j, child := range children {
+			draw(child, "", j == len(children)-1)
+		}
+	}
+
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// todoLine shows a todo on one line, as in the todo list.
+func todoLine(todo models.Todo) string {
+	var line strings.Builder
+
+	if todo.IsComplete {
+		line.WriteString("[X]")
+	} else {
+		line.WriteString("[ ]")
+	}
+
+	line.WriteString(fmt.Sprintf(" [#%d] ", todo.ID))
+	line.WriteString(priorityMarker(todo))
+
+	if todo.DueDate.Valid {
+		line.WriteString(todo.DueDate.Time.Format("2006-01-02") + "  ")
+	}
+
+	line.WriteString(todo.Content)
+	line.WriteString(subtaskProgress(todo))
+
+	if todo.Repeat != "" {
+		line.WriteString(" (repeats " + todo.Repeat + ")")
+	}
+
+	line.WriteString(blockedMarker(todo))
+
+	for _, tag := range todo.Tags {
+		line.WriteString(" #" + tag)
+	}
+
+	return line.String()
+}
@@ -0,0 +1,50 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func FormatIntegrityReport(checks []models.IntegrityCheck) string {
+	var output strings.Builder
+
+	problems := 0
+	for _, check := range checks {
+		problems += len(check.Problems)
+
+		switch {
+		case len(check.Problems) == 0:
+			output.WriteString(fmt.Sprintf("[ok]    %s\n", check.Name))
+		case check.Repaired:
+			output.WriteString(fmt.Sprintf("[fixed] %s (%d)\n", check.Name, len(check.Problems)))
+		default:
+			output.WriteString(fmt.Sprintf("[fail]  %s (%d)\n", check.Name, len(check.Problems)))
+		}
+
+		for _, problem := range check.Problems {
+			output.WriteString("          " + problem + "\n")
+		}
+	}
+
+	output.WriteString("\n")
+	if problems == 0 {
+		output.WriteString("No problems found.")
+	} else if anyRepaired(checks) {
+		output.WriteString(fmt.Sprintf("Repaired %d problem(s).", problems))
+	} else {
+		output.WriteString(fmt.Sprintf("Found %d problem(s). Run 'note doctor --fix' to repair them.", problems))
+	}
+
+	return output.String()
+}
+
+func anyRepaired(checks []models.IntegrityCheck) bool {
+	for _, check := range checks {
+		if check.Repaired {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+const brokenLabel = "(broken: missing or in the trash)"
+
+// FormatLinks lists the links written in a note or todo and the items that
+// link to it, to follow FormatNote or FormatTodo. It is empty when there are
+// neither.
+func FormatLinks(outgoing, backlinks []models.Link) string {
+	var output strings.Builder
+
+	if len(outgoing) > 0 {
+		output.WriteString("Links:\n")
+		for _, link := range outgoing {
+			output.WriteString("  " + formatTarget(link) + "\n")
+		}
+	}
+
+	if len(backlinks) > 0 {
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString("Linked from:\n")
+		for _, link := range backlinks {
+			output.WriteString(fmt.Sprintf("  %s #%d  %s\n", link.SourceKind, link.SourceID, previewLine(link.SourceTitle, 60)))
+		}
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func FormatLinkList(links []models.Link) string {
+	var output strings.Builder
+
+	for _, link := range links {
+		output.WriteString(fmt.Sprintf("%s #%d -> %s\n", link.SourceKind, link.SourceID, formatTarget(link)))
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func formatTarget(link models.Link) string {
+	if link.TargetKind == "project" {
+		if link.Broken {
+			return "project " + link.TargetRef + "  " + color.RedString(brokenLabel)
+		}
+		return "project " + link.TargetRef
+	}
+
+	target := fmt.Sprintf("%s #%s", link.TargetKind, link.TargetRef)
+	if link.Broken {
+		return target + "  " + color.RedString(brokenLabel)
+	}
+	return target + "  " + previewLine(link.TargetTitle, 60)
+}
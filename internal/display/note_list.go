@@ -65,6 +65,10 @@ func FormatNote(note *models.Note) string {
 		output.WriteString("Important: Yes\n")
 	}
 
+	if note.TodoID != 0 {
+		output.WriteString(fmt.Sprintf("Todo: #%d\n", note.TodoID))
+	}
+
 	if len(note.Tags) > 0 {
 		output.WriteString("Tags: ")
 		for i, tag := range note.Tags {
@@ -79,6 +83,11 @@ func FormatNote(note *models.Note) string {
 	output.WriteString("\n")
 	output.WriteString(note.Content)
 
+	if len(note.Attachments) > 0 {
+		output.WriteString("\n\n")
+		output.WriteString(formatAttachments(note.Attachments))
+	}
+
 	return output.String()
 }
 
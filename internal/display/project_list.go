@@ -144,12 +144,17 @@ func FormatProjectStatus(db *sql.DB, project *models.Project, showAll bool) (str
 
 	if len(incompleteTodos) > 0 {
 		output.WriteString("\nIncomplete Tasks:\n")
-		for _, todo := range incompleteTodos {
-			output.WriteString(fmt.Sprintf("  [ ] [#%d] ", todo.ID))
+		roots, subtasks := splitSubtasks(incompleteTodos)
+		for _, nested := range nestTodos(roots, subtasks) {
+			todo := nested.todo
+			output.WriteString(fmt.Sprintf("  %s[ ] [#%d] ", strings.Repeat("  ", nested.depth), todo.ID))
+			output.WriteString(priorityMarker(todo))
 			if todo.DueDate.Valid {
 				output.WriteString(fmt.Sprintf("%s  ", todo.DueDate.Time.Format("2006-01-02")))
 			}
 			output.WriteString(todo.Content)
+			output.WriteString(subtaskProgress(todo))
+			output.WriteString(blockedMarker(todo))
 			if len(todo.Tags) > 0 {
 				output.WriteString(" ")
 				for _, tag := range todo.Tags {
@@ -162,12 +167,16 @@ func FormatProjectStatus(db *sql.DB, project *models.Project, showAll bool) (str
 
 	if showAll && len(completeTodos) > 0 {
 		output.WriteString("\nCompleted Tasks:\n")
-		for _, todo := range completeTodos {
-			output.WriteString(fmt.Sprintf("  [X] [#%d] ", todo.ID))
+		roots, subtasks := splitSubtasks(completeTodos)
+		for _, nested := range nestTodos(roots, subtasks) {
+			todo := nested.todo
+			output.WriteString(fmt.Sprintf("  %s[X] [#%d] ", strings.Repeat("  ", nested.depth), todo.ID))
+			output.WriteString(priorityMarker(todo))
 			if todo.DueDate.Valid {
 				output.WriteString(fmt.Sprintf("(was due: %s) ", todo.DueDate.Time.Format("2006-01-02")))
 			}
 			output.WriteString(todo.Content)
+			output.WriteString(subtaskProgress(todo))
 			if len(todo.Tags) > 0 {
 				output.WriteString(" ")
 				for _, tag := range todo.Tags {
@@ -0,0 +1,169 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nathan-nicholson/note/internal/diff"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func FormatRevisionList(revisions []models.Revision) string {
+	if len(revisions) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+
+		output.WriteString(fmt.Sprintf("[r%d] %s  ", revision.Revision, revision.CreatedAt.Format("2006-01-02 03:04 PM")))
+
+		if i == 0 {
+			output.WriteString("original")
+		} else {
+			output.WriteString(strings.Join(revisionChanges(&revisions[i-1], &revision), ", "))
+		}
+
+		if i == len(revisions)-1 {
+			output.WriteString(" (current)")
+		}
+
+		output.WriteString("\n")
+		output.WriteString("  " + previewLine(revision.Content, 70) + "\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func FormatRevisionDiff(from, to *models.Revision) string {
+	label := func(r *models.Revision) string {
+		return fmt.Sprintf("%s #%d r%d (%s)", r.EntityKind, r.EntityID, r.Revision, r.CreatedAt.Format("2006-01-02 03:04 PM"))
+	}
+
+	var output strings.Builder
+
+	unified := diff.Unified(label(from), label(to), from.Content, to.Content, 3)
+	for _, line := range strings.Split(strings.TrimSuffix(unified, "\n"), "\n") {
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
+			output.WriteString(color.New(color.Bold).Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			output.WriteString(color.CyanString(line))
+		case strings.HasPrefix(line, "-"):
+			output.WriteString(color.RedString(line))
+		case strings.HasPrefix(line, "+"):
+			output.WriteString(color.GreenString(line))
+		default:
+			output.WriteString(line)
+		}
+		output.WriteString("\n")
+	}
+
+	removed, added := tagChanges(from.Tags, to.Tags)
+	if len(removed) > 0 || len(added) > 0 {
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString("Tags:")
+		for _, tag := range removed {
+			output.WriteString(" " + color.RedString("-#"+tag))
+		}
+		for _, tag := range added {
+			output.WriteString(" " + color.GreenString("+#"+tag))
+		}
+		output.WriteString("\n")
+	}
+
+	if from.IsImportant != to.IsImportant {
+		output.WriteString(fmt.Sprintf("Important: %s -> %s\n", yesNo(from.IsImportant), yesNo(to.IsImportant)))
+	}
+
+	if fromDue, toDue := formatDueDate(from), formatDueDate(to); fromDue != toDue {
+		output.WriteString(fmt.Sprintf("Due: %s -> %s\n", fromDue, toDue))
+	}
+
+	if output.Len() == 0 {
+		return fmt.Sprintf("No differences between r%d and r%d", from.Revision, to.Revision)
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func revisionChanges(from, to *models.Revision) []string {
+	var changes []string
+
+	if from.Content != to.Content {
+		changes = append(changes, "content")
+	}
+
+	if removed, added := tagChanges(from.Tags, to.Tags); len(removed) > 0 || len(added) > 0 {
+		changes = append(changes, "tags")
+	}
+
+	if from.IsImportant != to.IsImportant {
+		changes = append(changes, "importance")
+	}
+
+	if formatDueDate(from) != formatDueDate(to) {
+		changes = append(changes, "due date")
+	}
+
+	if len(changes) == 0 {
+		changes = append(changes, "no changes")
+	}
+
+	return changes
+}
+
+func tagChanges(from, to []string) (removed, added []string) {
+	inFrom := make(map[string]bool)
+	for _, tag := range from {
+		inFrom[tag] = true
+	}
+
+	inTo := make(map[string]bool)
+	for _, tag := range to {
+		inTo[tag] = true
+		if !inFrom[tag] {
+			added = append(added, tag)
+		}
+	}
+
+	for _, tag := range from {
+		if !inTo[tag] {
+			removed = append(removed, tag)
+		}
+	}
+
+	return removed, added
+}
+
+func formatDueDate(r *models.Revision) string {
+	if !r.DueDate.Valid {
+		return "none"
+	}
+	return r.DueDate.Time.Format("2006-01-02")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func previewLine(content string, width int) string {
+	line := strings.SplitN(content, "\n", 2)[0]
+	if len([]rune(line)) > width {
+		line = string([]rune(line)[:width-3]) + "..."
+	}
+	if strings.Contains(content, "\n") && !strings.HasSuffix(line, "...") {
+		line += " ..."
+	}
+	return line
+}
@@ -0,0 +1,84 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+)
+
+func FormatSearchResults(results []models.SearchResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+
+	for i, result := range results {
+		if i > 0 {
+			output.WriteString("\n")
+		}
+
+		output.WriteString(fmt.Sprintf("[%s #%d] %s", result.Kind, result.ID, result.CreatedAt.Format("2006-01-02 03:04 PM")))
+
+		if result.IsImportant {
+			output.WriteString("  [!]")
+		}
+
+		if result.Kind == "todo" {
+			if result.IsComplete {
+				output.WriteString("  [X]")
+			} else {
+				output.WriteString("  [ ]")
+			}
+		}
+
+		output.WriteString("\n  ")
+		output.WriteString(highlightSnippet(result.Snippet))
+
+		if len(result.Tags) > 0 {
+			output.WriteString(" ")
+			for _, tag := range result.Tags {
+				output.WriteString("#" + tag + " ")
+			}
+		}
+
+		output.WriteString("\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
+
+func highlightSnippet(snippet string) string {
+	snippet = strings.ReplaceAll(snippet, "\n", " ")
+
+	if color.NoColor {
+		snippet = strings.ReplaceAll(snippet, repository.SnippetStart, "[")
+		return strings.ReplaceAll(snippet, repository.SnippetEnd, "]")
+	}
+
+	highlight := color.New(color.FgYellow, color.Bold)
+
+	var output strings.Builder
+	for {
+		start := strings.Index(snippet, repository.SnippetStart)
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(snippet[start:], repository.SnippetEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+
+		output.WriteString(snippet[:start])
+		output.WriteString(highlight.Sprint(snippet[start+len(repository.SnippetStart) : end]))
+		snippet = snippet[end+len(repository.SnippetEnd):]
+	}
+	output.WriteString(snippet)
+
+	return output.String()
+}
@@ -0,0 +1,31 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func FormatTemplateList(templates []models.Template) string {
+	var output strings.Builder
+
+	for _, t := range templates {
+		output.WriteString(t.Name)
+
+		if t.IsImportant {
+			output.WriteString(" [!]")
+		}
+		for _, tag := range t.Tags {
+			output.WriteString(" #" + tag)
+		}
+		if t.Path != "" {
+			output.WriteString(fmt.Sprintf("  (%s)", t.Path))
+		}
+		output.WriteString("\n")
+
+		output.WriteString("  " + previewLine(t.Content, 60) + "\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
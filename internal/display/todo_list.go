@@ -2,9 +2,11 @@ package display
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/nathan-nicholson/note/internal/models"
 )
 
@@ -37,6 +39,12 @@ func GroupTodos(todos []models.Todo) []TodoGroup {
 		}
 	}
 
+	for _, group := range [][]models.Todo{overdue, todayTodos, upcoming, noDueDate} {
+		slices.SortStableFunc(group, func(a, b models.Todo) int {
+			return priorityRank(a.Priority) - priorityRank(b.Priority)
+		})
+	}
+
 	var groups []TodoGroup
 
 	if len(overdue) > 0 {
@@ -66,7 +74,9 @@ func FormatTodoList(todos []models.Todo) string {
 		return ""
 	}
 
-	groups := GroupTodos(todos)
+	// Subtasks are listed under their parent rather than grouped by their own
+	// due dates.
+	groups, subtasks := groupRoots(todos)
 
 	var output strings.Builder
 
@@ -77,8 +87,9 @@ func FormatTodoList(todos []models.Todo) string {
 
 		output.WriteString(group.Title + "\n")
 
-		for _, todo := range group.Todos {
-			output.WriteString("  ")
+		for _, nested := range nestTodos(group.Todos, subtasks) {
+			todo := nested.todo
+			output.WriteString("  " + strings.Repeat("  ", nested.depth))
 
 			if todo.IsComplete {
 				output.WriteString("[X]")
@@ -87,8 +98,11 @@ func FormatTodoList(todos []models.Todo) string {
 			}
 
 			output.WriteString(fmt.Sprintf(" [#%d] ", todo.ID))
+			output.WriteString(priorityMarker(todo))
 
-			if todo.DueDate.Valid && group.Title != "NO DUE DATE" {
+			if todo.DueDate.Valid && nested.depth > 0 {
+				output.WriteString(todo.DueDate.Time.Format("2006-01-02") + "  ")
+			} else if todo.DueDate.Valid && group.Title != "NO DUE DATE" {
 				dueDate := todo.DueDate.Time.Format("2006-01-02")
 				if group.Title == "UPCOMING" {
 					output.WriteString(dueDate + "  ")
@@ -100,6 +114,13 @@ func FormatTodoList(todos []models.Todo) string {
 			}
 
 			output.WriteString(todo.Content)
+			output.WriteString(subtaskProgress(todo))
+
+			if todo.Repeat != "" {
+				output.WriteString(" (repeats " + todo.Repeat + ")")
+			}
+
+			output.WriteString(blockedMarker(todo))
 
 			if len(todo.Tags) > 0 {
 				output.WriteString(" ")
@@ -115,6 +136,165 @@ func FormatTodoList(todos []models.Todo) string {
 	return strings.TrimSpace(output.String())
 }
 
+// FormatFlatTodoList lists todos one per line in the order given, for orders
+// that grouping by due date would undo.
+func FormatFlatTodoList(todos []models.Todo) string {
+	var output strings.Builder
+
+	for _, todo := range todos {
+		output.WriteString("  " + todoLine(todo) + "\n")
+	}
+
+	return strings.TrimRight(output.String(), "\n")
+}
+
+type nestedTodo struct {
+	todo  models.Todo
+	depth int
+}
+
+// splitSubtasks separates the subtasks whose parent is among todos from the
+// rest, mapping each parent's ID to its subtasks.
+func splitSubtasks(todos []models.Todo) ([]models.Todo, map[int][]models.Todo) {
+	present := make(map[int]bool, len(todos))
+	for _, todo := range todos {
+		present[todo.ID] = true
+	}
+
+	var roots []models.Todo
+	subtasks := make(map[int][]models.Todo)
+	for _, todo := range todos {
+		if todo.ParentID != 0 && present[todo.ParentID] {
+			subtasks[todo.ParentID] = append(subtasks[todo.ParentID], todo)
+		} else {
+			roots = append(roots, todo)
+		}
+	}
+
+	return roots, subtasks
+}
+
+// groupRoots groups the todos that are not subtasks of another in the list.
+// GroupTodos leaves some todos out, such as completed ones that were due
+// before today, and the subtasks of a parent left out are grouped on their own
+// instead, so an open subtask is never hidden with its parent.
+func groupRoots(todos []models.Todo) ([]TodoGroup, map[int][]models.Todo) {
+	roots, subtasks := splitSubtasks(todos)
+
+	for {
+		groups := GroupTodos(roots)
+
+		shown := make(map[int]bool)
+		for _, group := range groups {
+			for _, todo := range group.Todos {
+				shown[todo.ID] = true
+			}
+		}
+
+		promoted := false
+		isRoot := make(map[int]bool)
+		for _, root := range roots {
+			isRoot[root.ID] = true
+			if shown[root.ID] {
+				continue
+			}
+			for _, subtask := range subtasks[root.ID] {
+				isRoot[subtask.ID] = true
+				promoted = true
+			}
+			delete(subtasks, root.ID)
+		}
+
+		if !promoted {
+			return groups, subtasks
+		}
+
+		// Keep the order of the list, which is sorted by due date.
+		roots = nil
+		for _, todo := range todos {
+			if isRoot[todo.ID] {
+				roots = append(roots, todo)
+			}
+		}
+	}
+}
+
+// nestTodos follows each todo with its subtasks, one level deeper.
+func nestTodos(todos []models.Todo, subtasks map[int][]models.Todo) []nestedTodo {
+	var nested []nestedTodo
+
+	var add func(todo models.Todo, depth int)
+	add = func(todo models.Todo, depth int) {
+		nested = append(nested, nestedTodo{todo: todo, depth: depth})
+		for _, subtask := range subtasks[todo.ID] {
+			add(subtask, depth+1)
+		}
+	}
+	for _, todo := range todos {
+		add(todo, 0)
+	}
+
+	return nested
+}
+
+var priorityColors = map[string]*color.Color{
+	"P0": color.New(color.FgRed, color.Bold),
+	"P1": color.New(color.FgRed),
+	"P2": color.New(color.FgYellow),
+	"P3": color.New(color.FgCyan),
+}
+
+// priorityMarker shows a todo's priority in its color, followed by a space.
+func priorityMarker(todo models.Todo) string {
+	if todo.Priority == "" {
+		return ""
+	}
+	return priorityLabel(todo.Priority) + " "
+}
+
+// priorityLabel colors a priority. A value other than P0 to P3, which only a
+// hand-edited database can hold, is shown as it is.
+func priorityLabel(priority string) string {
+	c, ok := priorityColors[priority]
+	if !ok {
+		return priority
+	}
+	return c.Sprint(priority)
+}
+
+// priorityRank orders P0 first and todos without a priority, or with one
+// that is not P0 to P3, last.
+func priorityRank(priority string) int {
+	if _, ok := priorityColors[priority]; !ok {
+		return 4
+	}
+	return int(priority[1] - '0')
+}
+
+// blockedMarker shows the open todos a todo waits on, as " (blocked by #3)".
+func blockedMarker(todo models.Todo) string {
+	if len(todo.BlockedBy) == 0 {
+		return ""
+	}
+	return " " + color.YellowString("(blocked by %s)", todoRefs(todo.BlockedBy))
+}
+
+func todoRefs(ids []int) string {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = fmt.Sprintf("#%d", id)
+	}
+	return strings.Join(refs, ", ")
+}
+
+// subtaskProgress shows how many of a todo's subtasks are done, as " (2/5)".
+func subtaskProgress(todo models.Todo) string {
+	if todo.Subtasks == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d/%d)", todo.SubtasksDone, todo.Subtasks)
+}
+
 func FormatTodo(todo *models.Todo) string {
 	var output strings.Builder
 
@@ -125,12 +305,40 @@ func FormatTodo(todo *models.Todo) string {
 		output.WriteString(fmt.Sprintf("Due: %s\n", todo.DueDate.Time.Format("2006-01-02")))
 	}
 
+	if todo.Repeat != "" {
+		output.WriteString(fmt.Sprintf("Repeats: %s\n", todo.Repeat))
+	}
+
+	if len(todo.BlockedBy) > 0 {
+		output.WriteString(fmt.Sprintf("Blocked by: %s\n", todoRefs(todo.BlockedBy)))
+	}
+
+	if todo.Priority != "" {
+		if name := models.PriorityName(todo.Priority); name != "" {
+			output.WriteString(fmt.Sprintf("Priority: %s (%s)\n", priorityLabel(todo.Priority), name))
+		} else {
+			output.WriteString(fmt.Sprintf("Priority: %s\n", priorityLabel(todo.Priority)))
+		}
+	}
+
 	output.WriteString(fmt.Sprintf("Status: %s\n", map[bool]string{true: "Complete", false: "Incomplete"}[todo.IsComplete]))
 
 	if todo.CompletedAt.Valid {
 		output.WriteString(fmt.Sprintf("Completed: %s\n", todo.CompletedAt.Time.Format("2006-01-02 03:04 PM")))
 	}
 
+	if todo.ParentID != 0 {
+		output.WriteString(fmt.Sprintf("Subtask of: todo #%d\n", todo.ParentID))
+	}
+
+	if todo.Subtasks > 0 {
+		output.WriteString(fmt.Sprintf("Subtasks: %d/%d complete\n", todo.SubtasksDone, todo.Subtasks))
+	}
+
+	if todo.PromotedFrom != 0 {
+		output.WriteString(fmt.Sprintf("Promoted from: note #%d\n", todo.PromotedFrom))
+	}
+
 	if len(todo.Tags) > 0 {
 		output.WriteString("Tags: ")
 		for i, tag := range todo.Tags {
@@ -145,5 +353,28 @@ func FormatTodo(todo *models.Todo) string {
 	output.WriteString("\n")
 	output.WriteString(todo.Content)
 
+	if len(todo.Attachments) > 0 {
+		output.WriteString("\n\n")
+		output.WriteString(formatAttachments(todo.Attachments))
+	}
+
 	return output.String()
 }
+
+// FormatComments lists the notes added to a todo, to follow FormatTodo.
+func FormatComments(comments []models.Note) string {
+	if len(comments) == 0 {
+		return ""
+	}
+
+	var output strings.Builder
+
+	output.WriteString("Notes:\n")
+	for _, comment := range comments {
+		output.WriteString(fmt.Sprintf("  %s  [#%d] ", comment.CreatedAt.Format("2006-01-02 03:04 PM"), comment.ID))
+		output.WriteString(strings.ReplaceAll(comment.Content, "\n", "\n    "))
+		output.WriteString("\n")
+	}
+
+	return strings.TrimSpace(output.String())
+}
@@ -0,0 +1,30 @@
+package display
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func TestFormatTodoList_SubtaskOfHiddenParent(t *testing.T) {
+	lastWeek := sql.NullTime{Time: time.Now().AddDate(0, 0, -7), Valid: true}
+
+	todos := []models.Todo{
+		{ID: 1, Content: "Plan the offsite", IsComplete: true, DueDate: lastWeek, Subtasks: 1},
+		{ID: 2, Content: "Book the venue", ParentID: 1},
+	}
+
+	output := FormatTodoList(todos)
+
+	// A completed todo that was due before today is left out of the list,
+	// but its open subtask is still listed.
+	if strings.Contains(output, "Plan the offsite") {
+		t.Errorf("FormatTodoList() = %q, want the completed overdue parent left out", output)
+	}
+	if !strings.Contains(output, "NO DUE DATE\n  [ ] [#2] Book the venue") {
+		t.Errorf("FormatTodoList() = %q, want the open subtask listed on its own", output)
+	}
+}
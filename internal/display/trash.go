@@ -0,0 +1,53 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func FormatTrashList(items []models.TrashItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	groups := []struct {
+		kind  string
+		title string
+	}{
+		{kind: "note", title: "NOTES"},
+		{kind: "todo", title: "TODOS"},
+		{kind: "project", title: "PROJECTS"},
+	}
+
+	var output strings.Builder
+
+	for _, group := range groups {
+		var groupItems []models.TrashItem
+		for _, item := range items {
+			if item.Kind == group.kind {
+				groupItems = append(groupItems, item)
+			}
+		}
+
+		if len(groupItems) == 0 {
+			continue
+		}
+
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+
+		output.WriteString(group.title + "\n")
+		for _, item := range groupItems {
+			output.WriteString(fmt.Sprintf("  [#%d] ", item.ID))
+			output.WriteString(item.DeletedAt.Format("2006-01-02 03:04 PM"))
+			output.WriteString("  ")
+			output.WriteString(item.Title)
+			output.WriteString("\n")
+		}
+	}
+
+	return strings.TrimSpace(output.String())
+}
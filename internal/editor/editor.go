@@ -0,0 +1,133 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Documents opened in the editor start with a short header of "name: value"
+// fields, such as tags, followed by a line holding only the separator and
+// then the content itself.
+
+const Separator = "---"
+
+// Field is one line of the header.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Command returns the user's editor and any arguments it was configured
+// with, preferring $VISUAL over $EDITOR.
+func Command() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// Compose lays out the header fields and the content as they appear in the
+// editor.
+func Compose(fields []Field, content string) string {
+	var b strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", field.Name, field.Value)
+	}
+	b.WriteString(Separator + "\n")
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Parse splits edited text back into header fields and content. Only the
+// named fields are accepted; fields that were deleted from the header are
+// missing from the result. Surrounding blank lines are trimmed from the
+// content.
+func Parse(text string, names []string) (map[string]string, string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	separator := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == Separator {
+			separator = i
+			break
+		}
+	}
+	if separator < 0 {
+		return nil, "", fmt.Errorf("missing '%s' line between the header and the content", Separator)
+	}
+
+	fields := map[string]string{}
+	for _, line := range lines[:separator] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !slices.Contains(names, name) {
+			return nil, "", fmt.Errorf("unknown header line %q (expected %s)", line, strings.Join(names, ", "))
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+
+	content := strings.Trim(strings.Join(lines[separator+1:], "\n"), "\n")
+	return fields, content, nil
+}
+
+// Edit opens text in the user's editor and returns what was saved.
+func Edit(text string) (string, error) {
+	file, err := os.CreateTemp("", "note-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	command := Command()
+	cmd := exec.Command(command[0], append(command[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor '%s' failed: %w", strings.Join(command, " "), err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(edited), nil
+}
+
+// SplitTags parses the tags header. Tags may be separated by commas or
+// spaces and written with or without a leading #.
+func SplitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if tag = strings.TrimPrefix(tag, "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
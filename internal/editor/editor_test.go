@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestComposeParseRoundTrip(t *testing.T) {
+	fields := []Field{{Name: "tags", Value: "work, meeting"}, {Name: "important", Value: "true"}}
+	content := "Agenda\n\n- budget\n- hiring"
+
+	parsed, got, err := Parse(Compose(fields, content), []string{"tags", "important"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if got != content {
+		t.Errorf("content = %q, want %q", got, content)
+	}
+
+	want := map[string]string{"tags": "work, meeting", "important": "true"}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("fields = %v, want %v", parsed, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantFields  map[string]string
+		wantContent string
+		wantErr     bool
+	}{
+		{
+			name:        "header field removed",
+			text:        "tags: work\n---\nBody\n",
+			wantFields:  map[string]string{"tags": "work"},
+			wantContent: "Body",
+		},
+		{
+			name:        "separator inside content is kept",
+			text:        "tags:\n---\nabove\n---\nbelow\n",
+			wantFields:  map[string]string{"tags": ""},
+			wantContent: "above\n---\nbelow",
+		},
+		{
+			name:        "windows line endings",
+			text:        "Tags: work\r\n---\r\nBody\r\n",
+			wantFields:  map[string]string{"tags": "work"},
+			wantContent: "Body",
+		},
+		{
+			name:    "missing separator",
+			text:    "just some text\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			text:    "priority: high\n---\nBody\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, content, err := Parse(tt.text, []string{"tags", "important"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+			if content != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	got := SplitTags(" #work, meeting  #q3,,")
+	want := []string{"work", "meeting", "q3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitTags() = %v, want %v", got, want)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	if got := Command(); !reflect.DeepEqual(got, []string{"code", "--wait"}) {
+		t.Errorf("Command() = %v, want VISUAL to win", got)
+	}
+
+	t.Setenv("VISUAL", "")
+	if got := Command(); !reflect.DeepEqual(got, []string{"nano"}) {
+		t.Errorf("Command() = %v, want [nano]", got)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "fake-editor")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'added line' >> \"$1\"\n"), 0755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("VISUAL", script)
+
+	got, err := Edit("first line\n")
+	if err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+
+	if want := "first line\nadded line\n"; got != want {
+		t.Errorf("Edit() = %q, want %q", got, want)
+	}
+}
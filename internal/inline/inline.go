@@ -0,0 +1,90 @@
+package inline
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nathan-nicholson/note/internal/dateparse"
+)
+
+// Metadata can be typed straight into captured content. A #tag anywhere tags
+// the item and stays in the text. A run of #tags, a lone ! (important) and an
+// @date (due date, for todos) at the very end is taken out of the text:
+//
+//	Deploy went fine #release #ops !
+//	Call vendor @tomorrow #finance
+
+// Item is captured content with its inline metadata parsed out.
+type Item struct {
+	Content   string
+	Tags      []string
+	Important bool
+	Due       *time.Time
+}
+
+var (
+	tagRegex      = regexp.MustCompile(`(?:^|[\s(])#([A-Za-z][A-Za-z0-9_-]*)`)
+	tagTokenRegex = regexp.MustCompile(`^#([A-Za-z][A-Za-z0-9_-]*)$`)
+)
+
+// Parse reads the metadata in content. An @date is only recognized when dates
+// is set; otherwise it is left in the text. At least one word of content is
+// always kept, so a note of just "#standup" keeps its text.
+func Parse(content string, dates bool) Item {
+	item := Item{Content: strings.TrimSpace(content)}
+
+	var trailing []string
+	for {
+		i := strings.LastIndexFunc(item.Content, unicode.IsSpace)
+		if i < 0 || !item.take(item.Content[i+1:], dates, &trailing) {
+			break
+		}
+		item.Content = strings.TrimRightFunc(item.Content[:i], unicode.IsSpace)
+	}
+
+	for _, match := range tagRegex.FindAllStringSubmatch(item.Content, -1) {
+		item.Tags = appendTag(item.Tags, match[1])
+	}
+	for _, tag := range slices.Backward(trailing) {
+		item.Tags = appendTag(item.Tags, tag)
+	}
+
+	return item
+}
+
+// take records token if it is trailing metadata. Tokens are read from the end
+// of the content backwards, so the last due date written wins.
+func (item *Item) take(token string, dates bool, tags *[]string) bool {
+	if token == "!" {
+		item.Important = true
+		return true
+	}
+
+	if match := tagTokenRegex.FindStringSubmatch(token); match != nil {
+		*tags = append(*tags, match[1])
+		return true
+	}
+
+	if dates && len(token) > 1 && token[0] == '@' {
+		due, err := dateparse.ParseDate(token[1:])
+		if err != nil {
+			return false
+		}
+		if item.Due == nil {
+			item.Due = &due
+		}
+		return true
+	}
+
+	return false
+}
+
+func appendTag(tags []string, tag string) []string {
+	if slices.Contains(tags, tag) {
+		return tags
+	}
+	return append(tags, tag)
+}
@@ -0,0 +1,110 @@
+package inline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/dateparse"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		dates     bool
+		want      string
+		tags      []string
+		important bool
+	}{
+		{
+			name:      "trailing tags and importance",
+			content:   "Deploy went fine #release #ops !",
+			want:      "Deploy went fine",
+			tags:      []string{"release", "ops"},
+			important: true,
+		},
+		{
+			name:    "tags in the text stay",
+			content: "Fixed the #parser crash (#urgent) in [[#42]] #bugs",
+			want:    "Fixed the #parser crash (#urgent) in [[#42]]",
+			tags:    []string{"parser", "urgent", "bugs"},
+		},
+		{
+			name:    "not tags",
+			content: "C# and #42 and http://x.io/#top and # heading",
+			want:    "C# and #42 and http://x.io/#top and # heading",
+		},
+		{
+			name:    "punctuation after a tag in the text",
+			content: "That's #done.",
+			want:    "That's #done.",
+			tags:    []string{"done"},
+		},
+		{
+			name:    "duplicates collapse",
+			content: "Read #go notes #go",
+			want:    "Read #go notes",
+			tags:    []string{"go"},
+		},
+		{
+			name:    "one word is kept",
+			content: "#standup",
+			want:    "#standup",
+			tags:    []string{"standup"},
+		},
+		{
+			name:    "only the end of multi-line content",
+			content: "Line one !\nLine two #ops\n",
+			want:    "Line one !\nLine two",
+			tags:    []string{"ops"},
+		},
+		{
+			name:    "dates ignored for notes",
+			content: "Lunch @tomorrow #food",
+			want:    "Lunch @tomorrow",
+			tags:    []string{"food"},
+		},
+		{
+			name:    "not a date",
+			content: "Ping @alice #team",
+			dates:   true,
+			want:    "Ping @alice",
+			tags:    []string{"team"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.content, tt.dates)
+			if got.Content != tt.want {
+				t.Errorf("Content = %q, want %q", got.Content, tt.want)
+			}
+			if !reflect.DeepEqual(got.Tags, tt.tags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, tt.tags)
+			}
+			if got.Important != tt.important {
+				t.Errorf("Important = %v, want %v", got.Important, tt.important)
+			}
+			if got.Due != nil {
+				t.Errorf("Due = %v, want none", got.Due)
+			}
+		})
+	}
+}
+
+func TestParse_Due(t *testing.T) {
+	got := Parse("Call vendor @friday @tomorrow #finance", true)
+
+	if got.Content != "Call vendor" {
+		t.Errorf("Content = %q, want %q", got.Content, "Call vendor")
+	}
+	if !reflect.DeepEqual(got.Tags, []string{"finance"}) {
+		t.Errorf("Tags = %v, want [finance]", got.Tags)
+	}
+
+	want, _ := dateparse.ParseDate("tomorrow")
+	if got.Due == nil || !got.Due.Equal(want) {
+		t.Errorf("Due = %v, want %v", got.Due, want.Format(time.DateOnly))
+	}
+}
@@ -0,0 +1,378 @@
+package journal
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/repository"
+)
+
+// The journal records every mutating command as an operation holding before
+// and after snapshots of each row it touched. Undo puts the before snapshots
+// back and redo re-applies the after snapshots, so any command can be reversed
+// without knowing how it was implemented.
+
+const maxOperations = 100
+
+var createdKinds = []struct {
+	kind  string
+	table string
+}{
+	{kind: "note", table: "notes"},
+	{kind: "todo", table: "todos"},
+	{kind: "project", table: "projects"},
+	{kind: "activity", table: "activity"},
+}
+
+type change struct {
+	kind   string
+	id     int
+	before []byte
+	after  []byte
+}
+
+type Recorder struct {
+	db      database.DBTX
+	command string
+	maxIDs  map[string]int
+	changes []change
+}
+
+// Begin starts recording a command. Rows inserted into notes, todos, projects
+// or the activity log before Commit are picked up automatically; rows that
+// already exist must be passed to Track before they are modified.
+func Begin(db database.DBTX, command string) (*Recorder, error) {
+	r := &Recorder{db: db, command: command, maxIDs: make(map[string]int)}
+
+	for _, c := range createdKinds {
+		var maxID int
+		if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM " + c.table).Scan(&maxID); err != nil {
+			return nil, err
+		}
+		r.maxIDs[c.kind] = maxID
+	}
+
+	return r, nil
+}
+
+// Run executes a mutating command in a single transaction and journals it, so
+// the command's writes, the activity it logs and its journal entry are
+// committed together or not at all.
+func Run(db database.DBTX, command string, fn func(tx database.DBTX, op *Recorder) error) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		op, err := Begin(tx, command)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(tx, op); err != nil {
+			return err
+		}
+
+		return op.Commit()
+	})
+}
+
+// RunIrreversible executes a command that cannot be reversed, such as
+// emptying the trash, in a single transaction. The journal recorded so far is
+// cleared, since its snapshots hold what the command removed for good, and the
+// command is recorded without any changes, which Undo refuses to pass.
+func RunIrreversible(db database.DBTX, command string, fn func(tx database.DBTX) error) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM operation_changes"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM operations"); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("INSERT INTO operations (command, created_at) VALUES (?, ?)", command, time.Now())
+		return err
+	})
+}
+
+func (r *Recorder) Track(kind string, id int) error {
+	if r.tracked(kind, id) {
+		return nil
+	}
+
+	before, err := repository.Snapshot(r.db, kind, id)
+	if err != nil {
+		return err
+	}
+
+	r.changes = append(r.changes, change{kind: kind, id: id, before: before})
+	return nil
+}
+
+func (r *Recorder) Commit() error {
+	for _, c := range createdKinds {
+		rows, err := r.db.Query("SELECT id FROM "+c.table+" WHERE id > ? ORDER BY id", r.maxIDs[c.kind])
+		if err != nil {
+			return err
+		}
+
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if !r.tracked(c.kind, id) {
+				r.changes = append(r.changes, change{kind: c.kind, id: id})
+			}
+		}
+	}
+
+	var changed []change
+	for _, c := range r.changes {
+		after, err := repository.Snapshot(r.db, c.kind, c.id)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(c.before, after) {
+			c.after = after
+			changed = append(changed, c)
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	return database.WithTx(r.db, func(tx database.DBTX) error {
+		// A new operation makes anything that was undone impossible to redo.
+		if _, err := tx.Exec("DELETE FROM operation_changes WHERE operation_id IN (SELECT id FROM operations WHERE undone_at IS NOT NULL)"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM operations WHERE undone_at IS NOT NULL"); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec("INSERT INTO operations (command, created_at) VALUES (?, ?)", r.command, time.Now())
+		if err != nil {
+			return err
+		}
+
+		operationID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for _, c := range changed {
+			_, err := tx.Exec(`
+				INSERT INTO operation_changes (operation_id, entity_kind, entity_id, before, after)
+				VALUES (?, ?, ?, ?, ?)
+			`, operationID, c.kind, c.id, nullableJSON(c.before), nullableJSON(c.after))
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec("DELETE FROM operation_changes WHERE operation_id <= ?", operationID-maxOperations); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM operations WHERE id <= ?", operationID-maxOperations); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *Recorder) tracked(kind string, id int) bool {
+	for _, c := range r.changes {
+		if c.kind == kind && c.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func Undo(db database.DBTX, n int) ([]models.Operation, error) {
+	var undone []models.Operation
+
+	for i := 0; i < n; i++ {
+		var op *models.Operation
+
+		err := database.WithTx(db, func(tx database.DBTX) error {
+			var err error
+			op, err = findOperation(tx, "undone_at IS NULL ORDER BY id DESC")
+			if err != nil || op == nil {
+				return err
+			}
+
+			changes, err := loadChanges(tx, op.ID)
+			if err != nil {
+				return err
+			}
+
+			// Only RunIrreversible records an operation without changes.
+			if len(changes) == 0 {
+				return fmt.Errorf("Cannot undo '%s': it cannot be reversed", op.Command)
+			}
+
+			if err := verify(tx, op, changes, func(c change) []byte { return c.after }); err != nil {
+				return err
+			}
+
+			for j := len(changes) - 1; j >= 0; j-- {
+				if err := repository.RestoreSnapshot(tx, changes[j].kind, changes[j].id, changes[j].before); err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.Exec("UPDATE operations SET undone_at = ? WHERE id = ?", time.Now(), op.ID)
+			return err
+		})
+		if err != nil {
+			return undone, err
+		}
+
+		if op == nil {
+			if len(undone) == 0 {
+				return nil, fmt.Errorf("Nothing to undo")
+			}
+			break
+		}
+
+		undone = append(undone, *op)
+	}
+
+	return undone, nil
+}
+
+func Redo(db database.DBTX, n int) ([]models.Operation, error) {
+	var redone []models.Operation
+
+	for i := 0; i < n; i++ {
+		var op *models.Operation
+
+		err := database.WithTx(db, func(tx database.DBTX) error {
+			// Undone operations always form the tail of the journal, so the
+			// oldest of them is the next one to redo.
+			var err error
+			op, err = findOperation(tx, "undone_at IS NOT NULL ORDER BY id ASC")
+			if err != nil || op == nil {
+				return err
+			}
+
+			changes, err := loadChanges(tx, op.ID)
+			if err != nil {
+				return err
+			}
+
+			if err := verify(tx, op, changes, func(c change) []byte { return c.before }); err != nil {
+				return err
+			}
+
+			for _, c := range changes {
+				if err := repository.RestoreSnapshot(tx, c.kind, c.id, c.after); err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.Exec("UPDATE operations SET undone_at = NULL WHERE id = ?", op.ID)
+			return err
+		})
+		if err != nil {
+			return redone, err
+		}
+
+		if op == nil {
+			if len(redone) == 0 {
+				return nil, fmt.Errorf("Nothing to redo")
+			}
+			break
+		}
+
+		redone = append(redone, *op)
+	}
+
+	return redone, nil
+}
+
+// verify refuses to apply an operation when a row it touched has changed
+// since, because restoring the snapshot would silently discard that change.
+func verify(db database.DBTX, op *models.Operation, changes []change, expected func(change) []byte) error {
+	for _, c := range changes {
+		current, err := repository.Snapshot(db, c.kind, c.id)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(current, expected(c)) {
+			return fmt.Errorf("Cannot reverse '%s': %s #%d has changed since", op.Command, c.kind, c.id)
+		}
+	}
+	return nil
+}
+
+func findOperation(db database.DBTX, clause string) (*models.Operation, error) {
+	var op models.Operation
+	err := db.QueryRow("SELECT id, command, created_at, undone_at FROM operations WHERE "+clause+" LIMIT 1").
+		Scan(&op.ID, &op.Command, &op.CreatedAt, &op.UndoneAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+func loadChanges(db database.DBTX, operationID int) ([]change, error) {
+	rows, err := db.Query(`
+		SELECT entity_kind, entity_id, before, after
+		FROM operation_changes
+		WHERE operation_id = ?
+		ORDER BY id
+	`, operationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []change
+	for rows.Next() {
+		var c change
+		var before, after sql.NullString
+		if err := rows.Scan(&c.kind, &c.id, &before, &after); err != nil {
+			return nil, err
+		}
+		if before.Valid {
+			c.before = []byte(before.String)
+		}
+		if after.Valid {
+			c.after = []byte(after.String)
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
+
+func nullableJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
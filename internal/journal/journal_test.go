@@ -0,0 +1,339 @@
+package journal
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/activity"
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/repository"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "notes.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow(query, args...).Scan(&count); err != nil {
+		t.Fatalf("Count query failed: %v", err)
+	}
+	return count
+}
+
+func TestUndoDeleteRestoresTodoAndRemovesActivity(t *testing.T) {
+	db := setupTestDB(t)
+
+	todo, err := repository.CreateTodo(db, "Write report", []string{"work"}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+
+	op, err := Begin(db, "todo delete")
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := op.Track("todo", todo.ID); err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+	if err := repository.DeleteTodo(db, todo.ID); err != nil {
+		t.Fatalf("DeleteTodo failed: %v", err)
+	}
+	if err := activity.LogTodoDeleted(db, todo); err != nil {
+		t.Fatalf("LogTodoDeleted failed: %v", err)
+	}
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	activityBefore := countRows(t, db, "SELECT COUNT(*) FROM activity")
+	if activityBefore != 1 {
+		t.Fatalf("Expected 1 activity entry, got %d", activityBefore)
+	}
+
+	undone, err := Undo(db, 1)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if len(undone) != 1 || undone[0].Command != "todo delete" {
+		t.Fatalf("Expected to undo 'todo delete', got %+v", undone)
+	}
+
+	restored, err := repository.GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("Todo should be visible after undo: %v", err)
+	}
+	if len(restored.Tags) != 1 || restored.Tags[0] != "work" {
+		t.Errorf("Expected tags [work], got %v", restored.Tags)
+	}
+
+	if count := countRows(t, db, "SELECT COUNT(*) FROM activity"); count != 0 {
+		t.Errorf("Expected activity entry to be removed, got %d entries", count)
+	}
+}
+
+func TestUndoReplacedTags(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := repository.CreateNote(db, "Standup", []string{"meeting", "team"}, false)
+	if err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+
+	op, err := Begin(db, "edit")
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := op.Track("note", note.ID); err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+	if err := repository.UpdateNote(db, note.ID, nil, []string{"oops"}, nil); err != nil {
+		t.Fatalf("UpdateNote failed: %v", err)
+	}
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if _, err := Undo(db, 1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	restored, err := repository.GetNoteByID(db, note.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID failed: %v", err)
+	}
+	if strings.Join(restored.Tags, ",") != "meeting,team" {
+		t.Errorf("Expected tags meeting,team after undo, got %v", restored.Tags)
+	}
+}
+
+func TestUndoDependency(t *testing.T) {
+	db := setupTestDB(t)
+
+	design, err := repository.CreateTodo(db, "Design API", []string{}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+	build, err := repository.CreateTodo(db, "Build client", []string{}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo failed: %v", err)
+	}
+
+	err = Run(db, "todo block", func(tx database.DBTX, op *Recorder) error {
+		if err := op.Track("todo", build.ID); err != nil {
+			return err
+		}
+		return repository.AddDependency(tx, build.ID, design.ID)
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if _, err := Undo(db, 1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if count := countRows(t, db, "SELECT COUNT(*) FROM todo_dependencies"); count != 0 {
+		t.Errorf("Expected the dependency to be removed, got %d", count)
+	}
+
+	if _, err := Redo(db, 1); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	restored, err := repository.GetTodoByID(db, build.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID failed: %v", err)
+	}
+	if len(restored.BlockedBy) != 1 || restored.BlockedBy[0] != design.ID {
+		t.Errorf("Expected todo to be blocked by #%d after redo, got %v", design.ID, restored.BlockedBy)
+	}
+}
+
+func TestRedoReappliesUndoneOperations(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, content := range []string{"first", "second"} {
+		op, err := Begin(db, "add")
+		if err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		if _, err := repository.CreateNote(db, content, nil, false); err != nil {
+			t.Fatalf("CreateNote failed: %v", err)
+		}
+		if err := op.Commit(); err != nil {
+			t.Fatalf("Commit failed: %v", err)
+		}
+	}
+
+	if _, err := Undo(db, 2); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if count := countRows(t, db, "SELECT COUNT(*) FROM notes"); count != 0 {
+		t.Fatalf("Expected no notes after undo, got %d", count)
+	}
+
+	redone, err := Redo(db, 1)
+	if err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if len(redone) != 1 {
+		t.Fatalf("Expected 1 redone operation, got %d", len(redone))
+	}
+
+	var content string
+	if err := db.QueryRow("SELECT content FROM notes").Scan(&content); err != nil {
+		t.Fatalf("Expected exactly one note after redo: %v", err)
+	}
+	if content != "first" {
+		t.Errorf("Expected the oldest undone operation to be redone first, got %q", content)
+	}
+
+	if _, err := Redo(db, 5); err != nil {
+		t.Fatalf("Redo failed: %v", err)
+	}
+	if _, err := Redo(db, 1); err == nil {
+		t.Error("Expected error when there is nothing left to redo")
+	}
+}
+
+func TestNewOperationClearsRedo(t *testing.T) {
+	db := setupTestDB(t)
+
+	op, _ := Begin(db, "add")
+	repository.CreateNote(db, "first", nil, false)
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if _, err := Undo(db, 1); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	op, _ = Begin(db, "add")
+	repository.CreateNote(db, "second", nil, false)
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if _, err := Redo(db, 1); err == nil {
+		t.Error("Expected redo to be unavailable after a new operation")
+	}
+}
+
+func TestUndoRefusesWhenRowChangedSince(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := repository.CreateNote(db, "Draft", nil, false)
+	if err != nil {
+		t.Fatalf("CreateNote failed: %v", err)
+	}
+
+	op, _ := Begin(db, "edit")
+	op.Track("note", note.ID)
+	content := "Edited"
+	if err := repository.UpdateNote(db, note.ID, &content, nil, nil); err != nil {
+		t.Fatalf("UpdateNote failed: %v", err)
+	}
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	// An edit made outside the journal, e.g. by an older binary.
+	if _, err := db.Exec("UPDATE notes SET content = 'Changed elsewhere' WHERE id = ?", note.ID); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	_, err = Undo(db, 1)
+	if err == nil || !strings.Contains(err.Error(), "has changed since") {
+		t.Fatalf("Expected conflict error, got %v", err)
+	}
+
+	current, _ := repository.GetNoteByID(db, note.ID)
+	if current.Content != "Changed elsewhere" {
+		t.Errorf("Undo should not have modified the note, got %q", current.Content)
+	}
+}
+
+func TestCommitSkipsNoOpCommands(t *testing.T) {
+	db := setupTestDB(t)
+
+	op, err := Begin(db, "project")
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := op.Track("active_project", 0); err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if count := countRows(t, db, "SELECT COUNT(*) FROM operations"); count != 0 {
+		t.Errorf("Expected no operation to be recorded, got %d", count)
+	}
+
+	if _, err := Undo(db, 1); err == nil {
+		t.Error("Expected error when there is nothing to undo")
+	}
+}
+
+func TestUndoRefusesIrreversible(t *testing.T) {
+	db := setupTestDB(t)
+
+	err := Run(db, "add", func(tx database.DBTX, op *Recorder) error {
+		_, err := repository.CreateNote(tx, "Secret plan", []string{}, false)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	noteID := countRows(t, db, "SELECT MAX(id) FROM notes")
+
+	err = Run(db, "delete", func(tx database.DBTX, op *Recorder) error {
+		if err := op.Track("note", noteID); err != nil {
+			return err
+		}
+		return repository.DeleteNote(tx, noteID)
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	err = RunIrreversible(db, "trash empty", func(tx database.DBTX) error {
+		_, err := repository.EmptyTrash(tx, time.Time{})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RunIrreversible failed: %v", err)
+	}
+
+	if changes := countRows(t, db, "SELECT COUNT(*) FROM operation_changes"); changes != 0 {
+		t.Errorf("%d journal entries left, want none holding the purged note", changes)
+	}
+
+	if _, err := Undo(db, 1); err == nil || !strings.Contains(err.Error(), "trash empty") {
+		t.Errorf("Undo() error = %v, want a refusal to undo emptying the trash", err)
+	}
+	if _, err := Redo(db, 1); err == nil {
+		t.Error("Redo() expected error, got nil")
+	}
+
+	if notes := countRows(t, db, "SELECT COUNT(*) FROM notes WHERE id = ?", noteID); notes != 0 {
+		t.Errorf("purged note is back after undo")
+	}
+}
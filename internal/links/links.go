@@ -0,0 +1,46 @@
+package links
+
+import (
+	"regexp"
+	"strings"
+)
+
+// References are written inside note and todo content as [[#42]] or
+// [[note:42]] for a note, [[todo:17]] for a todo and [[project:work]] for a
+// project. Anything else between double brackets is left alone.
+
+// Target is the item a reference points at. Ref is the ID of a note or todo,
+// or the name of a project.
+type Target struct {
+	Kind string
+	Ref  string
+}
+
+var referenceRegex = regexp.MustCompile(`\[\[\s*(?:#(\d+)|(note|todo):\s*(\d+)|project:\s*([a-z0-9]+(?:-[a-z0-9]+)*))\s*\]\]`)
+
+// Parse returns the distinct targets referenced in content, in the order they
+// first appear.
+func Parse(content string) []Target {
+	var targets []Target
+	seen := map[Target]bool{}
+
+	for _, match := range referenceRegex.FindAllStringSubmatch(content, -1) {
+		var target Target
+		switch {
+		case match[1] != "":
+			target = Target{Kind: "note", Ref: strings.TrimLeft(match[1], "0")}
+		case match[2] != "":
+			target = Target{Kind: match[2], Ref: strings.TrimLeft(match[3], "0")}
+		default:
+			target = Target{Kind: "project", Ref: match[4]}
+		}
+
+		if target.Ref == "" || seen[target] {
+			continue
+		}
+		seen[target] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
@@ -0,0 +1,48 @@
+package links
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Target
+	}{
+		{
+			name:    "every reference form",
+			content: "See [[#42]], [[note:7]], [[todo:17]] and [[project:side-quest]].",
+			want: []Target{
+				{Kind: "note", Ref: "42"},
+				{Kind: "note", Ref: "7"},
+				{Kind: "todo", Ref: "17"},
+				{Kind: "project", Ref: "side-quest"},
+			},
+		},
+		{
+			name:    "duplicates collapse",
+			content: "[[#42]] then [[note:42]] then [[ #042 ]]",
+			want:    []Target{{Kind: "note", Ref: "42"}},
+		},
+		{
+			name:    "not references",
+			content: "[[wiki page]] [[todo:abc]] [[project:Bad Name]] [#42] [[#0]]",
+			want:    nil,
+		},
+		{
+			name:    "no references",
+			content: "Plain text",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Activity is an entry in the activity log: an event such as "created" or
+// "completed" on a todo or project. Before and After hold the item as JSON on
+// either side of the event, and are empty when it did not exist or was not
+// recorded. EntityID is 0 when the item is unknown.
+type Activity struct {
+	ID         int
+	Event      string
+	EntityKind string
+	EntityID   int
+	Summary    string
+	Before     string
+	After      string
+	CreatedAt  time.Time
+}
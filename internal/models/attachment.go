@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Attachment is a file attached to a note or todo. Its content is stored
+// outside the database under Hash.
+type Attachment struct {
+	ID        int
+	OwnerKind string
+	OwnerID   int
+	Hash      string
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,7 @@
+package models
+
+type IntegrityCheck struct {
+	Name     string
+	Problems []string
+	Repaired bool
+}
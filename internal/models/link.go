@@ -0,0 +1,15 @@
+package models
+
+// Link is a [[...]] reference in the content of a note or todo. TargetRef is
+// the ID of the note or todo it points at, or the name of the project.
+// TargetTitle is empty and Broken is set when the target is missing or in
+// the trash.
+type Link struct {
+	SourceKind  string
+	SourceID    int
+	SourceTitle string
+	TargetKind  string
+	TargetRef   string
+	TargetTitle string
+	Broken      bool
+}
@@ -8,5 +8,8 @@ type Note struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	IsImportant bool
+	// TodoID is set on comments, the notes that belong to a todo.
+	TodoID      int
 	Tags        []string
+	Attachments []Attachment
 }
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Operation struct {
+	ID        int
+	Command   string
+	CreatedAt time.Time
+	UndoneAt  sql.NullTime
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Revision struct {
+	ID          int
+	EntityKind  string
+	EntityID    int
+	Revision    int
+	Content     string
+	Tags        []string
+	IsImportant bool
+	DueDate     sql.NullTime
+	CreatedAt   time.Time
+}
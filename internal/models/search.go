@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type SearchResult struct {
+	Kind        string
+	ID          int
+	Snippet     string
+	Rank        float64
+	CreatedAt   time.Time
+	IsImportant bool
+	IsComplete  bool
+	Tags        []string
+}
@@ -1,6 +1,7 @@
 package models
 
 type Tag struct {
-	ID   int
-	Name string
+	ID         int
+	Name       string
+	UsageCount int
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Template is the starting point for a note. Templates are stored in the
+// database or read from files, in which case Path is set and ID is zero.
+type Template struct {
+	ID          int
+	Name        string
+	Content     string
+	Tags        []string
+	IsImportant bool
+	Path        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func ValidateTemplateName(name string) error {
+	if !kebabCaseRegex.MatchString(name) {
+		return fmt.Errorf("template name must be kebab-case (lowercase letters, digits and hyphens only)")
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -13,5 +15,53 @@ type Todo struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	CompletedAt sql.NullTime
+	// PromotedFrom is the ID of the note the todo was promoted from.
+	PromotedFrom int
+	// Repeat is how often the todo recurs, as read by dateparse.ParseRepeat,
+	// or empty.
+	Repeat string
+	// Priority is P0, the most urgent, to P3, or empty.
+	Priority string
+	// ParentID is the ID of the todo this is a subtask of, or 0.
+	ParentID int
+	// Subtasks and SubtasksDone count the todo's own subtasks and how many of
+	// them are complete.
+	Subtasks     int
+	SubtasksDone int
+	// BlockedBy lists the open todos this one waits on.
+	BlockedBy   []int
 	Tags        []string
+	Attachments []Attachment
+}
+
+// TodoDependency records that a todo cannot start until another, BlockedBy,
+// is done.
+type TodoDependency struct {
+	TodoID    int
+	BlockedBy int
+}
+
+var priorityNames = map[string]string{"critical": "P0", "high": "P1", "medium": "P2", "low": "P3"}
+
+// ParsePriority reads a priority as P0 to P3, or by name as critical, high,
+// medium or low.
+func ParsePriority(input string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	if priority, ok := priorityNames[value]; ok {
+		return priority, nil
+	}
+	if len(value) == 2 && value[0] == 'p' && value[1] >= '0' && value[1] <= '3' {
+		return strings.ToUpper(value), nil
+	}
+	return "", fmt.Errorf("Invalid priority '%s'. Use P0 to P3, or critical, high, medium or low", input)
+}
+
+// PriorityName is the name of a priority, such as high for P1.
+func PriorityName(priority string) string {
+	for name, p := range priorityNames {
+		if p == priority {
+			return name
+		}
+	}
+	return ""
 }
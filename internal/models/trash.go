@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type TrashItem struct {
+	Kind      string
+	ID        int
+	Title     string
+	DeletedAt time.Time
+}
@@ -0,0 +1,177 @@
+package notebook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultName = "default"
+	EnvDB       = "NOTE_DB"
+)
+
+type Notebook struct {
+	Name      string
+	Path      string
+	IsCurrent bool
+}
+
+var nameRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func ValidateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("notebook name must be kebab-case (lowercase letters, digits and hyphens only)")
+	}
+	return nil
+}
+
+func DataDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".note"), nil
+}
+
+func Path(name string) (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	if name == DefaultName {
+		return filepath.Join(dataDir, "notes.db"), nil
+	}
+
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataDir, "notebooks", name+".db"), nil
+}
+
+func Exists(name string) (bool, error) {
+	if name == DefaultName {
+		return true, nil
+	}
+
+	path, err := Path(name)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func Current() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, "current_notebook"))
+	if os.IsNotExist(err) {
+		return DefaultName, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return DefaultName, nil
+	}
+	return name, nil
+}
+
+func Use(name string) error {
+	exists, err := Exists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("Notebook '%s' not found. Create it with: note notebook create %s", name, name)
+	}
+
+	dataDir, err := DataDir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("could not create directory %s: %w", dataDir, err)
+	}
+
+	return os.WriteFile(filepath.Join(dataDir, "current_notebook"), []byte(name+"\n"), 0644)
+}
+
+func List() ([]Notebook, error) {
+	current, err := Current()
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPath, err := Path(DefaultName)
+	if err != nil {
+		return nil, err
+	}
+
+	notebooks := []Notebook{{Name: DefaultName, Path: defaultPath, IsCurrent: current == DefaultName}}
+
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dataDir, "notebooks", "*.db"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	for _, path := range matches {
+		name := strings.TrimSuffix(filepath.Base(path), ".db")
+		notebooks = append(notebooks, Notebook{Name: name, Path: path, IsCurrent: current == name})
+	}
+
+	return notebooks, nil
+}
+
+// ResolveDBPath picks the database file for this invocation. An explicit
+// --db path wins, then --notebook, then the NOTE_DB environment variable, and
+// finally the notebook selected with `note notebook use`.
+func ResolveDBPath(dbFlag, notebookFlag string) (string, error) {
+	if dbFlag != "" {
+		return dbFlag, nil
+	}
+
+	if notebookFlag != "" {
+		exists, err := Exists(notebookFlag)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return "", fmt.Errorf("Notebook '%s' not found. Create it with: note notebook create %s", notebookFlag, notebookFlag)
+		}
+		return Path(notebookFlag)
+	}
+
+	if envPath := os.Getenv(EnvDB); envPath != "" {
+		return envPath, nil
+	}
+
+	current, err := Current()
+	if err != nil {
+		return "", err
+	}
+
+	return Path(current)
+}
@@ -0,0 +1,178 @@
+package notebook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvDB, "")
+
+	return home
+}
+
+func TestPath(t *testing.T) {
+	home := setupHome(t)
+
+	t.Run("default notebook", func(t *testing.T) {
+		path, err := Path(DefaultName)
+		if err != nil {
+			t.Fatalf("Path() error = %v", err)
+		}
+
+		want := filepath.Join(home, ".note", "notes.db")
+		if path != want {
+			t.Errorf("Path() = %q, want %q", path, want)
+		}
+	})
+
+	t.Run("named notebook", func(t *testing.T) {
+		path, err := Path("work")
+		if err != nil {
+			t.Fatalf("Path() error = %v", err)
+		}
+
+		want := filepath.Join(home, ".note", "notebooks", "work.db")
+		if path != want {
+			t.Errorf("Path() = %q, want %q", path, want)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		if _, err := Path("../escape"); err == nil {
+			t.Error("Path() expected error for invalid name, got nil")
+		}
+	})
+}
+
+func TestUse(t *testing.T) {
+	home := setupHome(t)
+
+	t.Run("missing notebook", func(t *testing.T) {
+		if err := Use("work"); err == nil {
+			t.Error("Use() expected error for missing notebook, got nil")
+		}
+	})
+
+	t.Run("existing notebook", func(t *testing.T) {
+		createNotebookFile(t, home, "work")
+
+		if err := Use("work"); err != nil {
+			t.Fatalf("Use() error = %v", err)
+		}
+
+		current, err := Current()
+		if err != nil {
+			t.Fatalf("Current() error = %v", err)
+		}
+
+		if current != "work" {
+			t.Errorf("Current() = %q, want %q", current, "work")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	home := setupHome(t)
+
+	createNotebookFile(t, home, "work")
+	createNotebookFile(t, home, "personal")
+
+	notebooks, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	want := []string{DefaultName, "personal", "work"}
+	if len(notebooks) != len(want) {
+		t.Fatalf("List() returned %d notebooks, want %d", len(notebooks), len(want))
+	}
+
+	for i, name := range want {
+		if notebooks[i].Name != name {
+			t.Errorf("List()[%d].Name = %q, want %q", i, notebooks[i].Name, name)
+		}
+	}
+
+	if !notebooks[0].IsCurrent {
+		t.Error("List() did not mark the default notebook as current")
+	}
+}
+
+func TestResolveDBPath(t *testing.T) {
+	home := setupHome(t)
+	createNotebookFile(t, home, "work")
+
+	defaultPath := filepath.Join(home, ".note", "notes.db")
+	workPath := filepath.Join(home, ".note", "notebooks", "work.db")
+
+	t.Run("current notebook by default", func(t *testing.T) {
+		path, err := ResolveDBPath("", "")
+		if err != nil {
+			t.Fatalf("ResolveDBPath() error = %v", err)
+		}
+		if path != defaultPath {
+			t.Errorf("ResolveDBPath() = %q, want %q", path, defaultPath)
+		}
+	})
+
+	t.Run("environment variable", func(t *testing.T) {
+		t.Setenv(EnvDB, "/tmp/env.db")
+
+		path, err := ResolveDBPath("", "")
+		if err != nil {
+			t.Fatalf("ResolveDBPath() error = %v", err)
+		}
+		if path != "/tmp/env.db" {
+			t.Errorf("ResolveDBPath() = %q, want %q", path, "/tmp/env.db")
+		}
+	})
+
+	t.Run("notebook flag beats environment", func(t *testing.T) {
+		t.Setenv(EnvDB, "/tmp/env.db")
+
+		path, err := ResolveDBPath("", "work")
+		if err != nil {
+			t.Fatalf("ResolveDBPath() error = %v", err)
+		}
+		if path != workPath {
+			t.Errorf("ResolveDBPath() = %q, want %q", path, workPath)
+		}
+	})
+
+	t.Run("db flag beats everything", func(t *testing.T) {
+		t.Setenv(EnvDB, "/tmp/env.db")
+
+		path, err := ResolveDBPath("/tmp/flag.db", "work")
+		if err != nil {
+			t.Fatalf("ResolveDBPath() error = %v", err)
+		}
+		if path != "/tmp/flag.db" {
+			t.Errorf("ResolveDBPath() = %q, want %q", path, "/tmp/flag.db")
+		}
+	})
+
+	t.Run("unknown notebook", func(t *testing.T) {
+		if _, err := ResolveDBPath("", "missing"); err == nil {
+			t.Error("ResolveDBPath() expected error for unknown notebook, got nil")
+		}
+	})
+}
+
+func createNotebookFile(t *testing.T, home, name string) {
+	t.Helper()
+
+	dir := filepath.Join(home, ".note", "notebooks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".db"), nil, 0644); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+}
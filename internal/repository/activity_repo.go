@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// The activity log records what happened to todos and projects. Entries are
+// never edited, and are kept when the todo or project they are about is
+// deleted. The summary and payloads hold content, so they are sealed like
+// note content in an encrypted database.
+
+// CreateActivity adds an entry to the activity log, timestamped now.
+func CreateActivity(db database.DBTX, entry models.Activity) error {
+	summary, err := sealContent(entry.Summary)
+	if err != nil {
+		return err
+	}
+
+	before, err := sealPayload(entry.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := sealPayload(entry.After)
+	if err != nil {
+		return err
+	}
+
+	var entityID interface{}
+	if entry.EntityID != 0 {
+		entityID = entry.EntityID
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO activity (event, entity_kind, entity_id, summary, before, after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, entry.Event, entry.EntityKind, entityID, summary, before, after, time.Now())
+	return err
+}
+
+type ActivityListOptions struct {
+	// Kind is todo or project; EntityID narrows it to one of them.
+	Kind      string
+	EntityID  int
+	Event     string
+	StartDate *time.Time
+	EndDate   *time.Time
+
+	// Entries are listed oldest first unless Reverse is set.
+	Reverse bool
+	Limit   int
+}
+
+func ListActivity(db database.DBTX, opts ActivityListOptions) ([]models.Activity, error) {
+	query := `
+		SELECT id, event, entity_kind, COALESCE(entity_id, 0), summary,
+			COALESCE(before, ''), COALESCE(after, ''), created_at
+		FROM activity
+	`
+
+	var conditions []string
+	var args []interface{}
+
+	if opts.Kind != "" {
+		conditions = append(conditions, "entity_kind = ?")
+		args = append(args, opts.Kind)
+	}
+
+	if opts.EntityID != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, opts.EntityID)
+	}
+
+	if opts.Event != "" {
+		conditions = append(conditions, "event = ?")
+		args = append(args, opts.Event)
+	}
+
+	dateConditions, dateArgs := createdDateRange("created_at", opts.StartDate, opts.EndDate)
+	conditions = append(conditions, dateConditions...)
+	args = append(args, dateArgs...)
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if opts.Reverse {
+		query += " ORDER BY created_at DESC, id DESC"
+	} else {
+		query += " ORDER BY created_at, id"
+	}
+	query += limitClause(opts.Limit, 0)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.Activity
+	for rows.Next() {
+		var entry models.Activity
+		if err := rows.Scan(&entry.ID, &entry.Event, &entry.EntityKind, &entry.EntityID, &entry.Summary,
+			&entry.Before, &entry.After, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		for _, field := range []*string{&entry.Summary, &entry.Before, &entry.After} {
+			if err := openContent(field); err != nil {
+				return nil, err
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// sealPayload seals a JSON payload, storing an empty one as NULL.
+func sealPayload(payload string) (interface{}, error) {
+	if payload == "" {
+		return nil, nil
+	}
+	return sealContent(payload)
+}
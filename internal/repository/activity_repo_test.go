@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/models"
+	"github.com/nathan-nicholson/note/internal/vault"
+)
+
+func TestListActivity(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, entry := range []models.Activity{
+		{Event: "created", EntityKind: "todo", EntityID: 1, Summary: "Ship it", After: `{"content":"Ship it"}`},
+		{Event: "activated", EntityKind: "project", EntityID: 2, Summary: "work"},
+		{Event: "completed", EntityKind: "todo", EntityID: 1, Summary: "Ship it", Before: `{"is_complete":false}`, After: `{"is_complete":true}`},
+		{Event: "created", EntityKind: "todo", EntityID: 3, Summary: "Write docs"},
+	} {
+		if err := CreateActivity(db, entry); err != nil {
+			t.Fatalf("CreateActivity() error = %v", err)
+		}
+	}
+
+	all, err := ListActivity(db, ActivityListOptions{})
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if len(all) != 4 || all[0].Summary != "Ship it" || all[3].Summary != "Write docs" {
+		t.Errorf("ListActivity() = %+v, want all four oldest first", all)
+	}
+	if all[0].Before != "" || all[0].After != `{"content":"Ship it"}` {
+		t.Errorf("ListActivity()[0] payload = %q, %q, want no before and the created state", all[0].Before, all[0].After)
+	}
+
+	tests := []struct {
+		name string
+		opts ActivityListOptions
+		want []int
+	}{
+		{name: "kind", opts: ActivityListOptions{Kind: "project"}, want: []int{2}},
+		{name: "entity", opts: ActivityListOptions{Kind: "todo", EntityID: 1}, want: []int{1, 3}},
+		{name: "event", opts: ActivityListOptions{Event: "created"}, want: []int{1, 4}},
+		{name: "newest first", opts: ActivityListOptions{Reverse: true, Limit: 2}, want: []int{4, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ListActivity(db, tt.opts)
+			if err != nil {
+				t.Fatalf("ListActivity() error = %v", err)
+			}
+
+			var got []int
+			for _, entry := range entries {
+				got = append(got, entry.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListActivity() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ListActivity() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	entries, err := ListActivity(db, ActivityListOptions{StartDate: &yesterday, EndDate: &yesterday})
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("ListActivity() for yesterday = %d entries, want none", len(entries))
+	}
+}
+
+func TestActivityEncrypted(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := CreateActivity(db, models.Activity{Event: "created", EntityKind: "todo", EntityID: 1, Summary: "Draft offer letter", After: `{"content":"Draft offer letter"}`}); err != nil {
+		t.Fatalf("CreateActivity() error = %v", err)
+	}
+
+	encryptTestDB(t, db, "correct horse")
+
+	if err := CreateActivity(db, models.Activity{Event: "deleted", EntityKind: "todo", EntityID: 1, Summary: "Draft offer letter", Before: `{"content":"Draft offer letter"}`}); err != nil {
+		t.Fatalf("CreateActivity() error = %v", err)
+	}
+
+	rows, err := db.Query("SELECT summary, COALESCE(before, after) FROM activity")
+	if err != nil {
+		t.Fatalf("Failed to read activity: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var summary, payload string
+		if err := rows.Scan(&summary, &payload); err != nil {
+			t.Fatalf("Failed to read activity: %v", err)
+		}
+		if !vault.IsSealed(summary) || !vault.IsSealed(payload) {
+			t.Errorf("activity stored as %q, %q, want it sealed", summary, payload)
+		}
+	}
+
+	entries, err := ListActivity(db, ActivityListOptions{})
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if len(entries) != 2 || entries[1].Summary != "Draft offer letter" || entries[1].Before != `{"content":"Draft offer letter"}` {
+		t.Errorf("ListActivity() = %+v, want both entries decrypted", entries)
+	}
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Attachment rows point at blobs in the attachment store by hash. Rows of
+// items in the trash are kept so that restoring an item restores its
+// attachments; they are removed with the item when the trash is emptied.
+
+var ErrAttachmentsEncrypted = errors.New("attachments are not available for an encrypted database: attached files would be stored unencrypted")
+
+// AddAttachment records a file already copied into the attachment store as
+// attached to a note or todo.
+func AddAttachment(db database.DBTX, kind string, id int, hash, name string, size int64) (*models.Attachment, error) {
+	encrypted, err := database.Encrypted(db)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, ErrAttachmentsEncrypted
+	}
+
+	if err := requireOwner(db, kind, id); err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO attachments (owner_kind, owner_id, hash, name, size)
+		VALUES (?, ?, ?, ?, ?)
+	`, kind, id, hash, name, size)
+	if err != nil {
+		return nil, err
+	}
+
+	attachmentID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return getAttachment(db, "id = ?", attachmentID)
+}
+
+func ListAttachments(db database.DBTX, kind string, id int) ([]models.Attachment, error) {
+	rows, err := db.Query(`
+		SELECT id, owner_kind, owner_id, hash, name, size, created_at
+		FROM attachments
+		WHERE owner_kind = ? AND owner_id = ?
+		ORDER BY id
+	`, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.OwnerKind, &a.OwnerID, &a.Hash, &a.Name, &a.Size, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// FindAttachment looks up an attachment of a note or todo by its ID or, failing
+// that, by its file name.
+func FindAttachment(db database.DBTX, kind string, id int, ref string) (*models.Attachment, error) {
+	if err := requireOwner(db, kind, id); err != nil {
+		return nil, err
+	}
+
+	if attachmentID, err := strconv.Atoi(ref); err == nil {
+		a, err := getAttachment(db, "id = ? AND owner_kind = ? AND owner_id = ?", attachmentID, kind, id)
+		if err != sql.ErrNoRows {
+			return a, err
+		}
+	}
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM attachments WHERE name = ? AND owner_kind = ? AND owner_id = ?", ref, kind, id).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case count == 0:
+		return nil, fmt.Errorf("%s #%d has no attachment '%s'", kind, id, ref)
+	case count > 1:
+		return nil, fmt.Errorf("%s #%d has several attachments named '%s'; use the attachment ID", kind, id, ref)
+	}
+
+	return getAttachment(db, "name = ? AND owner_kind = ? AND owner_id = ?", ref, kind, id)
+}
+
+func RemoveAttachment(db database.DBTX, attachmentID int) error {
+	_, err := db.Exec("DELETE FROM attachments WHERE id = ?", attachmentID)
+	return err
+}
+
+// ReferencedBlobs returns the hashes of every blob still in use: by an
+// attachment, including those of items in the trash, or by a snapshot in the
+// undo journal, so that undoing a detach finds the file again.
+func ReferencedBlobs(db database.DBTX) (map[string]bool, error) {
+	hashes := map[string]bool{}
+
+	rows, err := db.Query("SELECT DISTINCT hash FROM attachments")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		hashes[hash] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT before, after FROM operation_changes
+		WHERE entity_kind IN ('note', 'todo')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var before, after sql.NullString
+		if err := rows.Scan(&before, &after); err != nil {
+			return nil, err
+		}
+		for _, data := range []sql.NullString{before, after} {
+			if !data.Valid {
+				continue
+			}
+			var s struct {
+				Attachments []attachmentSnapshot `json:"attachments"`
+			}
+			if err := json.Unmarshal([]byte(data.String), &s); err != nil {
+				return nil, err
+			}
+			for _, a := range s.Attachments {
+				hashes[a.Hash] = true
+			}
+		}
+	}
+
+	return hashes, rows.Err()
+}
+
+func getAttachment(db database.DBTX, where string, args ...interface{}) (*models.Attachment, error) {
+	var a models.Attachment
+	err := db.QueryRow(`
+		SELECT id, owner_kind, owner_id, hash, name, size, created_at
+		FROM attachments
+		WHERE `+where, args...).Scan(&a.ID, &a.OwnerKind, &a.OwnerID, &a.Hash, &a.Name, &a.Size, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// requireOwner checks that the note or todo exists and is not in the trash.
+func requireOwner(db database.DBTX, kind string, id int) error {
+	var table, label string
+	switch kind {
+	case "note":
+		table, label = "notes", "Note"
+	case "todo":
+		table, label = "todos", "Todo"
+	default:
+		return fmt.Errorf("cannot attach files to a %s", kind)
+	}
+
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s #%d not found", label, id)
+	}
+	return nil
+}
+
+// pruneAttachments removes attachment rows whose note or todo no longer
+// exists at all.
+func pruneAttachments(db database.DBTX) error {
+	_, err := db.Exec(`
+		DELETE FROM attachments
+		WHERE (owner_kind = 'note' AND owner_id NOT IN (SELECT id FROM notes))
+			OR (owner_kind = 'todo' AND owner_id NOT IN (SELECT id FROM todos))
+	`)
+	return err
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const testHash = "98ea6e4f216f2fb4b69fff9b3a44842c38686ca685f3f55dc48c5d3fb1107be4"
+
+func TestAttachments_AddFindRemove(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Crash report", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	added, err := AddAttachment(db, "note", note.ID, testHash, "crash.log", 2048)
+	if err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+
+	got, err := GetNoteByID(db, note.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID() error = %v", err)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Name != "crash.log" || got.Attachments[0].Size != 2048 {
+		t.Fatalf("GetNoteByID().Attachments = %+v, want crash.log", got.Attachments)
+	}
+
+	byName, err := FindAttachment(db, "note", note.ID, "crash.log")
+	if err != nil || byName.ID != added.ID {
+		t.Errorf("FindAttachment(name) = %+v, %v, want attachment %d", byName, err, added.ID)
+	}
+
+	if _, err := FindAttachment(db, "note", note.ID, "missing.log"); err == nil {
+		t.Error("FindAttachment() of an unknown name succeeded, want an error")
+	}
+
+	if err := RemoveAttachment(db, added.ID); err != nil {
+		t.Fatalf("RemoveAttachment() error = %v", err)
+	}
+
+	attachments, err := ListAttachments(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+	if len(attachments) != 0 {
+		t.Errorf("ListAttachments() after remove returned %d, want 0", len(attachments))
+	}
+}
+
+func TestAddAttachment_RequiresLiveOwner(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := AddAttachment(db, "todo", 42, testHash, "a.txt", 1); err == nil {
+		t.Error("AddAttachment() to a missing todo succeeded, want an error")
+	}
+
+	note, err := CreateNote(db, "Trashed", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := DeleteNote(db, note.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := AddAttachment(db, "note", note.ID, testHash, "a.txt", 1); err == nil {
+		t.Error("AddAttachment() to a trashed note succeeded, want an error")
+	}
+}
+
+func TestAddAttachment_RefusesEncrypted(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Secret", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	encryptTestDB(t, db, "correct horse")
+
+	if _, err := AddAttachment(db, "note", note.ID, testHash, "a.txt", 1); !errors.Is(err, ErrAttachmentsEncrypted) {
+		t.Errorf("AddAttachment() error = %v, want ErrAttachmentsEncrypted", err)
+	}
+}
+
+func TestAttachments_SnapshotRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Crash report", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	before, err := Snapshot(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if _, err := AddAttachment(db, "note", note.ID, testHash, "crash.log", 2048); err != nil {
+		t.Fatalf("AddAttachment() error = %v", err)
+	}
+
+	after, err := Snapshot(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if err := RestoreSnapshot(db, "note", note.ID, before); err != nil {
+		t.Fatalf("RestoreSnapshot(before) error = %v", err)
+	}
+	attachments, err := ListAttachments(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+	if len(attachments) != 0 {
+		t.Errorf("attachments after restoring the earlier snapshot = %d, want 0", len(attachments))
+	}
+
+	if err := RestoreSnapshot(db, "note", note.ID, after); err != nil {
+		t.Fatalf("RestoreSnapshot(after) error = %v", err)
+	}
+	attachments, err = ListAttachments(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListAttachments() error = %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].Hash != testHash {
+		t.Errorf("attachments after restoring the later snapshot = %+v, want crash.log", attachments)
+	}
+
+	// The blob stays referenced while the undo journal holds a snapshot of it,
+	// even once the attachment itself is gone.
+	if _, err := db.Exec("INSERT INTO operations (command) VALUES ('attach')"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO operation_changes (operation_id, entity_kind, entity_id, before, after) VALUES (1, 'note', ?, ?, ?)", note.ID, string(before), string(after)); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := RemoveAttachment(db, attachments[0].ID); err != nil {
+		t.Fatalf("RemoveAttachment() error = %v", err)
+	}
+
+	referenced, err := ReferencedBlobs(db)
+	if err != nil {
+		t.Fatalf("ReferencedBlobs() error = %v", err)
+	}
+	if !referenced[testHash] {
+		t.Error("ReferencedBlobs() dropped a blob the undo journal refers to")
+	}
+}
+
+func TestEmptyTrash_RemovesAttachments(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Doomed", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := AddAttachment(db, "note", note.ID, testHash, "a.txt", 1); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := DeleteNote(db, note.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := EmptyTrash(db, time.Time{}); err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+
+	referenced, err := ReferencedBlobs(db)
+	if err != nil {
+		t.Fatalf("ReferencedBlobs() error = %v", err)
+	}
+	if len(referenced) != 0 {
+		t.Errorf("ReferencedBlobs() after EmptyTrash = %v, want none", referenced)
+	}
+}
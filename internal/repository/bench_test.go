@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// The benchmarks share one database seeded with a year of data: benchNotes
+// notes spread evenly over 365 days, each tagged with its project and one
+// topic tag, plus benchTodos todos. Seeding takes a few seconds, so it
+// happens once per process.
+const (
+	benchNotes    = 100000
+	benchTodos    = 10000
+	benchProjects = 10
+	benchTopics   = 50
+)
+
+var (
+	benchOnce sync.Once
+	benchDB   *sql.DB
+	benchErr  error
+	benchDay  time.Time
+)
+
+func setupBenchDB(b *testing.B) *sql.DB {
+	b.Helper()
+
+	benchOnce.Do(func() {
+		benchDB, benchErr = sql.Open("sqlite3", "file:bench?mode=memory&cache=shared&_foreign_keys=on")
+		if benchErr != nil {
+			return
+		}
+		if benchErr = database.Migrate(benchDB); benchErr != nil {
+			return
+		}
+		benchErr = database.WithTx(benchDB, seedBenchData)
+	})
+
+	if benchErr != nil {
+		b.Fatalf("Failed to seed benchmark database: %v", benchErr)
+	}
+
+	return benchDB
+}
+
+func seedBenchData(tx database.DBTX) error {
+	start := time.Now().AddDate(-1, 0, 0)
+	benchDay = start.AddDate(0, 0, 180)
+
+	tagIDs := make(map[string]int)
+	for i := 0; i < benchProjects; i++ {
+		name := fmt.Sprintf("project%d", i)
+		if _, err := tx.Exec("INSERT INTO projects (name, created_at, is_closed) VALUES (?, ?, 0)", name, start); err != nil {
+			return err
+		}
+		id, err := GetOrCreateTag(tx, name)
+		if err != nil {
+			return err
+		}
+		tagIDs[name] = id
+	}
+	for i := 0; i < benchTopics; i++ {
+		name := fmt.Sprintf("topic%d", i)
+		id, err := GetOrCreateTag(tx, name)
+		if err != nil {
+			return err
+		}
+		tagIDs[name] = id
+	}
+
+	step := 365 * 24 * time.Hour / benchNotes
+	for i := 0; i < benchNotes; i++ {
+		createdAt := start.Add(time.Duration(i) * step)
+		result, err := tx.Exec("INSERT INTO notes (content, is_important, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			fmt.Sprintf("Note %d about something that happened", i), i%20 == 0, createdAt, createdAt)
+		if err != nil {
+			return err
+		}
+		id, _ := result.LastInsertId()
+
+		for _, tag := range []string{fmt.Sprintf("project%d", i%benchProjects), fmt.Sprintf("topic%d", i%benchTopics)} {
+			if _, err := tx.Exec("INSERT INTO note_tags (note_id, tag_id) VALUES (?, ?)", id, tagIDs[tag]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := 0; i < benchTodos; i++ {
+		createdAt := start.Add(time.Duration(i) * step * benchNotes / benchTodos)
+		result, err := tx.Exec("INSERT INTO todos (content, is_complete, due_date, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+			fmt.Sprintf("Todo %d", i), i%3 != 0, createdAt.AddDate(0, 0, 7).Format("2006-01-02"), createdAt, createdAt)
+		if err != nil {
+			return err
+		}
+		id, _ := result.LastInsertId()
+
+		if _, err := tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", id, tagIDs[fmt.Sprintf("project%d", i%benchProjects)]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func BenchmarkListNotes(b *testing.B) {
+	db := setupBenchDB(b)
+	day := benchDay
+	monthStart := benchDay.AddDate(0, -1, 0)
+
+	cases := []struct {
+		name string
+		opts NoteListOptions
+	}{
+		{name: "one day", opts: NoteListOptions{StartDate: &day, EndDate: &day}},
+		{name: "one month", opts: NoteListOptions{StartDate: &monthStart, EndDate: &day}},
+		{name: "tag", opts: NoteListOptions{Tags: []string{"topic7"}}},
+		{name: "all", opts: NoteListOptions{}},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ListNotes(db, tc.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkListNotesPerRowTags loads tags one note at a time, as ListNotes
+// used to, for comparison with BenchmarkListNotes.
+func BenchmarkListNotesPerRowTags(b *testing.B) {
+	db := setupBenchDB(b)
+	monthStart := benchDay.AddDate(0, -1, 0)
+
+	cases := []struct {
+		name       string
+		start, end *time.Time
+	}{
+		{name: "one month", start: &monthStart, end: &benchDay},
+		{name: "all"},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := listNotesPerRowTags(db, tc.start, tc.end); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// listNotesPerRowTags is ListNotes without the bulk tag lookup: the notes are
+// read first and then their tags are queried once per note.
+func listNotesPerRowTags(db database.DBTX, start, end *time.Time) ([]models.Note, error) {
+	conditions, args := createdDateRange("created_at", start, end)
+	conditions = append(conditions, "deleted_at IS NULL")
+
+	rows, err := db.Query(`
+		SELECT id, content, created_at, updated_at, is_important
+		FROM notes
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.Note
+	for rows.Next() {
+		var note models.Note
+		if err := rows.Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.IsImportant); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		if notes[i].Tags, err = GetTagsForNote(db, notes[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return notes, nil
+}
+
+func BenchmarkListTodos(b *testing.B) {
+	db := setupBenchDB(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ListTodos(db, TodoListOptions{Incomplete: true}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetIncompleteTodosForProject(b *testing.B) {
+	db := setupBenchDB(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := GetIncompleteTodosForProject(db, "project3"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListAllTags(b *testing.B) {
+	db := setupBenchDB(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ListAllTags(db); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Comments are ordinary notes that belong to a todo. They appear in note
+// listings and search like any other note, and are kept as plain notes if
+// their todo is permanently deleted.
+
+// PromoteNote creates a todo from the content and tags of a note and records
+// the note it came from. The note itself is left as it is.
+func PromoteNote(db database.DBTX, noteID int, dueDate *time.Time) (*models.Todo, error) {
+	var todo *models.Todo
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		note, err := GetNoteByID(tx, noteID)
+		if err != nil {
+			return err
+		}
+
+		todo, err = CreateTodo(tx, note.Content, note.Tags, dueDate)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE todos SET promoted_from = ? WHERE id = ?", noteID, todo.ID); err != nil {
+			return err
+		}
+		todo.PromotedFrom = noteID
+		return nil
+	})
+
+	return todo, err
+}
+
+// CreateComment adds a note to a todo.
+func CreateComment(db database.DBTX, todoID int, content string, tags []string, isImportant bool) (*models.Note, error) {
+	var note *models.Note
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		if _, err := GetTodoByID(tx, todoID); err != nil {
+			return err
+		}
+
+		var err error
+		note, err = CreateNote(tx, content, tags, isImportant)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("UPDATE notes SET todo_id = ? WHERE id = ?", todoID, note.ID); err != nil {
+			return err
+		}
+		note.TodoID = todoID
+		return nil
+	})
+
+	return note, err
+}
+
+// ListComments returns the comments on a todo, oldest first.
+func ListComments(db database.DBTX, todoID int) ([]models.Note, error) {
+	rows, err := db.Query(`
+		SELECT id, content, created_at, updated_at, is_important, todo_id
+		FROM notes
+		WHERE todo_id = ? AND deleted_at IS NULL
+		ORDER BY created_at, id
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.Note
+	for rows.Next() {
+		var note models.Note
+		if err := rows.Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.IsImportant, &note.TodoID); err != nil {
+			return nil, err
+		}
+
+		if err := openContent(&note.Content); err != nil {
+			return nil, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachNoteTags(db, notes); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromoteNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Cache the index", []string{"perf", "work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	todo, err := PromoteNote(db, note.ID, &due)
+	if err != nil {
+		t.Fatalf("PromoteNote() error = %v", err)
+	}
+
+	got, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if got.Content != "Cache the index" {
+		t.Errorf("Content = %q, want %q", got.Content, "Cache the index")
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "perf" || got.Tags[1] != "work" {
+		t.Errorf("Tags = %v, want [perf work]", got.Tags)
+	}
+	if got.PromotedFrom != note.ID {
+		t.Errorf("PromotedFrom = %d, want %d", got.PromotedFrom, note.ID)
+	}
+	if !got.DueDate.Valid || got.DueDate.Time.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("DueDate = %v, want 2026-03-01", got.DueDate)
+	}
+
+	if _, err := GetNoteByID(db, note.ID); err != nil {
+		t.Errorf("The promoted note should be kept: %v", err)
+	}
+
+	if _, err := PromoteNote(db, 99, nil); err == nil {
+		t.Error("PromoteNote() of a missing note should fail")
+	}
+}
+
+func TestComments(t *testing.T) {
+	db := setupTestDB(t)
+
+	todo, err := CreateTodo(db, "Fix the build", []string{"work"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := CreateNote(db, "Unrelated", []string{"work"}, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	first, err := CreateComment(db, todo.ID, "Flaky test in CI", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if first.TodoID != todo.ID {
+		t.Errorf("TodoID = %d, want %d", first.TodoID, todo.ID)
+	}
+
+	if _, err := CreateComment(db, todo.ID, "Pinned the version", nil, true); err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+
+	comments, err := ListComments(db, todo.ID)
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("ListComments() returned %d notes, want 2", len(comments))
+	}
+	if comments[0].Content != "Flaky test in CI" || len(comments[0].Tags) != 1 {
+		t.Errorf("comments[0] = %+v, want the first comment tagged work", comments[0])
+	}
+	if comments[1].Content != "Pinned the version" || !comments[1].IsImportant {
+		t.Errorf("comments[1] = %+v, want the important second comment", comments[1])
+	}
+
+	if err := DeleteNote(db, first.ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	comments, err = ListComments(db, todo.ID)
+	if err != nil {
+		t.Fatalf("ListComments() error = %v", err)
+	}
+	if len(comments) != 1 {
+		t.Errorf("ListComments() returned %d notes after a delete, want 1", len(comments))
+	}
+
+	if _, err := CreateComment(db, 99, "Nowhere", nil, false); err == nil {
+		t.Error("CreateComment() on a missing todo should fail")
+	}
+}
+
+func TestSnapshotKeepsCommentAndPromotion(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Idea", nil, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	todo, err := PromoteNote(db, note.ID, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	comment, err := CreateComment(db, todo.ID, "Progress", nil, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	todoData, err := Snapshot(db, "todo", todo.ID)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	noteData, err := Snapshot(db, "note", comment.ID)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE todos SET promoted_from = NULL WHERE id = ?", todo.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.Exec("UPDATE notes SET todo_id = NULL WHERE id = ?", comment.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := RestoreSnapshot(db, "todo", todo.ID, todoData); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if err := RestoreSnapshot(db, "note", comment.ID, noteData); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	restoredTodo, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if restoredTodo.PromotedFrom != note.ID {
+		t.Errorf("PromotedFrom = %d, want %d", restoredTodo.PromotedFrom, note.ID)
+	}
+
+	restoredNote, err := GetNoteByID(db, comment.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID() error = %v", err)
+	}
+	if restoredNote.TodoID != todo.ID {
+		t.Errorf("TodoID = %d, want %d", restoredNote.TodoID, todo.ID)
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/database"
+)
+
+// TestConcurrentWriters runs several processes' worth of writers against one
+// database file, each with its own connection pool, the way separate terminals
+// running note at the same time would.
+func TestConcurrentWriters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping stress test in short mode")
+	}
+
+	const (
+		writers    = 8
+		iterations = 25
+	)
+
+	dbPath := filepath.Join(t.TempDir(), "notes.db")
+
+	handles := make([]database.DBTX, writers)
+	var wg sync.WaitGroup
+	openErrs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db, err := database.Open(dbPath)
+			if err != nil {
+				openErrs <- err
+				return
+			}
+			t.Cleanup(func() { db.Close() })
+			handles[i] = db
+		}()
+	}
+	wg.Wait()
+	close(openErrs)
+	for err := range openErrs {
+		t.Fatalf("Open() failed: %v", err)
+	}
+
+	project, err := CreateProject(handles[0], "shared", nil)
+	if err != nil {
+		t.Fatalf("CreateProject() failed: %v", err)
+	}
+
+	errs := make(chan error, writers*iterations)
+	for i, db := range handles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range iterations {
+				err := database.WithTx(db, func(tx database.DBTX) error {
+					note, err := CreateNote(tx, fmt.Sprintf("writer %d note %d", i, j), []string{"shared"}, false)
+					if err != nil {
+						return err
+					}
+					if _, err := CreateTodo(tx, fmt.Sprintf("writer %d todo %d", i, j), []string{"shared"}, nil); err != nil {
+						return err
+					}
+					edited := note.Content + " (edited)"
+					important := true
+					if err := UpdateNote(tx, note.ID, &edited, []string{"shared", "edited"}, &important); err != nil {
+						return err
+					}
+					return SetActiveProject(tx, project.ID)
+				})
+				if err != nil {
+					errs <- fmt.Errorf("writer %d iteration %d: %w", i, j, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	db := handles[0]
+	for table, want := range map[string]int{"notes": writers * iterations, "todos": writers * iterations, "active_project": 1} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("Count %s failed: %v", table, err)
+		}
+		if count != want {
+			t.Errorf("%s has %d rows, want %d", table, count, want)
+		}
+	}
+
+	var schemaVersions, homeProjects int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&schemaVersions); err != nil {
+		t.Fatalf("Count schema_version failed: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM projects WHERE name = 'home'").Scan(&homeProjects); err != nil {
+		t.Fatalf("Count home projects failed: %v", err)
+	}
+	if homeProjects != 1 {
+		t.Errorf("found %d home projects, want 1", homeProjects)
+	}
+	if schemaVersions != database.LatestVersion() {
+		t.Errorf("schema_version has %d rows, want %d", schemaVersions, database.LatestVersion())
+	}
+}
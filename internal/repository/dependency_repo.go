@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// A todo is blocked while any todo it depends on is open. Dependencies stay
+// recorded once the blocker is done, so reopening the blocker blocks the todo
+// again.
+
+// AddDependency records that a todo cannot start until blockedBy is done. It
+// refuses dependencies that would leave a todo waiting on itself.
+func AddDependency(db database.DBTX, todoID, blockedBy int) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		if todoID == blockedBy {
+			return fmt.Errorf("Todo #%d cannot block itself", todoID)
+		}
+
+		if _, err := GetTodoByID(tx, todoID); err != nil {
+			return err
+		}
+
+		blocker, err := GetTodoByID(tx, blockedBy)
+		if err != nil {
+			return err
+		}
+		if blocker.IsComplete {
+			return fmt.Errorf("Todo #%d is already complete", blockedBy)
+		}
+
+		cycle, err := waitsOn(tx, blockedBy, todoID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return fmt.Errorf("Cannot block todo #%d by #%d: #%d already waits on #%d", todoID, blockedBy, blockedBy, todoID)
+		}
+
+		now := time.Now()
+		_, err = tx.Exec("INSERT OR IGNORE INTO todo_dependencies (todo_id, blocked_by, created_at) VALUES (?, ?, ?)", todoID, blockedBy, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("UPDATE todos SET updated_at = ? WHERE id = ?", now, todoID)
+		return err
+	})
+}
+
+func RemoveDependency(db database.DBTX, todoID, blockedBy int) error {
+	result, err := db.Exec("DELETE FROM todo_dependencies WHERE todo_id = ? AND blocked_by = ?", todoID, blockedBy)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("Todo #%d is not blocked by #%d", todoID, blockedBy)
+	}
+
+	_, err = db.Exec("UPDATE todos SET updated_at = ? WHERE id = ?", time.Now(), todoID)
+	return err
+}
+
+// waitsOn reports whether a todo depends on other, directly or through the
+// todos it depends on.
+func waitsOn(db database.DBTX, id, other int) (bool, error) {
+	var found bool
+	err := db.QueryRow(`
+		WITH RECURSIVE blockers(id) AS (
+			SELECT blocked_by FROM todo_dependencies WHERE todo_id = ?
+			UNION
+			SELECT d.blocked_by FROM todo_dependencies d JOIN blockers b ON d.todo_id = b.id
+		)
+		SELECT EXISTS(SELECT 1 FROM blockers WHERE id = ?)
+	`, id, other).Scan(&found)
+	return found, err
+}
+
+// ListDependencies returns the dependencies between todos that are not
+// deleted, blockers first.
+func ListDependencies(db database.DBTX) ([]models.TodoDependency, error) {
+	rows, err := db.Query(`
+		SELECT d.todo_id, d.blocked_by
+		FROM todo_dependencies d
+		JOIN todos t ON t.id = d.todo_id
+		JOIN todos b ON b.id = d.blocked_by
+		WHERE t.deleted_at IS NULL AND b.deleted_at IS NULL
+		ORDER BY d.blocked_by, d.todo_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []models.TodoDependency
+	for rows.Next() {
+		var d models.TodoDependency
+		if err := rows.Scan(&d.TodoID, &d.BlockedBy); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+
+	return dependencies, rows.Err()
+}
+
+// Unblocked returns the open todos that were waiting on a todo and wait on
+// nothing else, as they are once it is complete.
+func Unblocked(db database.DBTX, id int) ([]models.Todo, error) {
+	rows, err := db.Query(`
+		SELECT t.id
+		FROM todo_dependencies d
+		JOIN todos t ON t.id = d.todo_id
+		WHERE d.blocked_by = ? AND t.is_complete = 0 AND t.deleted_at IS NULL
+		ORDER BY t.id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var todoID int
+		if err := rows.Scan(&todoID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, todoID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var todos []models.Todo
+	for _, todoID := range ids {
+		todo, err := GetTodoByID(db, todoID)
+		if err != nil {
+			return nil, err
+		}
+		if len(todo.BlockedBy) == 0 {
+			todos = append(todos, *todo)
+		}
+	}
+	return todos, nil
+}
+
+// attachBlockers fills in the open todos each todo waits on.
+func attachBlockers(db database.DBTX, todos []models.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT d.todo_id, d.blocked_by
+		FROM todo_dependencies d
+		JOIN todos b ON b.id = d.blocked_by
+		WHERE b.is_complete = 0 AND b.deleted_at IS NULL
+	`
+	var args []interface{}
+	if len(todos) == 1 {
+		query += " AND d.todo_id = ?"
+		args = append(args, todos[0].ID)
+	}
+
+	rows, err := db.Query(query+" ORDER BY d.blocked_by", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	blockers := make(map[int][]int)
+	for rows.Next() {
+		var todoID, blockedBy int
+		if err := rows.Scan(&todoID, &blockedBy); err != nil {
+			return err
+		}
+		blockers[todoID] = append(blockers[todoID], blockedBy)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range todos {
+		todos[i].BlockedBy = blockers[todos[i].ID]
+	}
+	return nil
+}
+
+// getDependencies returns every todo a todo depends on, open or not.
+func getDependencies(db database.DBTX, todoID int) ([]int, error) {
+	rows, err := db.Query("SELECT blocked_by FROM todo_dependencies WHERE todo_id = ? ORDER BY blocked_by", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func replaceDependencies(db database.DBTX, todoID int, blockedBy []int) error {
+	if _, err := db.Exec("DELETE FROM todo_dependencies WHERE todo_id = ?", todoID); err != nil {
+		return err
+	}
+
+	for _, id := range blockedBy {
+		if _, err := db.Exec("INSERT INTO todo_dependencies (todo_id, blocked_by) VALUES (?, ?)", todoID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
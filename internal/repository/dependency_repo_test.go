@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAddDependency(t *testing.T) {
+	db := setupTestDB(t)
+
+	var ids []int
+	for _, content := range []string{"Design API", "Build client", "Release"} {
+		todo, err := CreateTodo(db, content, []string{}, nil)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		ids = append(ids, todo.ID)
+	}
+
+	if err := AddDependency(db, ids[1], ids[0]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+	if err := AddDependency(db, ids[2], ids[1]); err != nil {
+		t.Fatalf("AddDependency() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		todo      int
+		blockedBy int
+	}{
+		{name: "itself", todo: ids[0], blockedBy: ids[0]},
+		{name: "direct cycle", todo: ids[0], blockedBy: ids[1]},
+		{name: "indirect cycle", todo: ids[0], blockedBy: ids[2]},
+		{name: "missing todo", todo: ids[0], blockedBy: 999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AddDependency(db, tt.todo, tt.blockedBy); err == nil {
+				t.Errorf("AddDependency(%d, %d) should fail", tt.todo, tt.blockedBy)
+			}
+		})
+	}
+
+	dependencies, err := ListDependencies(db)
+	if err != nil {
+		t.Fatalf("ListDependencies() error = %v", err)
+	}
+	if len(dependencies) != 2 {
+		t.Errorf("ListDependencies() = %+v, want the two dependencies added", dependencies)
+	}
+}
+
+func TestBlockedTodos(t *testing.T) {
+	db := setupTestDB(t)
+
+	design, err := CreateTodo(db, "Design API", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	docs, err := CreateTodo(db, "Write docs", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	release, err := CreateTodo(db, "Release", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	for _, blocker := range []int{design.ID, docs.ID} {
+		if err := AddDependency(db, release.ID, blocker); err != nil {
+			t.Fatalf("AddDependency() error = %v", err)
+		}
+	}
+
+	got, err := GetTodoByID(db, release.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if !slices.Equal(got.BlockedBy, []int{design.ID, docs.ID}) {
+		t.Errorf("BlockedBy = %v, want both blockers", got.BlockedBy)
+	}
+
+	actionable := func() []int {
+		t.Helper()
+		todos, err := ListTodos(db, TodoListOptions{Actionable: true})
+		if err != nil {
+			t.Fatalf("ListTodos() error = %v", err)
+		}
+		var ids []int
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	if ids := actionable(); slices.Contains(ids, release.ID) || len(ids) != 2 {
+		t.Errorf("actionable todos = %v, want the two blockers", ids)
+	}
+
+	if _, err := CompleteTodo(db, design.ID); err != nil {
+		t.Fatalf("CompleteTodo() error = %v", err)
+	}
+	if unblocked, err := Unblocked(db, design.ID); err != nil || len(unblocked) != 0 {
+		t.Errorf("Unblocked() = %v, %v, want nothing while docs is open", unblocked, err)
+	}
+
+	if _, err := CompleteTodo(db, docs.ID); err != nil {
+		t.Fatalf("CompleteTodo() error = %v", err)
+	}
+	unblocked, err := Unblocked(db, docs.ID)
+	if err != nil {
+		t.Fatalf("Unblocked() error = %v", err)
+	}
+	if len(unblocked) != 1 || unblocked[0].ID != release.ID {
+		t.Errorf("Unblocked() = %+v, want the release", unblocked)
+	}
+
+	if ids := actionable(); !slices.Equal(ids, []int{release.ID}) {
+		t.Errorf("actionable todos = %v, want the release once its blockers are done", ids)
+	}
+
+	if err := UncompleteTodo(db, docs.ID); err != nil {
+		t.Fatalf("UncompleteTodo() error = %v", err)
+	}
+	got, err = GetTodoByID(db, release.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if !slices.Equal(got.BlockedBy, []int{docs.ID}) {
+		t.Errorf("BlockedBy = %v, want the reopened blocker", got.BlockedBy)
+	}
+}
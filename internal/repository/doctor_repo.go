@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// integrityCheck finds one kind of inconsistency and knows how to repair it.
+// Checks run in order and each repair is visible to the checks after it, so
+// for example tags freed by removing orphaned join rows are reported as
+// unused in the same run.
+type integrityCheck struct {
+	name   string
+	find   func(db database.DBTX) ([]string, error)
+	repair func(db database.DBTX) error
+}
+
+var integrityChecks = []integrityCheck{
+	orphanJoinCheck("note_tags", "note_id", "notes", "note"),
+	orphanJoinCheck("todo_tags", "todo_id", "todos", "todo"),
+	orphanJoinCheck("project_tags", "project_id", "projects", "project"),
+	{
+		name: "links from missing items",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT printf('link #%d comes from missing %s #%d', id, source_kind, source_id)
+				FROM links
+				WHERE (source_kind = 'note' AND source_id NOT IN (SELECT id FROM notes))
+					OR (source_kind = 'todo' AND source_id NOT IN (SELECT id FROM todos))
+				ORDER BY id
+			`)
+		},
+		repair: pruneLinks,
+	},
+	{
+		name: "attachments on missing items",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT printf('attachment #%d (%s) belongs to missing %s #%d', id, name, owner_kind, owner_id)
+				FROM attachments
+				WHERE (owner_kind = 'note' AND owner_id NOT IN (SELECT id FROM notes))
+					OR (owner_kind = 'todo' AND owner_id NOT IN (SELECT id FROM todos))
+				ORDER BY id
+			`)
+		},
+		repair: pruneAttachments,
+	},
+	{
+		name: "unused tags",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT printf('tag #%d #%s is not used by anything', id, name)
+				FROM tags
+				WHERE id NOT IN (SELECT tag_id FROM note_tags)
+					AND id NOT IN (SELECT tag_id FROM todo_tags)
+					AND id NOT IN (SELECT tag_id FROM project_tags)
+					AND name NOT IN (SELECT name FROM projects)
+				ORDER BY id
+			`)
+		},
+		repair: func(db database.DBTX) error {
+			_, err := db.Exec(`
+				DELETE FROM tags
+				WHERE id NOT IN (SELECT tag_id FROM note_tags)
+					AND id NOT IN (SELECT tag_id FROM todo_tags)
+					AND id NOT IN (SELECT tag_id FROM project_tags)
+					AND name NOT IN (SELECT name FROM projects)
+			`)
+			return err
+		},
+	},
+	{
+		name: "home project",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT 'the home project is missing'
+				WHERE NOT EXISTS (SELECT 1 FROM projects WHERE name = 'home')
+				UNION ALL
+				SELECT 'the home project is in the trash'
+				FROM projects WHERE name = 'home' AND deleted_at IS NOT NULL
+			`)
+		},
+		repair: func(db database.DBTX) error {
+			_, err := db.Exec(`
+				INSERT INTO projects (name, created_at, first_activated_at, is_closed)
+				SELECT 'home', ?, ?, 0
+				WHERE NOT EXISTS (SELECT 1 FROM projects WHERE name = 'home')
+			`, time.Now(), time.Now())
+			if err != nil {
+				return err
+			}
+
+			_, err = db.Exec("UPDATE projects SET deleted_at = NULL WHERE name = 'home'")
+			return err
+		},
+	},
+	{
+		name: "active project",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT 'no project is active'
+				WHERE NOT EXISTS (SELECT 1 FROM active_project)
+				UNION ALL
+				SELECT printf('%d projects are marked active', COUNT(*))
+				FROM active_project HAVING COUNT(*) > 1
+				UNION ALL
+				SELECT printf('active project #%d does not exist or is in the trash', ap.project_id)
+				FROM active_project ap
+				LEFT JOIN projects p ON p.id = ap.project_id AND p.deleted_at IS NULL
+				WHERE p.id IS NULL
+			`)
+		},
+		repair: repairActiveProject,
+	},
+	{
+		name: "todos in deleted projects",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT printf('todo #%d is tagged with deleted project #%s', td.id, p.name)
+				FROM todos td
+				JOIN todo_tags tt ON tt.todo_id = td.id
+				JOIN tags tg ON tg.id = tt.tag_id
+				JOIN projects p ON p.name = tg.name
+				WHERE td.deleted_at IS NULL AND p.deleted_at IS NOT NULL
+				ORDER BY td.id
+			`)
+		},
+		repair: repairTodosInDeletedProjects,
+	},
+}
+
+func CheckIntegrity(db database.DBTX, repair bool) ([]models.IntegrityCheck, error) {
+	var results []models.IntegrityCheck
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		results = nil
+		for _, check := range integrityChecks {
+			problems, err := check.find(tx)
+			if err != nil {
+				return fmt.Errorf("checking %s: %w", check.name, err)
+			}
+
+			result := models.IntegrityCheck{Name: check.name, Problems: problems}
+
+			if repair && len(problems) > 0 {
+				if err := check.repair(tx); err != nil {
+					return fmt.Errorf("repairing %s: %w", check.name, err)
+				}
+				result.Repaired = true
+			}
+
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func orphanJoinCheck(table, column, parentTable, kind string) integrityCheck {
+	return integrityCheck{
+		name: "orphaned " + kind + " tags",
+		find: func(db database.DBTX) ([]string, error) {
+			return queryProblems(db, `
+				SELECT printf('`+kind+` #%d -> tag #%d', j.`+column+`, j.tag_id)
+				FROM `+table+` j
+				WHERE j.`+column+` NOT IN (SELECT id FROM `+parentTable+`)
+					OR j.tag_id NOT IN (SELECT id FROM tags)
+				ORDER BY j.`+column+`, j.tag_id
+			`)
+		},
+		repair: func(db database.DBTX) error {
+			_, err := db.Exec(`
+				DELETE FROM ` + table + `
+				WHERE ` + column + ` NOT IN (SELECT id FROM ` + parentTable + `)
+					OR tag_id NOT IN (SELECT id FROM tags)
+			`)
+			return err
+		},
+	}
+}
+
+// repairActiveProject keeps the most recently activated project that still
+// exists and is open, falling back to home.
+func repairActiveProject(db database.DBTX) error {
+	var projectID int
+	err := db.QueryRow(`
+		SELECT p.id
+		FROM active_project ap
+		JOIN projects p ON p.id = ap.project_id
+		WHERE p.deleted_at IS NULL AND p.is_closed = 0
+		ORDER BY ap.activated_at DESC
+		LIMIT 1
+	`).Scan(&projectID)
+	if err != nil {
+		home, homeErr := GetProjectByName(db, "home")
+		if homeErr != nil {
+			return homeErr
+		}
+		projectID = home.ID
+	}
+
+	return SetActiveProject(db, projectID)
+}
+
+// repairTodosInDeletedProjects moves todos that still carry the tag of a
+// trashed project into home, so they show up in project status again.
+func repairTodosInDeletedProjects(db database.DBTX) error {
+	rows, err := db.Query(`
+		SELECT tt.todo_id, tt.tag_id
+		FROM todos td
+		JOIN todo_tags tt ON tt.todo_id = td.id
+		JOIN tags tg ON tg.id = tt.tag_id
+		JOIN projects p ON p.name = tg.name
+		WHERE td.deleted_at IS NULL AND p.deleted_at IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	type todoTag struct{ todoID, tagID int }
+	var stale []todoTag
+	for rows.Next() {
+		var tt todoTag
+		if err := rows.Scan(&tt.todoID, &tt.tagID); err != nil {
+			rows.Close()
+			return err
+		}
+		stale = append(stale, tt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, tt := range stale {
+		if _, err := db.Exec("DELETE FROM todo_tags WHERE todo_id = ? AND tag_id = ?", tt.todoID, tt.tagID); err != nil {
+			return err
+		}
+		if err := AddTagsToTodo(db, tt.todoID, []string{"home"}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func queryProblems(db database.DBTX, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, err
+		}
+		problems = append(problems, problem)
+	}
+
+	return problems, rows.Err()
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// setupCorruptibleDB returns a test database with foreign keys switched off,
+// as they were before enforcement was enabled, so inconsistencies can be
+// created directly.
+func setupCorruptibleDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := setupTestDB(t)
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("Failed to disable foreign keys: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO projects (name, created_at, first_activated_at, is_closed) VALUES ('home', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 0);
+		INSERT INTO active_project (project_id) SELECT id FROM projects WHERE name = 'home';
+	`); err != nil {
+		t.Fatalf("Failed to create home project: %v", err)
+	}
+
+	return db
+}
+
+func findCheck(t *testing.T, checks []models.IntegrityCheck, name string) models.IntegrityCheck {
+	t.Helper()
+
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("Check %q not found", name)
+	return models.IntegrityCheck{}
+}
+
+func countProblems(checks []models.IntegrityCheck) int {
+	total := 0
+	for _, check := range checks {
+		total += len(check.Problems)
+	}
+	return total
+}
+
+func TestCheckIntegrityHealthyDatabase(t *testing.T) {
+	db := setupCorruptibleDB(t)
+
+	if _, err := CreateNote(db, "Fine", []string{"home"}, false); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	checks, err := CheckIntegrity(db, false)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+
+	if n := countProblems(checks); n != 0 {
+		t.Errorf("Expected no problems, got %d: %+v", n, checks)
+	}
+}
+
+func TestCheckIntegrityFindsAndRepairsOrphans(t *testing.T) {
+	db := setupCorruptibleDB(t)
+
+	note, err := CreateNote(db, "Doomed", []string{"orphaned"}, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	// A hard delete without cascades, as older versions performed.
+	if _, err := db.Exec("DELETE FROM notes WHERE id = ?", note.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	checks, err := CheckIntegrity(db, false)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+
+	orphans := findCheck(t, checks, "orphaned note tags")
+	if len(orphans.Problems) != 1 || orphans.Repaired {
+		t.Errorf("orphaned note tags = %+v, want 1 unrepaired problem", orphans)
+	}
+
+	checks, err = CheckIntegrity(db, true)
+	if err != nil {
+		t.Fatalf("CheckIntegrity(repair) error = %v", err)
+	}
+
+	// Removing the orphaned link leaves the tag unused, which the same run
+	// picks up.
+	if unused := findCheck(t, checks, "unused tags"); len(unused.Problems) != 1 || !unused.Repaired {
+		t.Errorf("unused tags = %+v, want 1 repaired problem", unused)
+	}
+
+	checks, err = CheckIntegrity(db, false)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+	if n := countProblems(checks); n != 0 {
+		t.Errorf("Expected no problems after repair, got %d: %+v", n, checks)
+	}
+}
+
+func TestCheckIntegrityRepairsLinksFromMissingItems(t *testing.T) {
+	db := setupCorruptibleDB(t)
+
+	note, err := CreateNote(db, "Points at [[#1]]", []string{"home"}, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	if _, err := db.Exec("DELETE FROM notes WHERE id = ?", note.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	checks, err := CheckIntegrity(db, true)
+	if err != nil {
+		t.Fatalf("CheckIntegrity(repair) error = %v", err)
+	}
+
+	if links := findCheck(t, checks, "links from missing items"); len(links.Problems) != 1 || !links.Repaired {
+		t.Errorf("links from missing items = %+v, want 1 repaired problem", links)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM links").Scan(&count); err != nil {
+		t.Fatalf("Failed to count links: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("links after repair = %d, want 0", count)
+	}
+}
+
+func TestCheckIntegrityRepairsActiveProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt string
+	}{
+		{name: "missing", corrupt: "DELETE FROM active_project"},
+		{name: "duplicate", corrupt: "INSERT INTO active_project (project_id) VALUES (999)"},
+		{name: "dangling", corrupt: "UPDATE active_project SET project_id = 999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupCorruptibleDB(t)
+
+			if _, err := db.Exec(tt.corrupt); err != nil {
+				t.Fatalf("Corrupt failed: %v", err)
+			}
+
+			checks, err := CheckIntegrity(db, true)
+			if err != nil {
+				t.Fatalf("CheckIntegrity() error = %v", err)
+			}
+			if check := findCheck(t, checks, "active project"); len(check.Problems) == 0 {
+				t.Error("Expected an active project problem")
+			}
+
+			active, err := GetActiveProject(db)
+			if err != nil {
+				t.Fatalf("GetActiveProject() error = %v", err)
+			}
+			if active.Name != "home" {
+				t.Errorf("Active project = %s, want home", active.Name)
+			}
+
+			var rows int
+			db.QueryRow("SELECT COUNT(*) FROM active_project").Scan(&rows)
+			if rows != 1 {
+				t.Errorf("active_project rows = %d, want 1", rows)
+			}
+		})
+	}
+}
+
+func TestCheckIntegrityRestoresHomeProject(t *testing.T) {
+	db := setupCorruptibleDB(t)
+
+	if _, err := db.Exec("DELETE FROM projects WHERE name = 'home'"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	checks, err := CheckIntegrity(db, true)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+	if check := findCheck(t, checks, "home project"); len(check.Problems) != 1 {
+		t.Errorf("home project = %+v, want 1 problem", check)
+	}
+
+	if _, err := GetProjectByName(db, "home"); err != nil {
+		t.Errorf("Expected home project to be recreated: %v", err)
+	}
+}
+
+func TestCheckIntegrityMovesTodosOutOfDeletedProjects(t *testing.T) {
+	db := setupCorruptibleDB(t)
+
+	if _, err := CreateProject(db, "side", nil); err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	todo, err := CreateTodo(db, "Loose end", []string{"side", "misc"}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+	if err := DeleteProject(db, "side"); err != nil {
+		t.Fatalf("DeleteProject() error = %v", err)
+	}
+
+	checks, err := CheckIntegrity(db, true)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() error = %v", err)
+	}
+	if check := findCheck(t, checks, "todos in deleted projects"); len(check.Problems) != 1 {
+		t.Errorf("todos in deleted projects = %+v, want 1 problem", check)
+	}
+
+	got, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "home" || got.Tags[1] != "misc" {
+		t.Errorf("Tags = %v, want [home misc]", got.Tags)
+	}
+}
+
+func TestForeignKeysCascade(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Tagged", []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	if _, err := db.Exec("DELETE FROM notes WHERE id = ?", note.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	var links int
+	db.QueryRow("SELECT COUNT(*) FROM note_tags WHERE note_id = ?", note.ID).Scan(&links)
+	if links != 0 {
+		t.Errorf("Expected note_tags to cascade, found %d rows", links)
+	}
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/vault"
+)
+
+// An encrypted database stores note, todo and revision content sealed with a
+// key derived from the user's passphrase. Tags, dates and flags stay in
+// plaintext so listing and filtering work without the key. Once Unlock
+// succeeds the key is held in memory for the rest of the process, and every
+// read and write of content goes through openContent and sealContent.
+//
+// Undo snapshots copy the content column as stored, so they are sealed too.
+
+// checkPlaintext is sealed into the settings row so that a wrong passphrase is
+// reported as such instead of surfacing as garbled content.
+const checkPlaintext = "note"
+
+var contentCipher *vault.Cipher
+
+var ErrLocked = errors.New("the database is encrypted and has not been unlocked")
+
+// contentColumns are the columns holding content, sealed when the database is
+// encrypted.
+var contentColumns = []struct{ table, column string }{
+	{"notes", "content"},
+	{"todos", "content"},
+	{"revisions", "content"},
+	{"activity", "summary"},
+	{"activity", "before"},
+	{"activity", "after"},
+}
+
+func Unlock(db database.DBTX, passphrase string) error {
+	var salt []byte
+	var params vault.Params
+	var check string
+	err := db.QueryRow(`
+		SELECT salt, time_cost, memory_kib, threads, check_value
+		FROM encryption
+	`).Scan(&salt, &params.Time, &params.MemoryKiB, &params.Threads, &check)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("the database is not encrypted")
+	}
+	if err != nil {
+		return err
+	}
+
+	c, err := vault.NewCipher(vault.DeriveKey(passphrase, salt, params))
+	if err != nil {
+		return err
+	}
+
+	if plaintext, err := c.Open(check); err != nil || plaintext != checkPlaintext {
+		return fmt.Errorf("incorrect passphrase")
+	}
+
+	contentCipher = c
+
+	// Activity notes sealed before migration 11 could not be moved to the
+	// activity log then, so they are moved now that they can be read.
+	return database.MoveSealedActivityNotes(db, func(content string) (string, error) {
+		err := openContent(&content)
+		return content, err
+	}, sealContent)
+}
+
+// Lock forgets the key, after which encrypted content can no longer be read.
+func Lock() {
+	contentCipher = nil
+}
+
+// EncryptContent seals all existing content under a new key derived from
+// passphrase and leaves the database unlocked with it. The undo history and
+// the search index are removed, since both hold plaintext.
+func EncryptContent(db database.DBTX, passphrase string) error {
+	encrypted, err := database.Encrypted(db)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return fmt.Errorf("the database is already encrypted")
+	}
+
+	var attachments int
+	if err := db.QueryRow("SELECT COUNT(*) FROM attachments").Scan(&attachments); err != nil {
+		return err
+	}
+	if attachments > 0 {
+		return fmt.Errorf("the database has %d attachment(s), which cannot be encrypted; detach them first", attachments)
+	}
+
+	salt, err := vault.NewSalt()
+	if err != nil {
+		return err
+	}
+
+	params := vault.DefaultParams
+	c, err := vault.NewCipher(vault.DeriveKey(passphrase, salt, params))
+	if err != nil {
+		return err
+	}
+
+	check, err := c.Seal(checkPlaintext)
+	if err != nil {
+		return err
+	}
+
+	err = database.WithTx(db, func(tx database.DBTX) error {
+		_, err := tx.Exec(`
+			INSERT INTO encryption (kdf, salt, time_cost, memory_kib, threads, check_value)
+			VALUES ('argon2id', ?, ?, ?, ?, ?)
+		`, salt, params.Time, params.MemoryKiB, params.Threads, check)
+		if err != nil {
+			return err
+		}
+
+		if err := database.DropSearchIndex(tx); err != nil {
+			return err
+		}
+
+		if err := rewriteContent(tx, func(content string) (string, error) {
+			if vault.IsSealed(content) {
+				return content, nil
+			}
+			return c.Seal(content)
+		}); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("DELETE FROM operations")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	contentCipher = c
+	return nil
+}
+
+// DecryptContent stores all content in plaintext again and removes the
+// encryption settings. The database must be unlocked. The undo history is
+// removed, since its snapshots hold sealed content.
+func DecryptContent(db database.DBTX) error {
+	if contentCipher == nil {
+		return ErrLocked
+	}
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		if err := rewriteContent(tx, func(content string) (string, error) {
+			if err := openContent(&content); err != nil {
+				return "", err
+			}
+			return content, nil
+		}); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM operations"); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("DELETE FROM encryption")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	Lock()
+	return nil
+}
+
+func rewriteContent(db database.DBTX, transform func(string) (string, error)) error {
+	for _, c := range contentColumns {
+		rows, err := db.Query("SELECT id, " + c.column + " FROM " + c.table + " WHERE " + c.column + " IS NOT NULL")
+		if err != nil {
+			return err
+		}
+
+		contents := map[int]string{}
+		for rows.Next() {
+			var id int
+			var content string
+			if err := rows.Scan(&id, &content); err != nil {
+				rows.Close()
+				return err
+			}
+			contents[id] = content
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for id, content := range contents {
+			rewritten, err := transform(content)
+			if err != nil {
+				return fmt.Errorf("%s #%d: %w", c.table, id, err)
+			}
+			if _, err := db.Exec("UPDATE "+c.table+" SET "+c.column+" = ? WHERE id = ?", rewritten, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// sealContent prepares content for storage, encrypting it when the database
+// has been unlocked.
+func sealContent(content string) (string, error) {
+	if contentCipher == nil {
+		return content, nil
+	}
+	return contentCipher.Seal(content)
+}
+
+// openContent decrypts stored content in place. Plaintext is left alone, so
+// callers need not know whether the database is encrypted.
+func openContent(content *string) error {
+	if !vault.IsSealed(*content) {
+		return nil
+	}
+
+	if contentCipher == nil {
+		return ErrLocked
+	}
+
+	plaintext, err := contentCipher.Open(*content)
+	if err != nil {
+		return err
+	}
+
+	*content = plaintext
+	return nil
+}
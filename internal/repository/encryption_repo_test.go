@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/vault"
+)
+
+func encryptTestDB(t *testing.T, db database.DBTX, passphrase string) {
+	t.Helper()
+
+	if err := EncryptContent(db, passphrase); err != nil {
+		t.Fatalf("EncryptContent() error = %v", err)
+	}
+	t.Cleanup(Lock)
+}
+
+func storedContent(t *testing.T, db database.DBTX, table string, id int) string {
+	t.Helper()
+
+	var content string
+	if err := db.QueryRow("SELECT content FROM "+table+" WHERE id = ?", id).Scan(&content); err != nil {
+		t.Fatalf("Failed to read %s #%d: %v", table, id, err)
+	}
+	return content
+}
+
+func TestEncryptContent(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Salary review", []string{"hr"}, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	edited := "Salary review, round two"
+	if err := UpdateNote(db, note.ID, &edited, nil, nil); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+	todo, err := CreateTodo(db, "Draft offer letter", []string{"hr"}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	encryptTestDB(t, db, "correct horse")
+
+	if stored := storedContent(t, db, "notes", note.ID); !vault.IsSealed(stored) {
+		t.Errorf("note content stored as %q, want it sealed", stored)
+	}
+	if stored := storedContent(t, db, "todos", todo.ID); !vault.IsSealed(stored) {
+		t.Errorf("todo content stored as %q, want it sealed", stored)
+	}
+
+	var plaintextRevisions int
+	if err := db.QueryRow("SELECT COUNT(*) FROM revisions WHERE content NOT LIKE '$note-enc-v1$%'").Scan(&plaintextRevisions); err != nil {
+		t.Fatalf("Count revisions failed: %v", err)
+	}
+	if plaintextRevisions != 0 {
+		t.Errorf("%d revisions still stored in plaintext", plaintextRevisions)
+	}
+
+	notes, err := ListNotes(db, NoteListOptions{Tags: []string{"hr"}})
+	if err != nil {
+		t.Fatalf("ListNotes() error = %v", err)
+	}
+	if len(notes) != 1 || notes[0].Content != edited {
+		t.Errorf("ListNotes() = %+v, want the decrypted note", notes)
+	}
+
+	revisions, err := ListRevisions(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "Salary review" {
+		t.Errorf("ListRevisions() = %+v, want both revisions decrypted", revisions)
+	}
+
+	if _, err := Search(db, "salary", SearchOptions{}); !errors.Is(err, ErrSearchEncrypted) {
+		t.Errorf("Search() error = %v, want ErrSearchEncrypted", err)
+	}
+
+	if err := EncryptContent(db, "again"); err == nil {
+		t.Error("EncryptContent() on an encrypted database expected error, got nil")
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Board minutes", nil, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	encryptTestDB(t, db, "correct horse")
+	Lock()
+
+	if _, err := GetNoteByID(db, note.ID); !errors.Is(err, ErrLocked) {
+		t.Errorf("GetNoteByID() while locked error = %v, want ErrLocked", err)
+	}
+
+	if err := Unlock(db, "wrong horse"); err == nil {
+		t.Error("Unlock() with wrong passphrase expected error, got nil")
+	}
+
+	if err := Unlock(db, "correct horse"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	got, err := GetNoteByID(db, note.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID() error = %v", err)
+	}
+	if got.Content != "Board minutes" {
+		t.Errorf("Content = %q, want %q", got.Content, "Board minutes")
+	}
+}
+
+func TestUnlock_MovesSealedActivityNotes(t *testing.T) {
+	db := setupTestDB(t)
+
+	todo, err := CreateTodo(db, "Ship it", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	encryptTestDB(t, db, "correct horse")
+
+	// An activity note written before migration 11, which could not read it.
+	note, err := CreateNote(db, "Created todo: Ship it", []string{"todo", "create"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := db.Exec("UPDATE notes SET created_at = '2025-11-01 09:00:00' WHERE id = ?", note.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	Lock()
+
+	if err := Unlock(db, "correct horse"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if _, err := GetNoteByID(db, note.ID); err == nil {
+		t.Error("activity note still exists after Unlock()")
+	}
+
+	entries, err := ListActivity(db, ActivityListOptions{Kind: "todo", EntityID: todo.ID, Event: "created"})
+	if err != nil {
+		t.Fatalf("ListActivity() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Summary != "Ship it" {
+		t.Errorf("ListActivity() = %+v, want the moved note", entries)
+	}
+}
+
+func TestDecryptContent(t *testing.T) {
+	db := setupTestDB(t)
+
+	encryptTestDB(t, db, "correct horse")
+
+	note, err := CreateNote(db, "Written while encrypted", nil, false)
+	if err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	if stored := storedContent(t, db, "notes", note.ID); !vault.IsSealed(stored) {
+		t.Fatalf("note content stored as %q, want it sealed", stored)
+	}
+
+	if err := DecryptContent(db); err != nil {
+		t.Fatalf("DecryptContent() error = %v", err)
+	}
+
+	if stored := storedContent(t, db, "notes", note.ID); stored != "Written while encrypted" {
+		t.Errorf("note content stored as %q, want plaintext", stored)
+	}
+
+	encrypted, err := database.Encrypted(db)
+	if err != nil {
+		t.Fatalf("Encrypted() error = %v", err)
+	}
+	if encrypted {
+		t.Error("Encrypted() = true after DecryptContent()")
+	}
+}
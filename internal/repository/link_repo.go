@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/links"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Links are derived from content: every save of a note or todo replaces the
+// links recorded for it with the references in its new content. Links from
+// items in the trash are kept, so restoring an item restores its links, but
+// they are hidden until then.
+
+// linkQuery resolves each link to its source and target. A target that is
+// missing or in the trash leaves the target title NULL.
+const linkQuery = `
+	SELECT l.source_kind, l.source_id, COALESCE(sn.content, st.content),
+		l.target_kind, l.target_ref, COALESCE(tn.content, tt.content, tp.name)
+	FROM links l
+	LEFT JOIN notes sn ON l.source_kind = 'note' AND sn.id = l.source_id
+	LEFT JOIN todos st ON l.source_kind = 'todo' AND st.id = l.source_id
+	LEFT JOIN notes tn ON l.target_kind = 'note' AND tn.id = CAST(l.target_ref AS INTEGER) AND tn.deleted_at IS NULL
+	LEFT JOIN todos tt ON l.target_kind = 'todo' AND tt.id = CAST(l.target_ref AS INTEGER) AND tt.deleted_at IS NULL
+	LEFT JOIN projects tp ON l.target_kind = 'project' AND tp.name = l.target_ref AND tp.deleted_at IS NULL
+	WHERE COALESCE(sn.deleted_at, st.deleted_at) IS NULL
+		AND COALESCE(sn.id, st.id) IS NOT NULL
+`
+
+// GetLinks returns the links written in a note or todo.
+func GetLinks(db database.DBTX, kind string, id int) ([]models.Link, error) {
+	return queryLinks(db, linkQuery+`
+		AND l.source_kind = ? AND l.source_id = ?
+		ORDER BY l.id
+	`, kind, id)
+}
+
+// GetBacklinks returns the links that point at an item. ref is the ID of a
+// note or todo, or the name of a project.
+func GetBacklinks(db database.DBTX, kind string, ref string) ([]models.Link, error) {
+	return queryLinks(db, linkQuery+`
+		AND l.target_kind = ? AND l.target_ref = ?
+		ORDER BY l.source_kind, l.source_id
+	`, kind, ref)
+}
+
+// ListLinks returns every link, or with brokenOnly just those whose target
+// is missing or in the trash.
+func ListLinks(db database.DBTX, brokenOnly bool) ([]models.Link, error) {
+	query := linkQuery
+	if brokenOnly {
+		query += " AND COALESCE(tn.id, tt.id, tp.id) IS NULL"
+	}
+	query += " ORDER BY l.source_kind, l.source_id, l.id"
+
+	return queryLinks(db, query)
+}
+
+func queryLinks(db database.DBTX, query string, args ...interface{}) ([]models.Link, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Link
+	for rows.Next() {
+		var link models.Link
+		var targetTitle sql.NullString
+		if err := rows.Scan(&link.SourceKind, &link.SourceID, &link.SourceTitle,
+			&link.TargetKind, &link.TargetRef, &targetTitle); err != nil {
+			return nil, err
+		}
+
+		if err := openContent(&link.SourceTitle); err != nil {
+			return nil, err
+		}
+
+		link.Broken = !targetTitle.Valid
+		link.TargetTitle = targetTitle.String
+		if err := openContent(&link.TargetTitle); err != nil {
+			return nil, err
+		}
+
+		result = append(result, link)
+	}
+
+	return result, rows.Err()
+}
+
+// syncLinks replaces the links recorded for a note or todo with the
+// references in its content.
+func syncLinks(db database.DBTX, kind string, id int, content string) error {
+	if err := deleteLinks(db, kind, id); err != nil {
+		return err
+	}
+
+	for _, target := range links.Parse(content) {
+		_, err := db.Exec(`
+			INSERT INTO links (source_kind, source_id, target_kind, target_ref)
+			VALUES (?, ?, ?, ?)
+		`, kind, id, target.Kind, target.Ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteLinks(db database.DBTX, kind string, id int) error {
+	_, err := db.Exec("DELETE FROM links WHERE source_kind = ? AND source_id = ?", kind, id)
+	return err
+}
+
+// pruneLinks removes links whose source no longer exists at all.
+func pruneLinks(db database.DBTX) error {
+	_, err := db.Exec(`
+		DELETE FROM links
+		WHERE (source_kind = 'note' AND source_id NOT IN (SELECT id FROM notes))
+			OR (source_kind = 'todo' AND source_id NOT IN (SELECT id FROM todos))
+	`)
+	return err
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinks_SyncAndBacklinks(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := CreateNote(db, "Planning doc", []string{"work"}, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	todo, err := CreateTodo(db, "Ship it, see [[#1]]", []string{"work"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	source, err := CreateNote(db, "Retro: [[note:1]] [[todo:1]] [[project:missing]]", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	outgoing, err := GetLinks(db, "note", source.ID)
+	if err != nil {
+		t.Fatalf("GetLinks() error = %v", err)
+	}
+	if len(outgoing) != 3 {
+		t.Fatalf("GetLinks() returned %d links, want 3", len(outgoing))
+	}
+	if outgoing[0].TargetTitle != "Planning doc" || outgoing[0].Broken {
+		t.Errorf("GetLinks()[0] = %+v, want a resolved link to note 1", outgoing[0])
+	}
+	if outgoing[1].TargetKind != "todo" || outgoing[1].TargetRef != "1" || outgoing[1].Broken {
+		t.Errorf("GetLinks()[1] = %+v, want a resolved link to todo 1", outgoing[1])
+	}
+	if !outgoing[2].Broken {
+		t.Errorf("GetLinks()[2] = %+v, want a broken project link", outgoing[2])
+	}
+
+	backlinks, err := GetBacklinks(db, "note", "1")
+	if err != nil {
+		t.Fatalf("GetBacklinks() error = %v", err)
+	}
+	if len(backlinks) != 2 {
+		t.Fatalf("GetBacklinks() returned %d links, want 2", len(backlinks))
+	}
+	if backlinks[0].SourceKind != "note" || backlinks[0].SourceID != source.ID {
+		t.Errorf("GetBacklinks()[0] = %+v, want note %d", backlinks[0], source.ID)
+	}
+	if backlinks[1].SourceKind != "todo" || backlinks[1].SourceID != todo.ID {
+		t.Errorf("GetBacklinks()[1] = %+v, want todo %d", backlinks[1], todo.ID)
+	}
+
+	// Editing the content replaces its links.
+	content := "Retro, no references"
+	if err := UpdateNote(db, source.ID, &content, nil, nil); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+
+	outgoing, err = GetLinks(db, "note", source.ID)
+	if err != nil {
+		t.Fatalf("GetLinks() error = %v", err)
+	}
+	if len(outgoing) != 0 {
+		t.Errorf("GetLinks() after edit returned %d links, want 0", len(outgoing))
+	}
+
+	backlinks, err = GetBacklinks(db, "note", "1")
+	if err != nil {
+		t.Fatalf("GetBacklinks() error = %v", err)
+	}
+	if len(backlinks) != 1 {
+		t.Errorf("GetBacklinks() after edit returned %d links, want 1", len(backlinks))
+	}
+
+}
+
+func TestLinks_BrokenAfterDelete(t *testing.T) {
+	db := setupTestDB(t)
+
+	target, err := CreateNote(db, "Target", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := CreateNote(db, "Points at [[#1]]", []string{"work"}, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	broken, err := ListLinks(db, true)
+	if err != nil {
+		t.Fatalf("ListLinks() error = %v", err)
+	}
+	if len(broken) != 0 {
+		t.Fatalf("ListLinks(broken) returned %d links, want 0", len(broken))
+	}
+
+	if err := DeleteNote(db, target.ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+
+	broken, err = ListLinks(db, true)
+	if err != nil {
+		t.Fatalf("ListLinks() error = %v", err)
+	}
+	if len(broken) != 1 || broken[0].TargetRef != "1" {
+		t.Fatalf("ListLinks(broken) = %+v, want the link to note 1", broken)
+	}
+
+	if err := RestoreNote(db, target.ID); err != nil {
+		t.Fatalf("RestoreNote() error = %v", err)
+	}
+
+	broken, err = ListLinks(db, true)
+	if err != nil {
+		t.Fatalf("ListLinks() error = %v", err)
+	}
+	if len(broken) != 0 {
+		t.Errorf("ListLinks(broken) after restore returned %d links, want 0", len(broken))
+	}
+}
+
+func TestLinks_HiddenAndPrunedWithSource(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := CreateNote(db, "Target", []string{"work"}, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	source, err := CreateNote(db, "Points at [[#1]]", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteNote(db, source.ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+
+	backlinks, err := GetBacklinks(db, "note", "1")
+	if err != nil {
+		t.Fatalf("GetBacklinks() error = %v", err)
+	}
+	if len(backlinks) != 0 {
+		t.Errorf("GetBacklinks() returned %d links from a trashed note, want 0", len(backlinks))
+	}
+
+	if _, err := EmptyTrash(db, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM links").Scan(&count); err != nil {
+		t.Fatalf("Failed to count links: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("links after EmptyTrash = %d, want 0", count)
+	}
+}
@@ -6,38 +6,55 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nathan-nicholson/note/internal/database"
 	"github.com/nathan-nicholson/note/internal/models"
 )
 
-func CreateNote(db *sql.DB, content string, tags []string, isImportant bool) (*models.Note, error) {
-	now := time.Now()
-	result, err := db.Exec(`
-		INSERT INTO notes (content, is_important, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
-	`, content, isImportant, now, now)
+func CreateNote(db database.DBTX, content string, tags []string, isImportant bool) (*models.Note, error) {
+	stored, err := sealContent(content)
 	if err != nil {
 		return nil, err
 	}
 
-	noteID, err := result.LastInsertId()
-	if err != nil {
-		return nil, err
-	}
+	var note *models.Note
 
-	if err := AddTagsToNote(db, int(noteID), tags); err != nil {
-		return nil, err
-	}
+	err = database.WithTx(db, func(tx database.DBTX) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO notes (content, is_important, created_at, updated_at)
+			VALUES (?, ?, ?, ?)
+		`, stored, isImportant, now, now)
+		if err != nil {
+			return err
+		}
 
-	return GetNoteByID(db, int(noteID))
+		noteID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := AddTagsToNote(tx, int(noteID), tags); err != nil {
+			return err
+		}
+
+		if err := syncLinks(tx, "note", int(noteID), content); err != nil {
+			return err
+		}
+
+		note, err = GetNoteByID(tx, int(noteID))
+		return err
+	})
+
+	return note, err
 }
 
-func GetNoteByID(db *sql.DB, id int) (*models.Note, error) {
+func GetNoteByID(db database.DBTX, id int) (*models.Note, error) {
 	var note models.Note
 	err := db.QueryRow(`
-		SELECT id, content, created_at, updated_at, is_important
+		SELECT id, content, created_at, updated_at, is_important, COALESCE(todo_id, 0)
 		FROM notes
-		WHERE id = ?
-	`, id).Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.IsImportant)
+		WHERE id = ? AND deleted_at IS NULL
+	`, id).Scan(&note.ID, &note.Content, &note.CreatedAt, &note.UpdatedAt, &note.IsImportant, &note.TodoID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -46,29 +63,52 @@ func GetNoteByID(db *sql.DB, id int) (*models.Note, error) {
 		return nil, err
 	}
 
+	if err := openContent(&note.Content); err != nil {
+		return nil, err
+	}
+
 	tags, err := GetTagsForNote(db, id)
 	if err != nil {
 		return nil, err
 	}
 	note.Tags = tags
 
+	attachments, err := ListAttachments(db, "note", id)
+	if err != nil {
+		return nil, err
+	}
+	note.Attachments = attachments
+
 	return &note, nil
 }
 
 type NoteListOptions struct {
-	StartDate   *time.Time
-	EndDate     *time.Time
-	Tags        []string
-	Important   bool
+	StartDate *time.Time
+	EndDate   *time.Time
+	Tags      []string
+	Important bool
+
+	// Sort is one of created (the default), updated or important.
+	Sort    string
+	Reverse bool
+	Limit   int
+	Offset  int
+	// AfterID continues a listing after the note with this ID.
+	AfterID int
 }
 
-func ListNotes(db *sql.DB, opts NoteListOptions) ([]models.Note, error) {
+func ListNotes(db database.DBTX, opts NoteListOptions) ([]models.Note, error) {
+	sortKeys, err := resolveSort(noteSorts, opts.Sort, "created", "n.id", opts.Reverse)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT DISTINCT n.id, n.content, n.created_at, n.updated_at, n.is_important
 		FROM notes n
 	`
 
-	var conditions []string
+	conditions := []string{"n.deleted_at IS NULL"}
 	var args []interface{}
 
 	if len(opts.Tags) > 0 {
@@ -84,29 +124,31 @@ func ListNotes(db *sql.DB, opts NoteListOptions) ([]models.Note, error) {
 		conditions = append(conditions, fmt.Sprintf("t.name IN (%s)", strings.Join(placeholders, ",")))
 	}
 
-	if opts.StartDate != nil {
-		conditions = append(conditions, "DATE(n.created_at) >= DATE(?)")
-		args = append(args, opts.StartDate.Format("2006-01-02"))
-	}
-
-	if opts.EndDate != nil {
-		conditions = append(conditions, "DATE(n.created_at) <= DATE(?)")
-		args = append(args, opts.EndDate.Format("2006-01-02"))
-	}
+	dateConditions, dateArgs := createdDateRange("n.created_at", opts.StartDate, opts.EndDate)
+	conditions = append(conditions, dateConditions...)
+	args = append(args, dateArgs...)
 
 	if opts.Important {
 		conditions = append(conditions, "n.is_important = 1")
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if opts.AfterID > 0 {
+		condition, cursorArgs, err := afterCondition(db, "notes", "n", sortKeys, "Note", opts.AfterID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
 	}
 
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
 	if len(opts.Tags) > 0 {
 		query += fmt.Sprintf(" GROUP BY n.id HAVING COUNT(DISTINCT t.name) = %d", len(opts.Tags))
 	}
 
-	query += " ORDER BY n.created_at ASC"
+	query += orderByClause(sortKeys)
+	query += limitClause(opts.Limit, opts.Offset)
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -121,58 +163,100 @@ func ListNotes(db *sql.DB, opts NoteListOptions) ([]models.Note, error) {
 			return nil, err
 		}
 
-		tags, err := GetTagsForNote(db, note.ID)
-		if err != nil {
+		if err := openContent(&note.Content); err != nil {
 			return nil, err
 		}
-		note.Tags = tags
 
 		notes = append(notes, note)
 	}
 
-	return notes, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachNoteTags(db, notes); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
 }
 
-func UpdateNote(db *sql.DB, id int, content *string, tags []string, isImportant *bool) error {
-	now := time.Now()
+// createdDateRange filters a timestamp column to whole days between start and
+// end inclusive. Timestamps are stored as text beginning with the local date,
+// so comparing the text directly matches the day the row was written and,
+// unlike DATE(), lets SQLite use an index on the column.
+func createdDateRange(column string, start, end *time.Time) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
 
-	if content != nil {
-		_, err := db.Exec(`
-			UPDATE notes
-			SET content = ?, updated_at = ?
-			WHERE id = ?
-		`, *content, now, id)
-		if err != nil {
-			return err
-		}
+	if start != nil {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, start.Format("2006-01-02"))
 	}
 
-	if isImportant != nil {
-		_, err := db.Exec(`
-			UPDATE notes
-			SET is_important = ?, updated_at = ?
-			WHERE id = ?
-		`, *isImportant, now, id)
-		if err != nil {
-			return err
-		}
+	if end != nil {
+		conditions = append(conditions, column+" < ?")
+		args = append(args, end.AddDate(0, 0, 1).Format("2006-01-02"))
 	}
 
-	if len(tags) > 0 {
-		if err := ReplaceNoteTags(db, id, tags); err != nil {
+	return conditions, args
+}
+
+func UpdateNote(db database.DBTX, id int, content *string, tags []string, isImportant *bool) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		if err := ensureBaseRevision(tx, "note", id); err != nil {
 			return err
 		}
-		_, err := db.Exec("UPDATE notes SET updated_at = ? WHERE id = ?", now, id)
-		if err != nil {
-			return err
+
+		now := time.Now()
+
+		if content != nil {
+			stored, err := sealContent(*content)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
+				UPDATE notes
+				SET content = ?, updated_at = ?
+				WHERE id = ? AND deleted_at IS NULL
+			`, stored, now, id)
+			if err != nil {
+				return err
+			}
+
+			if err := syncLinks(tx, "note", id, *content); err != nil {
+				return err
+			}
 		}
-	}
 
-	return nil
+		if isImportant != nil {
+			_, err := tx.Exec(`
+				UPDATE notes
+				SET is_important = ?, updated_at = ?
+				WHERE id = ? AND deleted_at IS NULL
+			`, *isImportant, now, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(tags) > 0 {
+			if err := ReplaceNoteTags(tx, id, tags); err != nil {
+				return err
+			}
+			_, err := tx.Exec("UPDATE notes SET updated_at = ? WHERE id = ?", now, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordRevision(tx, "note", id)
+	})
 }
 
-func DeleteNote(db *sql.DB, id int) error {
-	result, err := db.Exec("DELETE FROM notes WHERE id = ?", id)
+func DeleteNote(db database.DBTX, id int) error {
+	result, err := db.Exec("UPDATE notes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/nathan-nicholson/note/internal/models"
 )
 
 func TestCreateNote(t *testing.T) {
@@ -357,3 +360,101 @@ func TestListNotes_ChronologicalOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestListNotesPaging(t *testing.T) {
+	db := setupTestDB(t)
+
+	var ids []int
+	for i, content := range []string{"first", "second", "third", "fourth", "fifth"} {
+		note, err := CreateNote(db, content, []string{"paged"}, i == 2)
+		if err != nil {
+			t.Fatalf("CreateNote() error = %v", err)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	noteIDs := func(notes []models.Note) []int {
+		var got []int
+		for _, note := range notes {
+			got = append(got, note.ID)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name string
+		opts NoteListOptions
+		want []int
+	}{
+		{name: "default order", opts: NoteListOptions{}, want: ids},
+		{name: "limit", opts: NoteListOptions{Limit: 2}, want: ids[:2]},
+		{name: "offset", opts: NoteListOptions{Limit: 2, Offset: 2}, want: ids[2:4]},
+		{name: "offset without limit", opts: NoteListOptions{Offset: 3}, want: ids[3:]},
+		{name: "last two", opts: NoteListOptions{Limit: 2, Reverse: true}, want: []int{ids[4], ids[3]}},
+		{name: "after", opts: NoteListOptions{AfterID: ids[1], Limit: 2}, want: ids[2:4]},
+		{name: "after reversed", opts: NoteListOptions{AfterID: ids[3], Reverse: true}, want: []int{ids[2], ids[1], ids[0]}},
+		{name: "important first", opts: NoteListOptions{Sort: "important", Limit: 2}, want: []int{ids[2], ids[0]}},
+		{name: "after important", opts: NoteListOptions{Sort: "important", AfterID: ids[2], Limit: 1}, want: []int{ids[0]}},
+		{name: "after with tag filter", opts: NoteListOptions{Tags: []string{"paged"}, AfterID: ids[3]}, want: ids[4:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := ListNotes(db, tt.opts)
+			if err != nil {
+				t.Fatalf("ListNotes() error = %v", err)
+			}
+
+			got := noteIDs(notes)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListNotes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ListNotes() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("sort by updated", func(t *testing.T) {
+		content := "first, edited"
+		if err := UpdateNote(db, ids[0], &content, nil, nil); err != nil {
+			t.Fatalf("UpdateNote() error = %v", err)
+		}
+
+		notes, err := ListNotes(db, NoteListOptions{Sort: "updated", Reverse: true, Limit: 1})
+		if err != nil {
+			t.Fatalf("ListNotes() error = %v", err)
+		}
+		if len(notes) != 1 || notes[0].ID != ids[0] {
+			t.Errorf("Most recently updated = %v, want #%d", noteIDs(notes), ids[0])
+		}
+	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		if _, err := ListNotes(db, NoteListOptions{Sort: "due"}); err == nil {
+			t.Error("ListNotes() expected error for sort 'due'")
+		}
+	})
+
+	t.Run("unknown cursor", func(t *testing.T) {
+		if _, err := ListNotes(db, NoteListOptions{AfterID: 9999}); err == nil {
+			t.Error("ListNotes() expected error for missing cursor note")
+		}
+	})
+
+	t.Run("trashed cursor", func(t *testing.T) {
+		trashed, err := CreateNote(db, "Trashed", []string{}, false)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := DeleteNote(db, trashed.ID); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+
+		if _, err := ListNotes(db, NoteListOptions{AfterID: trashed.ID}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("ListNotes() error = %v, want the trashed cursor not found", err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
+)
+
+// sortKey is one term of an ORDER BY clause. Every sort ends with the row ID
+// so that the order is total, which is what lets --after resume exactly
+// where a previous page stopped.
+type sortKey struct {
+	expr string
+	desc bool
+}
+
+var noteSorts = map[string][]sortKey{
+	"created":   {{expr: "n.created_at"}},
+	"updated":   {{expr: "n.updated_at"}},
+	"important": {{expr: "n.is_important", desc: true}, {expr: "n.created_at"}},
+}
+
+// todoPrioritySort also serves as the important sort of todos, which have no
+// important flag, so that --sort important works for notes and todos alike.
+var todoPrioritySort = []sortKey{{expr: "t.priority IS NULL"}, {expr: "COALESCE(t.priority, '')"}, {expr: "t.due_date IS NULL"}, {expr: "COALESCE(t.due_date, '')"}, {expr: "t.created_at"}}
+
+var todoSorts = map[string][]sortKey{
+	"due":       {{expr: "t.due_date IS NULL"}, {expr: "COALESCE(t.due_date, '')"}, {expr: "t.created_at"}},
+	"created":   {{expr: "t.created_at"}},
+	"updated":   {{expr: "t.updated_at"}},
+	"priority":  todoPrioritySort,
+	"important": todoPrioritySort,
+}
+
+func resolveSort(sorts map[string][]sortKey, name, fallback, idExpr string, reverse bool) ([]sortKey, error) {
+	if name == "" {
+		name = fallback
+	}
+
+	base, ok := sorts[name]
+	if !ok {
+		names := make([]string, 0, len(sorts))
+		for n := range sorts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("invalid sort '%s' (expected %s)", name, strings.Join(names, ", "))
+	}
+
+	keys := append(append([]sortKey{}, base...), sortKey{expr: idExpr})
+	if reverse {
+		for i := range keys {
+			keys[i].desc = !keys[i].desc
+		}
+	}
+
+	return keys, nil
+}
+
+func orderByClause(keys []sortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.expr
+		if key.desc {
+			terms[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+// afterCondition matches rows that sort after the row with the given ID. The
+// cursor row's keys are read by subqueries so that the values are compared
+// exactly as stored. A trashed row is not in any listing, so like a missing one
+// it is reported as not found.
+func afterCondition(db database.DBTX, table, alias string, keys []sortKey, kind string, afterID int) (string, []interface{}, error) {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ? AND deleted_at IS NULL)", afterID).Scan(&exists); err != nil {
+		return "", nil, err
+	}
+	if !exists {
+		return "", nil, fmt.Errorf("%s #%d not found", kind, afterID)
+	}
+
+	cursor := func(expr string) string {
+		return fmt.Sprintf("(SELECT %s FROM %s %s WHERE %s.id = ?)", expr, table, alias, alias)
+	}
+
+	// (k1 > c1) OR (k1 = c1 AND k2 > c2) OR ..., with < for descending keys.
+	var alternatives []string
+	var args []interface{}
+	for i, key := range keys {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, keys[j].expr+" = "+cursor(keys[j].expr))
+			args = append(args, afterID)
+		}
+
+		op := " > "
+		if key.desc {
+			op = " < "
+		}
+		terms = append(terms, key.expr+op+cursor(key.expr))
+		args = append(args, afterID)
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
+
+func limitClause(limit, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, max(offset, 0))
+}
@@ -5,41 +5,58 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/nathan-nicholson/note/internal/database"
 	"github.com/nathan-nicholson/note/internal/models"
 )
 
-func CreateProject(db *sql.DB, name string, tags []string) (*models.Project, error) {
+func CreateProject(db database.DBTX, name string, tags []string) (*models.Project, error) {
 	if err := models.ValidateProjectName(name); err != nil {
 		return nil, err
 	}
 
-	now := time.Now()
-	result, err := db.Exec(`
-		INSERT INTO projects (name, created_at, is_closed)
-		VALUES (?, ?, 0)
-	`, name, now)
+	var trashed bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM projects WHERE name = ? AND deleted_at IS NOT NULL)", name).Scan(&trashed)
 	if err != nil {
 		return nil, err
 	}
-
-	projectID, err := result.LastInsertId()
-	if err != nil {
-		return nil, err
+	if trashed {
+		return nil, fmt.Errorf("Project '%s' is in the trash. Restore it with: note trash restore project %s", name, name)
 	}
 
-	if err := AddTagsToProject(db, int(projectID), tags); err != nil {
-		return nil, err
-	}
+	var project *models.Project
+
+	err = database.WithTx(db, func(tx database.DBTX) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO projects (name, created_at, is_closed)
+			VALUES (?, ?, 0)
+		`, name, now)
+		if err != nil {
+			return err
+		}
 
-	return GetProjectByName(db, name)
+		projectID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := AddTagsToProject(tx, int(projectID), tags); err != nil {
+			return err
+		}
+
+		project, err = GetProjectByName(tx, name)
+		return err
+	})
+
+	return project, err
 }
 
-func GetProjectByName(db *sql.DB, name string) (*models.Project, error) {
+func GetProjectByName(db database.DBTX, name string) (*models.Project, error) {
 	var project models.Project
 	err := db.QueryRow(`
 		SELECT id, name, created_at, first_activated_at, last_activity_at, closed_at, is_closed
 		FROM projects
-		WHERE name = ?
+		WHERE name = ? AND deleted_at IS NULL
 	`, name).Scan(&project.ID, &project.Name, &project.CreatedAt, &project.FirstActivatedAt,
 		&project.LastActivityAt, &project.ClosedAt, &project.IsClosed)
 
@@ -59,12 +76,12 @@ func GetProjectByName(db *sql.DB, name string) (*models.Project, error) {
 	return &project, nil
 }
 
-func GetProjectByID(db *sql.DB, id int) (*models.Project, error) {
+func GetProjectByID(db database.DBTX, id int) (*models.Project, error) {
 	var project models.Project
 	err := db.QueryRow(`
 		SELECT id, name, created_at, first_activated_at, last_activity_at, closed_at, is_closed
 		FROM projects
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`, id).Scan(&project.ID, &project.Name, &project.CreatedAt, &project.FirstActivatedAt,
 		&project.LastActivityAt, &project.ClosedAt, &project.IsClosed)
 
@@ -81,7 +98,7 @@ func GetProjectByID(db *sql.DB, id int) (*models.Project, error) {
 	return &project, nil
 }
 
-func GetActiveProject(db *sql.DB) (*models.Project, error) {
+func GetActiveProject(db database.DBTX) (*models.Project, error) {
 	var projectID int
 	err := db.QueryRow("SELECT project_id FROM active_project").Scan(&projectID)
 	if err != nil {
@@ -91,48 +108,42 @@ func GetActiveProject(db *sql.DB) (*models.Project, error) {
 	return GetProjectByID(db, projectID)
 }
 
-func SetActiveProject(db *sql.DB, projectID int) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	now := time.Now()
+func SetActiveProject(db database.DBTX, projectID int) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		now := time.Now()
 
-	_, err = tx.Exec("DELETE FROM active_project")
-	if err != nil {
-		return err
-	}
+		_, err := tx.Exec("DELETE FROM active_project")
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.Exec(`
-		INSERT INTO active_project (project_id, activated_at)
-		VALUES (?, ?)
-	`, projectID, now)
-	if err != nil {
-		return err
-	}
+		_, err = tx.Exec(`
+			INSERT INTO active_project (project_id, activated_at)
+			VALUES (?, ?)
+		`, projectID, now)
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.Exec(`
-		UPDATE projects
-		SET first_activated_at = COALESCE(first_activated_at, ?)
-		WHERE id = ?
-	`, now, projectID)
-	if err != nil {
+		_, err = tx.Exec(`
+			UPDATE projects
+			SET first_activated_at = COALESCE(first_activated_at, ?)
+			WHERE id = ?
+		`, now, projectID)
 		return err
-	}
-
-	return tx.Commit()
+	})
 }
 
-func ListProjects(db *sql.DB, includeAll bool) ([]models.Project, error) {
+func ListProjects(db database.DBTX, includeAll bool) ([]models.Project, error) {
 	query := `
 		SELECT id, name, created_at, first_activated_at, last_activity_at, closed_at, is_closed
 		FROM projects
 	`
 
+	query += " WHERE deleted_at IS NULL"
+
 	if !includeAll {
-		query += " WHERE is_closed = 0"
+		query += " AND is_closed = 0"
 	}
 
 	query += " ORDER BY name"
@@ -151,19 +162,21 @@ func ListProjects(db *sql.DB, includeAll bool) ([]models.Project, error) {
 			return nil, err
 		}
 
-		tags, err := GetTagsForProject(db, project.ID)
-		if err != nil {
-			return nil, err
-		}
-		project.Tags = tags
-
 		projects = append(projects, project)
 	}
 
-	return projects, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachProjectTags(db, projects); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
 }
 
-func CloseProject(db *sql.DB, projectID int) error {
+func CloseProject(db database.DBTX, projectID int) error {
 	now := time.Now()
 	_, err := db.Exec(`
 		UPDATE projects
@@ -173,7 +186,7 @@ func CloseProject(db *sql.DB, projectID int) error {
 	return err
 }
 
-func ReopenProject(db *sql.DB, projectID int) error {
+func ReopenProject(db database.DBTX, projectID int) error {
 	_, err := db.Exec(`
 		UPDATE projects
 		SET is_closed = 0, closed_at = NULL
@@ -182,12 +195,12 @@ func ReopenProject(db *sql.DB, projectID int) error {
 	return err
 }
 
-func DeleteProject(db *sql.DB, name string) error {
+func DeleteProject(db database.DBTX, name string) error {
 	if name == "home" {
 		return fmt.Errorf("Cannot delete the 'home' project. It is the default project and must always exist.")
 	}
 
-	result, err := db.Exec("DELETE FROM projects WHERE name = ?", name)
+	result, err := db.Exec("UPDATE projects SET deleted_at = ? WHERE name = ? AND deleted_at IS NULL", time.Now(), name)
 	if err != nil {
 		return err
 	}
@@ -204,17 +217,28 @@ func DeleteProject(db *sql.DB, name string) error {
 	return nil
 }
 
-func UpdateProjectTags(db *sql.DB, projectID int, tags []string) error {
+func UpdateProjectTags(db database.DBTX, projectID int, tags []string) error {
 	return ReplaceProjectTags(db, projectID, tags)
 }
 
-func GetIncompleteTodosForProject(db *sql.DB, projectName string) ([]models.Todo, error) {
-	rows, err := db.Query(`
-		SELECT DISTINCT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at
-		FROM todos t
-		JOIN todo_tags tt ON t.id = tt.todo_id
+// projectTodos finds the todos tagged with a project and all their subtasks,
+// tagged or not, so that a project is not done while any of them is open.
+const projectTodos = `
+	WITH RECURSIVE project_todos(id) AS (
+		SELECT tt.todo_id
+		FROM todo_tags tt
 		JOIN tags tg ON tt.tag_id = tg.id
-		WHERE tg.name = ? AND t.is_complete = 0
+		WHERE tg.name = ?
+		UNION
+		SELECT t.id FROM todos t JOIN project_todos p ON t.parent_id = p.id
+	)`
+
+func GetIncompleteTodosForProject(db database.DBTX, projectName string) ([]models.Todo, error) {
+	rows, err := db.Query(projectTodos+`
+		SELECT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at,
+			COALESCE(t.priority, ''), COALESCE(t.parent_id, 0), `+subtaskCounts+`
+		FROM todos t
+		WHERE t.id IN (SELECT id FROM project_todos) AND t.is_complete = 0 AND t.deleted_at IS NULL
 		ORDER BY t.due_date IS NULL, t.due_date, t.created_at
 	`, projectName)
 	if err != nil {
@@ -225,29 +249,39 @@ func GetIncompleteTodosForProject(db *sql.DB, projectName string) ([]models.Todo
 	var todos []models.Todo
 	for rows.Next() {
 		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt); err != nil {
+		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt,
+			&todo.Priority, &todo.ParentID, &todo.Subtasks, &todo.SubtasksDone); err != nil {
 			return nil, err
 		}
 
-		tags, err := GetTagsForTodo(db, todo.ID)
-		if err != nil {
+		if err := openContent(&todo.Content); err != nil {
 			return nil, err
 		}
-		todo.Tags = tags
 
 		todos = append(todos, todo)
 	}
 
-	return todos, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachTodoTags(db, todos); err != nil {
+		return nil, err
+	}
+
+	if err := attachBlockers(db, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
 }
 
-func GetCompleteTodosForProject(db *sql.DB, projectName string) ([]models.Todo, error) {
-	rows, err := db.Query(`
-		SELECT DISTINCT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at
+func GetCompleteTodosForProject(db database.DBTX, projectName string) ([]models.Todo, error) {
+	rows, err := db.Query(projectTodos+`
+		SELECT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at,
+			COALESCE(t.priority, ''), COALESCE(t.parent_id, 0), `+subtaskCounts+`
 		FROM todos t
-		JOIN todo_tags tt ON t.id = tt.todo_id
-		JOIN tags tg ON tt.tag_id = tg.id
-		WHERE tg.name = ? AND t.is_complete = 1
+		WHERE t.id IN (SELECT id FROM project_todos) AND t.is_complete = 1 AND t.deleted_at IS NULL
 		ORDER BY t.completed_at DESC
 	`, projectName)
 	if err != nil {
@@ -258,23 +292,34 @@ func GetCompleteTodosForProject(db *sql.DB, projectName string) ([]models.Todo,
 	var todos []models.Todo
 	for rows.Next() {
 		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt); err != nil {
+		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt,
+			&todo.Priority, &todo.ParentID, &todo.Subtasks, &todo.SubtasksDone); err != nil {
 			return nil, err
 		}
 
-		tags, err := GetTagsForTodo(db, todo.ID)
-		if err != nil {
+		if err := openContent(&todo.Content); err != nil {
 			return nil, err
 		}
-		todo.Tags = tags
 
 		todos = append(todos, todo)
 	}
 
-	return todos, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := attachTodoTags(db, todos); err != nil {
+		return nil, err
+	}
+
+	if err := attachBlockers(db, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
 }
 
-func UpdateProjectLastActivity(db *sql.DB, projectID int) error {
+func UpdateProjectLastActivity(db database.DBTX, projectID int) error {
 	now := time.Now()
 	_, err := db.Exec(`
 		UPDATE projects
@@ -284,8 +329,8 @@ func UpdateProjectLastActivity(db *sql.DB, projectID int) error {
 	return err
 }
 
-func CountOpenProjects(db *sql.DB) (int, error) {
+func CountOpenProjects(db database.DBTX) (int, error) {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM projects WHERE is_closed = 0").Scan(&count)
+	err := db.QueryRow("SELECT COUNT(*) FROM projects WHERE is_closed = 0 AND deleted_at IS NULL").Scan(&count)
 	return count, err
 }
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Revisions are snapshots of a note or todo after each edit: its content,
+// tags, importance and due date. A todo's priority, recurrence, parent and
+// dependencies are not part of them, so reverting keeps their current values.
+// Rows created before revision history existed have no stored revisions, so
+// the state they are in when first edited is saved as revision 1 before the
+// edit is applied.
+
+var revisionLabels = map[string]string{
+	"note": "Note",
+	"todo": "Todo",
+}
+
+func ListRevisions(db database.DBTX, kind string, id int) ([]models.Revision, error) {
+	current, err := snapshot(db, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := queryRevisions(db, "ORDER BY revision", kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		current.Revision = 1
+		revisions = append(revisions, *current)
+	}
+
+	return revisions, nil
+}
+
+// queryRevisions reads the stored revisions of a note or todo, ordered and
+// limited by order.
+func queryRevisions(db database.DBTX, order, kind string, id int) ([]models.Revision, error) {
+	rows, err := db.Query(`
+		SELECT id, entity_kind, entity_id, revision, content, tags, is_important, due_date, created_at
+		FROM revisions
+		WHERE entity_kind = ? AND entity_id = ?
+	`+order, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.Revision
+	for rows.Next() {
+		var revision models.Revision
+		var tagsJSON string
+		if err := rows.Scan(&revision.ID, &revision.EntityKind, &revision.EntityID, &revision.Revision,
+			&revision.Content, &tagsJSON, &revision.IsImportant, &revision.DueDate, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(tagsJSON), &revision.Tags); err != nil {
+			return nil, err
+		}
+
+		if err := openContent(&revision.Content); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func GetRevision(db database.DBTX, kind string, id int, revision int) (*models.Revision, error) {
+	revisions, err := ListRevisions(db, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return &r, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s #%d has no revision %d", revisionLabels[kind], id, revision)
+}
+
+func RevertNote(db database.DBTX, id int, revision int) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		target, err := GetRevision(tx, "note", id, revision)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureBaseRevision(tx, "note", id); err != nil {
+			return err
+		}
+
+		content, err := sealContent(target.Content)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE notes
+			SET content = ?, is_important = ?, updated_at = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, content, target.IsImportant, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		if err := ReplaceNoteTags(tx, id, target.Tags); err != nil {
+			return err
+		}
+
+		if err := syncLinks(tx, "note", id, target.Content); err != nil {
+			return err
+		}
+
+		return recordRevision(tx, "note", id)
+	})
+}
+
+func RevertTodo(db database.DBTX, id int, revision int) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		target, err := GetRevision(tx, "todo", id, revision)
+		if err != nil {
+			return err
+		}
+
+		if err := ensureBaseRevision(tx, "todo", id); err != nil {
+			return err
+		}
+
+		var dueDate interface{}
+		if target.DueDate.Valid {
+			dueDate = target.DueDate.Time.Format("2006-01-02")
+		}
+
+		content, err := sealContent(target.Content)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE todos
+			SET content = ?, due_date = ?, updated_at = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, content, dueDate, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		if err := ReplaceTodoTags(tx, id, target.Tags); err != nil {
+			return err
+		}
+
+		if err := syncLinks(tx, "todo", id, target.Content); err != nil {
+			return err
+		}
+
+		return recordRevision(tx, "todo", id)
+	})
+}
+
+func snapshot(db database.DBTX, kind string, id int) (*models.Revision, error) {
+	switch kind {
+	case "note":
+		note, err := GetNoteByID(db, id)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Revision{
+			EntityKind:  kind,
+			EntityID:    id,
+			Content:     note.Content,
+			Tags:        note.Tags,
+			IsImportant: note.IsImportant,
+			CreatedAt:   note.UpdatedAt,
+		}, nil
+
+	case "todo":
+		todo, err := GetTodoByID(db, id)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Revision{
+			EntityKind: kind,
+			EntityID:   id,
+			Content:    todo.Content,
+			Tags:       todo.Tags,
+			DueDate:    todo.DueDate,
+			CreatedAt:  todo.UpdatedAt,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown revision kind '%s'", kind)
+	}
+}
+
+func latestRevisionNumber(db database.DBTX, kind string, id int) (int, error) {
+	var latest int
+	err := db.QueryRow(`
+		SELECT COALESCE(MAX(revision), 0)
+		FROM revisions
+		WHERE entity_kind = ? AND entity_id = ?
+	`, kind, id).Scan(&latest)
+	return latest, err
+}
+
+func ensureBaseRevision(db database.DBTX, kind string, id int) error {
+	latest, err := latestRevisionNumber(db, kind, id)
+	if err != nil {
+		return err
+	}
+
+	if latest > 0 {
+		return nil
+	}
+
+	current, err := snapshot(db, kind, id)
+	if err != nil {
+		return err
+	}
+
+	current.Revision = 1
+	return saveRevision(db, current)
+}
+
+func recordRevision(db database.DBTX, kind string, id int) error {
+	current, err := snapshot(db, kind, id)
+	if err != nil {
+		return err
+	}
+
+	// Only the latest revision is compared, so it is the only one read.
+	revisions, err := queryRevisions(db, "ORDER BY revision DESC LIMIT 1", kind, id)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	latest := revisions[0]
+	if sameRevisionState(&latest, current) {
+		return nil
+	}
+
+	current.Revision = latest.Revision + 1
+	current.CreatedAt = time.Now()
+	return saveRevision(db, current)
+}
+
+func saveRevision(db database.DBTX, revision *models.Revision) error {
+	tags := revision.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	var dueDate interface{}
+	if revision.DueDate.Valid {
+		dueDate = revision.DueDate.Time.Format("2006-01-02")
+	}
+
+	content, err := sealContent(revision.Content)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO revisions (entity_kind, entity_id, revision, content, tags, is_important, due_date, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, revision.EntityKind, revision.EntityID, revision.Revision, content, string(tagsJSON),
+		revision.IsImportant, dueDate, revision.CreatedAt)
+	return err
+}
+
+func sameRevisionState(a, b *models.Revision) bool {
+	if a.Content != b.Content || a.IsImportant != b.IsImportant {
+		return false
+	}
+
+	if a.DueDate.Valid != b.DueDate.Valid {
+		return false
+	}
+	if a.DueDate.Valid && a.DueDate.Time.Format("2006-01-02") != b.DueDate.Time.Format("2006-01-02") {
+		return false
+	}
+
+	if len(a.Tags) != len(b.Tags) {
+		return false
+	}
+	for i := range a.Tags {
+		if a.Tags[i] != b.Tags[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListRevisions_UneditedNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Never edited", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	revisions, err := ListRevisions(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	if len(revisions) != 1 {
+		t.Fatalf("ListRevisions() returned %d revisions, want 1", len(revisions))
+	}
+
+	if revisions[0].Revision != 1 || revisions[0].Content != "Never edited" {
+		t.Errorf("ListRevisions()[0] = %+v, want revision 1 with the current content", revisions[0])
+	}
+}
+
+func TestUpdateNote_RecordsRevisions(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "First draft", []string{"draft"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	content := "Second draft"
+	if err := UpdateNote(db, note.ID, &content, nil, nil); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+
+	if err := UpdateNote(db, note.ID, nil, []string{"final"}, nil); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+
+	// An edit that changes nothing must not add a revision.
+	if err := UpdateNote(db, note.ID, &content, nil, nil); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+
+	revisions, err := ListRevisions(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	if len(revisions) != 3 {
+		t.Fatalf("ListRevisions() returned %d revisions, want 3", len(revisions))
+	}
+
+	if revisions[0].Content != "First draft" || revisions[0].Tags[0] != "draft" {
+		t.Errorf("revision 1 = %+v, want the original state", revisions[0])
+	}
+
+	if revisions[1].Content != "Second draft" || revisions[1].Tags[0] != "draft" {
+		t.Errorf("revision 2 = %+v, want the content edit", revisions[1])
+	}
+
+	if revisions[2].Content != "Second draft" || revisions[2].Tags[0] != "final" {
+		t.Errorf("revision 3 = %+v, want the tag edit", revisions[2])
+	}
+}
+
+func TestRevertNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Original", []string{"a"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	content := "Changed"
+	important := true
+	if err := UpdateNote(db, note.ID, &content, []string{"b"}, &important); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+
+	if err := RevertNote(db, note.ID, 1); err != nil {
+		t.Fatalf("RevertNote() error = %v", err)
+	}
+
+	reverted, err := GetNoteByID(db, note.ID)
+	if err != nil {
+		t.Fatalf("GetNoteByID() error = %v", err)
+	}
+
+	if reverted.Content != "Original" || reverted.IsImportant || len(reverted.Tags) != 1 || reverted.Tags[0] != "a" {
+		t.Errorf("RevertNote() left note as %+v, want the original state", reverted)
+	}
+
+	revisions, err := ListRevisions(db, "note", note.ID)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+
+	if len(revisions) != 3 {
+		t.Errorf("RevertNote() should record a new revision, got %d revisions", len(revisions))
+	}
+
+	if err := RevertNote(db, note.ID, 42); err == nil {
+		t.Error("RevertNote() expected error for unknown revision, got nil")
+	}
+}
+
+func TestRevertTodo(t *testing.T) {
+	db := setupTestDB(t)
+
+	due := time.Now().AddDate(0, 0, 3)
+	todo, err := CreateTodo(db, "Ship it", []string{"work"}, &due)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	content := "Ship it later"
+	if err := UpdateTodo(db, todo.ID, &content, nil, nil, true); err != nil {
+		t.Fatalf("UpdateTodo() error = %v", err)
+	}
+
+	if err := RevertTodo(db, todo.ID, 1); err != nil {
+		t.Fatalf("RevertTodo() error = %v", err)
+	}
+
+	reverted, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+
+	if reverted.Content != "Ship it" {
+		t.Errorf("RevertTodo() content = %q, want %q", reverted.Content, "Ship it")
+	}
+
+	if !reverted.DueDate.Valid || reverted.DueDate.Time.Format("2006-01-02") != due.Format("2006-01-02") {
+		t.Errorf("RevertTodo() due date = %v, want %s", reverted.DueDate, due.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+const (
+	SnippetStart = "\x02"
+	SnippetEnd   = "\x03"
+)
+
+var ErrSearchUnavailable = errors.New("full-text search is not available: this build of note was compiled without FTS5 (build with -tags sqlite_fts5)")
+
+var ErrSearchEncrypted = errors.New("full-text search is not available for an encrypted database; filter by tag or date with note list instead")
+
+type SearchOptions struct {
+	NoteListOptions
+	Limit int
+}
+
+func Search(db database.DBTX, query string, opts SearchOptions) ([]models.SearchResult, error) {
+	match := BuildMatchQuery(query)
+	if match == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	encrypted, err := database.Encrypted(db)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return nil, ErrSearchEncrypted
+	}
+
+	var available bool
+	err = db.QueryRow(`
+		SELECT sqlite_compileoption_used('ENABLE_FTS5')
+			AND EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'notes_fts')
+	`).Scan(&available)
+	if err != nil {
+		return nil, err
+	}
+	if !available {
+		return nil, ErrSearchUnavailable
+	}
+
+	results, err := searchTable(db, "note", match, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Todos have no importance flag, so --important only ever matches notes.
+	if !opts.Important {
+		todoResults, err := searchTable(db, "todo", match, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, todoResults...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank < results[j].Rank
+	})
+
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	var noteIDs, todoIDs []int
+	for _, result := range results {
+		if result.Kind == "note" {
+			noteIDs = append(noteIDs, result.ID)
+		} else {
+			todoIDs = append(todoIDs, result.ID)
+		}
+	}
+
+	noteTags, err := GetTagsForNotes(db, noteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	todoTags, err := GetTagsForTodos(db, todoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Kind == "note" {
+			results[i].Tags = noteTags[results[i].ID]
+		} else {
+			results[i].Tags = todoTags[results[i].ID]
+		}
+	}
+
+	return results, nil
+}
+
+func searchTable(db database.DBTX, kind, match string, opts SearchOptions) ([]models.SearchResult, error) {
+	table, ftsTable, tagTable, tagColumn, extraColumns := "notes", "notes_fts", "note_tags", "note_id", "e.is_important, 0"
+	if kind == "todo" {
+		table, ftsTable, tagTable, tagColumn, extraColumns = "todos", "todos_fts", "todo_tags", "todo_id", "0, e.is_complete"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT e.id, snippet(%[1]s, 0, ?, ?, '...', 12), bm25(%[1]s), e.created_at, %[2]s
+		FROM %[1]s
+		JOIN %[3]s e ON e.id = %[1]s.rowid
+	`, ftsTable, extraColumns, table)
+
+	conditions := []string{ftsTable + " MATCH ?", "e.deleted_at IS NULL"}
+	args := []interface{}{SnippetStart, SnippetEnd, match}
+
+	if len(opts.Tags) > 0 {
+		placeholders := make([]string, len(opts.Tags))
+		for i, tag := range opts.Tags {
+			placeholders[i] = "?"
+			args = append(args, tag)
+		}
+		conditions = append(conditions, fmt.Sprintf(`e.id IN (
+			SELECT jt.%s FROM %s jt
+			JOIN tags t ON jt.tag_id = t.id
+			WHERE t.name IN (%s)
+			GROUP BY jt.%s
+			HAVING COUNT(DISTINCT t.name) = %d
+		)`, tagColumn, tagTable, strings.Join(placeholders, ","), tagColumn, len(opts.Tags)))
+	}
+
+	dateConditions, dateArgs := createdDateRange("e.created_at", opts.StartDate, opts.EndDate)
+	conditions = append(conditions, dateConditions...)
+	args = append(args, dateArgs...)
+
+	if opts.Important {
+		conditions = append(conditions, "e.is_important = 1")
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY bm25(%s)", ftsTable)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.SearchResult
+	for rows.Next() {
+		result := models.SearchResult{Kind: kind}
+		if err := rows.Scan(&result.ID, &result.Snippet, &result.Rank, &result.CreatedAt, &result.IsImportant, &result.IsComplete); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// BuildMatchQuery turns user input into an FTS5 MATCH expression. Quoted
+// text becomes a phrase, a trailing * makes a prefix query, and the bare
+// operators AND, OR and NOT are passed through. Everything else is quoted so
+// that punctuation such as hyphens can never be parsed as FTS5 syntax.
+func BuildMatchQuery(input string) string {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	flush := func(phrase bool) {
+		text := current.String()
+		current.Reset()
+
+		if !phrase {
+			text = strings.TrimSpace(text)
+		}
+		if text == "" {
+			return
+		}
+
+		if !phrase && (text == "AND" || text == "OR" || text == "NOT") {
+			terms = append(terms, text)
+			return
+		}
+
+		prefix := !phrase && strings.HasSuffix(text, "*")
+		text = strings.TrimRight(text, "*")
+		if text == "" {
+			return
+		}
+
+		term := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			flush(inQuotes)
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(inQuotes)
+
+	// A dangling operator makes FTS5 reject the whole query.
+	for len(terms) > 0 && isOperator(terms[0]) {
+		terms = terms[1:]
+	}
+	for len(terms) > 0 && isOperator(terms[len(terms)-1]) {
+		terms = terms[:len(terms)-1]
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func isOperator(term string) bool {
+	return term == "AND" || term == "OR" || term == "NOT"
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/database"
+)
+
+func requireSearch(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	available, err := database.SearchAvailable(db)
+	if err != nil {
+		t.Fatalf("SearchAvailable() error = %v", err)
+	}
+
+	if !available {
+		t.Skip("FTS5 not compiled in; run with -tags sqlite_fts5")
+	}
+}
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "deploy", want: `"deploy"`},
+		{input: "deploy release", want: `"deploy" "release"`},
+		{input: `"release plan"`, want: `"release plan"`},
+		{input: "depl*", want: `"depl"*`},
+		{input: "deploy OR release", want: `"deploy" OR "release"`},
+		{input: "follow-up", want: `"follow-up"`},
+		{input: `say "hi`, want: `"say" "hi"`},
+		{input: "OR deploy AND", want: `"deploy"`},
+		{input: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := BuildMatchQuery(tt.input)
+			if got != tt.want {
+				t.Errorf("BuildMatchQuery(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	db := setupTestDB(t)
+	requireSearch(t, db)
+
+	deployNote, err := CreateNote(db, "Deploy went fine after the release freeze", []string{"ops"}, true)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	_, err = CreateNote(db, "Lunch with the design team", []string{"social"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	deployTodo, err := CreateTodo(db, "Write deployment checklist", []string{"ops"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	t.Run("matches notes and todos", func(t *testing.T) {
+		results, err := Search(db, "deploy*", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("Search() returned %d results, want 2", len(results))
+		}
+
+		found := map[string]int{}
+		for _, result := range results {
+			found[result.Kind] = result.ID
+		}
+
+		if found["note"] != deployNote.ID || found["todo"] != deployTodo.ID {
+			t.Errorf("Search() results = %+v, want note #%d and todo #%d", results, deployNote.ID, deployTodo.ID)
+		}
+	})
+
+	t.Run("phrase query", func(t *testing.T) {
+		results, err := Search(db, `"release freeze"`, SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 1 || results[0].ID != deployNote.ID {
+			t.Errorf("Search() phrase returned %+v, want note #%d", results, deployNote.ID)
+		}
+	})
+
+	t.Run("snippet highlights match", func(t *testing.T) {
+		results, err := Search(db, "lunch", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 1 {
+			t.Fatalf("Search() returned %d results, want 1", len(results))
+		}
+
+		if !strings.Contains(results[0].Snippet, SnippetStart+"Lunch"+SnippetEnd) {
+			t.Errorf("Search() snippet = %q, want highlighted match", results[0].Snippet)
+		}
+	})
+
+	t.Run("important filter excludes todos", func(t *testing.T) {
+		results, err := Search(db, "deploy*", SearchOptions{NoteListOptions: NoteListOptions{Important: true}})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 1 || results[0].Kind != "note" {
+			t.Errorf("Search() with Important returned %+v, want only the note", results)
+		}
+	})
+
+	t.Run("tag filter", func(t *testing.T) {
+		results, err := Search(db, "the", SearchOptions{NoteListOptions: NoteListOptions{Tags: []string{"social"}}})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 1 {
+			t.Errorf("Search() with tag returned %d results, want 1", len(results))
+		}
+	})
+
+	t.Run("index follows updates and deletes", func(t *testing.T) {
+		content := "Rollback rehearsal"
+		if err := UpdateNote(db, deployNote.ID, &content, nil, nil); err != nil {
+			t.Fatalf("UpdateNote() error = %v", err)
+		}
+
+		if err := DeleteTodo(db, deployTodo.ID); err != nil {
+			t.Fatalf("DeleteTodo() error = %v", err)
+		}
+
+		results, err := Search(db, "deploy*", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 0 {
+			t.Errorf("Search() returned %d stale results, want 0", len(results))
+		}
+
+		results, err = Search(db, "rehearsal", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+
+		if len(results) != 1 {
+			t.Errorf("Search() returned %d results for updated content, want 1", len(results))
+		}
+	})
+}
@@ -0,0 +1,521 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+)
+
+// Snapshots capture a complete row, including its tags and trash state, as
+// JSON so that the undo journal can put it back exactly as it was. A nil
+// snapshot means the row did not exist.
+
+type noteSnapshot struct {
+	ID          int                  `json:"id"`
+	Content     string               `json:"content"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	IsImportant bool                 `json:"is_important"`
+	DeletedAt   *time.Time           `json:"deleted_at"`
+	Tags        []string             `json:"tags"`
+	Attachments []attachmentSnapshot `json:"attachments,omitempty"`
+	TodoID      *int                 `json:"todo_id,omitempty"`
+}
+
+type todoSnapshot struct {
+	ID           int                  `json:"id"`
+	Content      string               `json:"content"`
+	IsComplete   bool                 `json:"is_complete"`
+	DueDate      *string              `json:"due_date"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+	CompletedAt  *time.Time           `json:"completed_at"`
+	DeletedAt    *time.Time           `json:"deleted_at"`
+	Tags         []string             `json:"tags"`
+	Attachments  []attachmentSnapshot `json:"attachments,omitempty"`
+	PromotedFrom *int                 `json:"promoted_from,omitempty"`
+	Repeat       *string              `json:"repeat,omitempty"`
+	ParentID     *int                 `json:"parent_id,omitempty"`
+	Priority     *string              `json:"priority,omitempty"`
+	BlockedBy    []int                `json:"blocked_by,omitempty"`
+}
+
+type projectSnapshot struct {
+	ID               int        `json:"id"`
+	Name             string     `json:"name"`
+	CreatedAt        time.Time  `json:"created_at"`
+	FirstActivatedAt *time.Time `json:"first_activated_at"`
+	LastActivityAt   *time.Time `json:"last_activity_at"`
+	ClosedAt         *time.Time `json:"closed_at"`
+	IsClosed         bool       `json:"is_closed"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	Tags             []string   `json:"tags"`
+}
+
+// Attachments are left out of snapshots of items without any, so snapshots
+// recorded before attachments existed still match.
+type attachmentSnapshot struct {
+	ID        int       `json:"id"`
+	Hash      string    `json:"hash"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// activitySnapshot holds an activity entry as stored, sealed or not.
+type activitySnapshot struct {
+	ID         int       `json:"id"`
+	Event      string    `json:"event"`
+	EntityKind string    `json:"entity_kind"`
+	EntityID   *int      `json:"entity_id"`
+	Summary    string    `json:"summary"`
+	Before     *string   `json:"before"`
+	After      *string   `json:"after"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type activeProjectSnapshot struct {
+	ProjectID   int       `json:"project_id"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+func Snapshot(db database.DBTX, kind string, id int) ([]byte, error) {
+	var snapshot interface{}
+	var err error
+
+	switch kind {
+	case "note":
+		snapshot, err = snapshotNoteRow(db, id)
+	case "todo":
+		snapshot, err = snapshotTodoRow(db, id)
+	case "project":
+		snapshot, err = snapshotProjectRow(db, id)
+	case "activity":
+		snapshot, err = snapshotActivityRow(db, id)
+	case "active_project":
+		snapshot, err = snapshotActiveProject(db)
+	default:
+		return nil, fmt.Errorf("unknown snapshot kind '%s'", kind)
+	}
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(snapshot)
+}
+
+func RestoreSnapshot(db database.DBTX, kind string, id int, data []byte) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		switch kind {
+		case "note":
+			return restoreNoteRow(tx, id, data)
+		case "todo":
+			return restoreTodoRow(tx, id, data)
+		case "project":
+			return restoreProjectRow(tx, id, data)
+		case "activity":
+			return restoreActivityRow(tx, id, data)
+		case "active_project":
+			return restoreActiveProject(tx, data)
+		default:
+			return fmt.Errorf("unknown snapshot kind '%s'", kind)
+		}
+	})
+}
+
+func snapshotNoteRow(db database.DBTX, id int) (*noteSnapshot, error) {
+	var s noteSnapshot
+	var deletedAt sql.NullTime
+	var todoID sql.NullInt64
+	err := db.QueryRow(`
+		SELECT id, content, created_at, updated_at, is_important, deleted_at, todo_id
+		FROM notes
+		WHERE id = ?
+	`, id).Scan(&s.ID, &s.Content, &s.CreatedAt, &s.UpdatedAt, &s.IsImportant, &deletedAt, &todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.DeletedAt = nullTimePtr(deletedAt)
+	s.TodoID = nullIntPtr(todoID)
+	s.Tags, err = GetTagsForNote(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Attachments, err = snapshotAttachments(db, "note", id)
+	return &s, err
+}
+
+func snapshotTodoRow(db database.DBTX, id int) (*todoSnapshot, error) {
+	var s todoSnapshot
+	var dueDate, completedAt, deletedAt sql.NullTime
+	var promotedFrom sql.NullInt64
+	var repeat sql.NullString
+	var parentID sql.NullInt64
+	var priority sql.NullString
+	err := db.QueryRow(`
+		SELECT id, content, is_complete, due_date, created_at, updated_at, completed_at, deleted_at, promoted_from, repeat, parent_id, priority
+		FROM todos
+		WHERE id = ?
+	`, id).Scan(&s.ID, &s.Content, &s.IsComplete, &dueDate, &s.CreatedAt, &s.UpdatedAt, &completedAt, &deletedAt, &promotedFrom, &repeat, &parentID, &priority)
+	if err != nil {
+		return nil, err
+	}
+
+	if dueDate.Valid {
+		formatted := dueDate.Time.Format("2006-01-02")
+		s.DueDate = &formatted
+	}
+	s.CompletedAt = nullTimePtr(completedAt)
+	s.DeletedAt = nullTimePtr(deletedAt)
+	s.PromotedFrom = nullIntPtr(promotedFrom)
+	s.Repeat = nullStringPtr(repeat)
+	s.ParentID = nullIntPtr(parentID)
+	s.Priority = nullStringPtr(priority)
+	s.Tags, err = GetTagsForTodo(db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Attachments, err = snapshotAttachments(db, "todo", id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.BlockedBy, err = getDependencies(db, id)
+	return &s, err
+}
+
+func snapshotProjectRow(db database.DBTX, id int) (*projectSnapshot, error) {
+	var s projectSnapshot
+	var firstActivatedAt, lastActivityAt, closedAt, deletedAt sql.NullTime
+	err := db.QueryRow(`
+		SELECT id, name, created_at, first_activated_at, last_activity_at, closed_at, is_closed, deleted_at
+		FROM projects
+		WHERE id = ?
+	`, id).Scan(&s.ID, &s.Name, &s.CreatedAt, &firstActivatedAt, &lastActivityAt, &closedAt, &s.IsClosed, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.FirstActivatedAt = nullTimePtr(firstActivatedAt)
+	s.LastActivityAt = nullTimePtr(lastActivityAt)
+	s.ClosedAt = nullTimePtr(closedAt)
+	s.DeletedAt = nullTimePtr(deletedAt)
+	s.Tags, err = GetTagsForProject(db, id)
+	return &s, err
+}
+
+func snapshotActiveProject(db database.DBTX) (*activeProjectSnapshot, error) {
+	var s activeProjectSnapshot
+	err := db.QueryRow("SELECT project_id, activated_at FROM active_project").Scan(&s.ProjectID, &s.ActivatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func restoreNoteRow(db database.DBTX, id int, data []byte) error {
+	if data == nil {
+		if _, err := db.Exec("DELETE FROM note_tags WHERE note_id = ?", id); err != nil {
+			return err
+		}
+		if err := deleteLinks(db, "note", id); err != nil {
+			return err
+		}
+		if err := replaceAttachments(db, "note", id, nil); err != nil {
+			return err
+		}
+		_, err := db.Exec("DELETE FROM notes WHERE id = ?", id)
+		return err
+	}
+
+	var s noteSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	err := upsert(db, "notes", id,
+		"content = ?, created_at = ?, updated_at = ?, is_important = ?, deleted_at = ?, todo_id = ?",
+		"id, content, created_at, updated_at, is_important, deleted_at, todo_id",
+		s.Content, s.CreatedAt, s.UpdatedAt, s.IsImportant, timePtrValue(s.DeletedAt), intPtrValue(s.TodoID))
+	if err != nil {
+		return err
+	}
+
+	if err := ReplaceNoteTags(db, id, s.Tags); err != nil {
+		return err
+	}
+
+	if err := replaceAttachments(db, "note", id, s.Attachments); err != nil {
+		return err
+	}
+
+	content := s.Content
+	if err := openContent(&content); err != nil {
+		return err
+	}
+	if err := syncLinks(db, "note", id, content); err != nil {
+		return err
+	}
+
+	if s.DeletedAt == nil {
+		return recordRevision(db, "note", id)
+	}
+	return nil
+}
+
+func restoreTodoRow(db database.DBTX, id int, data []byte) error {
+	if data == nil {
+		if _, err := db.Exec("DELETE FROM todo_tags WHERE todo_id = ?", id); err != nil {
+			return err
+		}
+		if err := deleteLinks(db, "todo", id); err != nil {
+			return err
+		}
+		if err := replaceAttachments(db, "todo", id, nil); err != nil {
+			return err
+		}
+		if err := replaceDependencies(db, id, nil); err != nil {
+			return err
+		}
+		_, err := db.Exec("DELETE FROM todos WHERE id = ?", id)
+		return err
+	}
+
+	var s todoSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	var dueDate interface{}
+	if s.DueDate != nil {
+		dueDate = *s.DueDate
+	}
+
+	err := upsert(db, "todos", id,
+		"content = ?, is_complete = ?, due_date = ?, created_at = ?, updated_at = ?, completed_at = ?, deleted_at = ?, promoted_from = ?, repeat = ?, parent_id = ?, priority = ?",
+		"id, content, is_complete, due_date, created_at, updated_at, completed_at, deleted_at, promoted_from, repeat, parent_id, priority",
+		s.Content, s.IsComplete, dueDate, s.CreatedAt, s.UpdatedAt, timePtrValue(s.CompletedAt), timePtrValue(s.DeletedAt),
+		intPtrValue(s.PromotedFrom), stringPtrValue(s.Repeat), intPtrValue(s.ParentID), stringPtrValue(s.Priority))
+	if err != nil {
+		return err
+	}
+
+	if err := ReplaceTodoTags(db, id, s.Tags); err != nil {
+		return err
+	}
+
+	if err := replaceAttachments(db, "todo", id, s.Attachments); err != nil {
+		return err
+	}
+
+	if err := replaceDependencies(db, id, s.BlockedBy); err != nil {
+		return err
+	}
+
+	content := s.Content
+	if err := openContent(&content); err != nil {
+		return err
+	}
+	if err := syncLinks(db, "todo", id, content); err != nil {
+		return err
+	}
+
+	if s.DeletedAt == nil {
+		return recordRevision(db, "todo", id)
+	}
+	return nil
+}
+
+func restoreProjectRow(db database.DBTX, id int, data []byte) error {
+	if data == nil {
+		if _, err := db.Exec("DELETE FROM project_tags WHERE project_id = ?", id); err != nil {
+			return err
+		}
+		_, err := db.Exec("DELETE FROM projects WHERE id = ?", id)
+		return err
+	}
+
+	var s projectSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	err := upsert(db, "projects", id,
+		"name = ?, created_at = ?, first_activated_at = ?, last_activity_at = ?, closed_at = ?, is_closed = ?, deleted_at = ?",
+		"id, name, created_at, first_activated_at, last_activity_at, closed_at, is_closed, deleted_at",
+		s.Name, s.CreatedAt, timePtrValue(s.FirstActivatedAt), timePtrValue(s.LastActivityAt),
+		timePtrValue(s.ClosedAt), s.IsClosed, timePtrValue(s.DeletedAt))
+	if err != nil {
+		return err
+	}
+
+	return ReplaceProjectTags(db, id, s.Tags)
+}
+
+func restoreActiveProject(db database.DBTX, data []byte) error {
+	if _, err := db.Exec("DELETE FROM active_project"); err != nil {
+		return err
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	var s activeProjectSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	_, err := db.Exec("INSERT INTO active_project (project_id, activated_at) VALUES (?, ?)", s.ProjectID, s.ActivatedAt)
+	return err
+}
+
+// snapshotActivityRow captures an activity entry as stored, so that undoing
+// the command that wrote it removes it again.
+func snapshotActivityRow(db database.DBTX, id int) (*activitySnapshot, error) {
+	var s activitySnapshot
+	var entityID sql.NullInt64
+	var before, after sql.NullString
+	err := db.QueryRow(`
+		SELECT id, event, entity_kind, entity_id, summary, before, after, created_at
+		FROM activity
+		WHERE id = ?
+	`, id).Scan(&s.ID, &s.Event, &s.EntityKind, &entityID, &s.Summary, &before, &after, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.EntityID = nullIntPtr(entityID)
+	s.Before = nullStringPtr(before)
+	s.After = nullStringPtr(after)
+	return &s, nil
+}
+
+func restoreActivityRow(db database.DBTX, id int, data []byte) error {
+	if data == nil {
+		_, err := db.Exec("DELETE FROM activity WHERE id = ?", id)
+		return err
+	}
+
+	var s activitySnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return upsert(db, "activity", id,
+		"event = ?, entity_kind = ?, entity_id = ?, summary = ?, before = ?, after = ?, created_at = ?",
+		"id, event, entity_kind, entity_id, summary, before, after, created_at",
+		s.Event, s.EntityKind, intPtrValue(s.EntityID), s.Summary, stringPtrValue(s.Before), stringPtrValue(s.After), s.CreatedAt)
+}
+
+func snapshotAttachments(db database.DBTX, kind string, id int) ([]attachmentSnapshot, error) {
+	attachments, err := ListAttachments(db, kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []attachmentSnapshot
+	for _, a := range attachments {
+		snapshots = append(snapshots, attachmentSnapshot{
+			ID:        a.ID,
+			Hash:      a.Hash,
+			Name:      a.Name,
+			Size:      a.Size,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return snapshots, nil
+}
+
+func replaceAttachments(db database.DBTX, kind string, id int, attachments []attachmentSnapshot) error {
+	if _, err := db.Exec("DELETE FROM attachments WHERE owner_kind = ? AND owner_id = ?", kind, id); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		_, err := db.Exec(`
+			INSERT INTO attachments (id, owner_kind, owner_id, hash, name, size, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, a.ID, kind, id, a.Hash, a.Name, a.Size, a.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsert updates the row in place when it exists so that UPDATE triggers,
+// such as the search index, see an ordinary edit. INSERT OR REPLACE would
+// delete the old row without firing its DELETE triggers.
+func upsert(db database.DBTX, table string, id int, setClause, columns string, values ...interface{}) error {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ?)", id).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		_, err := db.Exec("UPDATE "+table+" SET "+setClause+" WHERE id = ?", append(values, id)...)
+		return err
+	}
+
+	placeholders := "?"
+	for range values {
+		placeholders += ", ?"
+	}
+
+	_, err := db.Exec("INSERT INTO "+table+" ("+columns+") VALUES ("+placeholders+")", append([]interface{}{id}, values...)...)
+	return err
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func timePtrValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	value := int(n.Int64)
+	return &value
+}
+
+func intPtrValue(n *int) interface{} {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func stringPtrValue(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -2,10 +2,18 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nathan-nicholson/note/internal/database"
 	"github.com/nathan-nicholson/note/internal/models"
 )
 
-func GetOrCreateTag(db *sql.DB, name string) (int, error) {
+// tagBatchSize keeps bulk tag lookups well below SQLite's limit on the number
+// of bound parameters in one statement.
+const tagBatchSize = 500
+
+func GetOrCreateTag(db database.DBTX, name string) (int, error) {
 	var tagID int
 	err := db.QueryRow("SELECT id FROM tags WHERE name = ?", name).Scan(&tagID)
 	if err == nil {
@@ -29,7 +37,7 @@ func GetOrCreateTag(db *sql.DB, name string) (int, error) {
 	return int(id), nil
 }
 
-func GetTagsForNote(db *sql.DB, noteID int) ([]string, error) {
+func GetTagsForNote(db database.DBTX, noteID int) ([]string, error) {
 	rows, err := db.Query(`
 		SELECT t.name
 		FROM tags t
@@ -54,7 +62,7 @@ func GetTagsForNote(db *sql.DB, noteID int) ([]string, error) {
 	return tags, rows.Err()
 }
 
-func GetTagsForTodo(db *sql.DB, todoID int) ([]string, error) {
+func GetTagsForTodo(db database.DBTX, todoID int) ([]string, error) {
 	rows, err := db.Query(`
 		SELECT t.name
 		FROM tags t
@@ -79,7 +87,7 @@ func GetTagsForTodo(db *sql.DB, todoID int) ([]string, error) {
 	return tags, rows.Err()
 }
 
-func GetTagsForProject(db *sql.DB, projectID int) ([]string, error) {
+func GetTagsForProject(db database.DBTX, projectID int) ([]string, error) {
 	rows, err := db.Query(`
 		SELECT t.name
 		FROM tags t
@@ -104,80 +112,200 @@ func GetTagsForProject(db *sql.DB, projectID int) ([]string, error) {
 	return tags, rows.Err()
 }
 
-func AddTagsToNote(db *sql.DB, noteID int, tags []string) error {
-	for _, tagName := range tags {
-		tagID, err := GetOrCreateTag(db, tagName)
-		if err != nil {
-			return err
-		}
+// GetTagsForNotes loads the tags of many notes at once, keyed by note ID.
+// List paths use it instead of GetTagsForNote to avoid a query per row.
+func GetTagsForNotes(db database.DBTX, noteIDs []int) (map[int][]string, error) {
+	return getTagsForOwners(db, "note_tags", "note_id", noteIDs)
+}
 
-		_, err = db.Exec("INSERT OR IGNORE INTO note_tags (note_id, tag_id) VALUES (?, ?)", noteID, tagID)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+func GetTagsForTodos(db database.DBTX, todoIDs []int) (map[int][]string, error) {
+	return getTagsForOwners(db, "todo_tags", "todo_id", todoIDs)
 }
 
-func AddTagsToTodo(db *sql.DB, todoID int, tags []string) error {
-	for _, tagName := range tags {
-		tagID, err := GetOrCreateTag(db, tagName)
-		if err != nil {
-			return err
+func GetTagsForProjects(db database.DBTX, projectIDs []int) (map[int][]string, error) {
+	return getTagsForOwners(db, "project_tags", "project_id", projectIDs)
+}
+
+func getTagsForOwners(db database.DBTX, joinTable, ownerColumn string, ids []int) (map[int][]string, error) {
+	tags := make(map[int][]string, len(ids))
+
+	for start := 0; start < len(ids); start += tagBatchSize {
+		batch := ids[start:min(start+tagBatchSize, len(ids))]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
 		}
 
-		_, err = db.Exec("INSERT OR IGNORE INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", todoID, tagID)
+		rows, err := db.Query(fmt.Sprintf(`
+			SELECT j.%[2]s, t.name
+			FROM tags t
+			JOIN %[1]s j ON t.id = j.tag_id
+			WHERE j.%[2]s IN (%[3]s)
+			ORDER BY t.name
+		`, joinTable, ownerColumn, strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")), args...)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-	return nil
-}
 
-func AddTagsToProject(db *sql.DB, projectID int, tags []string) error {
-	for _, tagName := range tags {
-		tagID, err := GetOrCreateTag(db, tagName)
-		if err != nil {
-			return err
+		for rows.Next() {
+			var id int
+			var tag string
+			if err := rows.Scan(&id, &tag); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			tags[id] = append(tags[id], tag)
 		}
+		rows.Close()
 
-		_, err = db.Exec("INSERT OR IGNORE INTO project_tags (project_id, tag_id) VALUES (?, ?)", projectID, tagID)
-		if err != nil {
-			return err
+		if err := rows.Err(); err != nil {
+			return nil, err
 		}
 	}
-	return nil
+
+	return tags, nil
 }
 
-func ReplaceNoteTags(db *sql.DB, noteID int, tags []string) error {
-	_, err := db.Exec("DELETE FROM note_tags WHERE note_id = ?", noteID)
+func attachNoteTags(db database.DBTX, notes []models.Note) error {
+	ids := make([]int, len(notes))
+	for i, note := range notes {
+		ids[i] = note.ID
+	}
+
+	tags, err := GetTagsForNotes(db, ids)
 	if err != nil {
 		return err
 	}
-	return AddTagsToNote(db, noteID, tags)
+
+	for i := range notes {
+		notes[i].Tags = tags[notes[i].ID]
+	}
+	return nil
 }
 
-func ReplaceTodoTags(db *sql.DB, todoID int, tags []string) error {
-	_, err := db.Exec("DELETE FROM todo_tags WHERE todo_id = ?", todoID)
+func attachTodoTags(db database.DBTX, todos []models.Todo) error {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	tags, err := GetTagsForTodos(db, ids)
 	if err != nil {
 		return err
 	}
-	return AddTagsToTodo(db, todoID, tags)
+
+	for i := range todos {
+		todos[i].Tags = tags[todos[i].ID]
+	}
+	return nil
 }
 
-func ReplaceProjectTags(db *sql.DB, projectID int, tags []string) error {
-	_, err := db.Exec("DELETE FROM project_tags WHERE project_id = ?", projectID)
+func attachProjectTags(db database.DBTX, projects []models.Project) error {
+	ids := make([]int, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	tags, err := GetTagsForProjects(db, ids)
 	if err != nil {
 		return err
 	}
-	return AddTagsToProject(db, projectID, tags)
+
+	for i := range projects {
+		projects[i].Tags = tags[projects[i].ID]
+	}
+	return nil
+}
+
+func AddTagsToNote(db database.DBTX, noteID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		for _, tagName := range tags {
+			tagID, err := GetOrCreateTag(tx, tagName)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT OR IGNORE INTO note_tags (note_id, tag_id) VALUES (?, ?)", noteID, tagID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func AddTagsToTodo(db database.DBTX, todoID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		for _, tagName := range tags {
+			tagID, err := GetOrCreateTag(tx, tagName)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT OR IGNORE INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", todoID, tagID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func AddTagsToProject(db database.DBTX, projectID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		for _, tagName := range tags {
+			tagID, err := GetOrCreateTag(tx, tagName)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT OR IGNORE INTO project_tags (project_id, tag_id) VALUES (?, ?)", projectID, tagID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func ReplaceNoteTags(db database.DBTX, noteID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		_, err := tx.Exec("DELETE FROM note_tags WHERE note_id = ?", noteID)
+		if err != nil {
+			return err
+		}
+		return AddTagsToNote(tx, noteID, tags)
+	})
+}
+
+func ReplaceTodoTags(db database.DBTX, todoID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		_, err := tx.Exec("DELETE FROM todo_tags WHERE todo_id = ?", todoID)
+		if err != nil {
+			return err
+		}
+		return AddTagsToTodo(tx, todoID, tags)
+	})
+}
+
+func ReplaceProjectTags(db database.DBTX, projectID int, tags []string) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		_, err := tx.Exec("DELETE FROM project_tags WHERE project_id = ?", projectID)
+		if err != nil {
+			return err
+		}
+		return AddTagsToProject(tx, projectID, tags)
+	})
 }
 
-func ListAllTags(db *sql.DB) ([]models.Tag, error) {
+func ListAllTags(db database.DBTX) ([]models.Tag, error) {
 	rows, err := db.Query(`
 		SELECT t.id, t.name,
-			(SELECT COUNT(*) FROM note_tags WHERE tag_id = t.id) +
-			(SELECT COUNT(*) FROM todo_tags WHERE tag_id = t.id) AS usage_count
+			(SELECT COUNT(*) FROM note_tags nt JOIN notes n ON n.id = nt.note_id
+				WHERE nt.tag_id = t.id AND n.deleted_at IS NULL) +
+			(SELECT COUNT(*) FROM todo_tags tt JOIN todos td ON td.id = tt.todo_id
+				WHERE tt.tag_id = t.id AND td.deleted_at IS NULL) AS usage_count
 		FROM tags t
 		ORDER BY usage_count DESC, t.name
 	`)
@@ -189,8 +317,7 @@ func ListAllTags(db *sql.DB) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		var usageCount int
-		if err := rows.Scan(&tag.ID, &tag.Name, &usageCount); err != nil {
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.UsageCount); err != nil {
 			return nil, err
 		}
 		tags = append(tags, tag)
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+func CreateTemplate(db database.DBTX, name, content string, tags []string, isImportant bool) (*models.Template, error) {
+	if err := models.ValidateTemplateName(name); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("template content cannot be empty")
+	}
+
+	tagsJSON, err := marshalTemplateTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM templates WHERE name = ?)", name).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("Template '%s' already exists", name)
+	}
+
+	now := time.Now()
+	_, err = db.Exec(`
+		INSERT INTO templates (name, content, tags, is_important, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, name, content, tagsJSON, isImportant, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetTemplate(db, name)
+}
+
+// GetTemplate returns the template stored in the database under name, or nil
+// if there is none.
+func GetTemplate(db database.DBTX, name string) (*models.Template, error) {
+	t, err := scanTemplate(db.QueryRow(`
+		SELECT id, name, content, tags, is_important, created_at, updated_at
+		FROM templates
+		WHERE name = ?
+	`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+func ListTemplates(db database.DBTX) ([]models.Template, error) {
+	rows, err := db.Query(`
+		SELECT id, name, content, tags, is_important, created_at, updated_at
+		FROM templates
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *t)
+	}
+
+	return result, rows.Err()
+}
+
+// UpdateTemplate changes the fields that are given: content and isImportant
+// when not nil, tags when not nil.
+func UpdateTemplate(db database.DBTX, name string, content *string, tags []string, isImportant *bool) error {
+	t, err := GetTemplate(db, name)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("Template '%s' not found", name)
+	}
+
+	if content != nil {
+		if strings.TrimSpace(*content) == "" {
+			return fmt.Errorf("template content cannot be empty")
+		}
+		t.Content = *content
+	}
+	if tags != nil {
+		t.Tags = tags
+	}
+	if isImportant != nil {
+		t.IsImportant = *isImportant
+	}
+
+	tagsJSON, err := marshalTemplateTags(t.Tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		UPDATE templates
+		SET content = ?, tags = ?, is_important = ?, updated_at = ?
+		WHERE id = ?
+	`, t.Content, tagsJSON, t.IsImportant, time.Now(), t.ID)
+	return err
+}
+
+func DeleteTemplate(db database.DBTX, name string) error {
+	result, err := db.Exec("DELETE FROM templates WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("Template '%s' not found", name)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTemplate(row rowScanner) (*models.Template, error) {
+	var t models.Template
+	var tagsJSON string
+	if err := row.Scan(&t.ID, &t.Name, &t.Content, &tagsJSON, &t.IsImportant, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tagsJSON), &t.Tags); err != nil {
+		return nil, err
+	}
+	if len(t.Tags) == 0 {
+		t.Tags = nil
+	}
+	return &t, nil
+}
+
+func marshalTemplateTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	data, err := json.Marshal(tags)
+	return string(data), err
+}
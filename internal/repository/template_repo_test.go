@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplates_CreateUpdateDelete(t *testing.T) {
+	db := setupTestDB(t)
+
+	created, err := CreateTemplate(db, "standup", "Standup {{date}}", []string{"standup"}, false)
+	if err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+	if created.Name != "standup" || !reflect.DeepEqual(created.Tags, []string{"standup"}) {
+		t.Errorf("CreateTemplate() = %+v", created)
+	}
+
+	if _, err := CreateTemplate(db, "standup", "Again", nil, false); err == nil {
+		t.Error("CreateTemplate() with a taken name succeeded, want an error")
+	}
+	if _, err := CreateTemplate(db, "Bad Name", "x", nil, false); err == nil {
+		t.Error("CreateTemplate() with an invalid name succeeded, want an error")
+	}
+
+	content := "Daily standup {{date}}"
+	important := true
+	if err := UpdateTemplate(db, "standup", &content, []string{}, &important); err != nil {
+		t.Fatalf("UpdateTemplate() error = %v", err)
+	}
+
+	got, err := GetTemplate(db, "standup")
+	if err != nil {
+		t.Fatalf("GetTemplate() error = %v", err)
+	}
+	if got.Content != content || !got.IsImportant || len(got.Tags) != 0 {
+		t.Errorf("GetTemplate() after update = %+v", got)
+	}
+
+	list, err := ListTemplates(db)
+	if err != nil {
+		t.Fatalf("ListTemplates() error = %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("ListTemplates() returned %d templates, want 1", len(list))
+	}
+
+	if err := DeleteTemplate(db, "standup"); err != nil {
+		t.Fatalf("DeleteTemplate() error = %v", err)
+	}
+
+	got, err = GetTemplate(db, "standup")
+	if err != nil || got != nil {
+		t.Errorf("GetTemplate() after delete = %+v, %v, want nil, nil", got, err)
+	}
+
+	if err := DeleteTemplate(db, "standup"); err == nil {
+		t.Error("DeleteTemplate() of a missing template succeeded, want an error")
+	}
+}
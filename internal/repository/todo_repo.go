@@ -6,43 +6,69 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/dateparse"
 	"github.com/nathan-nicholson/note/internal/models"
 )
 
-func CreateTodo(db *sql.DB, content string, tags []string, dueDate *time.Time) (*models.Todo, error) {
+// subtaskCounts selects how many subtasks the todo aliased t has and how many
+// of them are complete.
+const subtaskCounts = `
+	(SELECT COUNT(*) FROM todos s WHERE s.parent_id = t.id AND s.deleted_at IS NULL),
+	(SELECT COUNT(*) FROM todos s WHERE s.parent_id = t.id AND s.deleted_at IS NULL AND s.is_complete = 1)`
+
+func CreateTodo(db database.DBTX, content string, tags []string, dueDate *time.Time) (*models.Todo, error) {
 	var dueDateSQL interface{}
 	if dueDate != nil {
 		dueDateSQL = dueDate.Format("2006-01-02")
 	}
 
-	now := time.Now()
-	result, err := db.Exec(`
-		INSERT INTO todos (content, due_date, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
-	`, content, dueDateSQL, now, now)
+	stored, err := sealContent(content)
 	if err != nil {
 		return nil, err
 	}
 
-	todoID, err := result.LastInsertId()
-	if err != nil {
-		return nil, err
-	}
+	var todo *models.Todo
 
-	if err := AddTagsToTodo(db, int(todoID), tags); err != nil {
-		return nil, err
-	}
+	err = database.WithTx(db, func(tx database.DBTX) error {
+		now := time.Now()
+		result, err := tx.Exec(`
+			INSERT INTO todos (content, due_date, created_at, updated_at)
+			VALUES (?, ?, ?, ?)
+		`, stored, dueDateSQL, now, now)
+		if err != nil {
+			return err
+		}
+
+		todoID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := AddTagsToTodo(tx, int(todoID), tags); err != nil {
+			return err
+		}
+
+		if err := syncLinks(tx, "todo", int(todoID), content); err != nil {
+			return err
+		}
 
-	return GetTodoByID(db, int(todoID))
+		todo, err = GetTodoByID(tx, int(todoID))
+		return err
+	})
+
+	return todo, err
 }
 
-func GetTodoByID(db *sql.DB, id int) (*models.Todo, error) {
+func GetTodoByID(db database.DBTX, id int) (*models.Todo, error) {
 	var todo models.Todo
 	err := db.QueryRow(`
-		SELECT id, content, is_complete, due_date, created_at, updated_at, completed_at
-		FROM todos
-		WHERE id = ?
-	`, id).Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt)
+		SELECT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at,
+			COALESCE(t.promoted_from, 0), COALESCE(t.repeat, ''), COALESCE(t.priority, ''), COALESCE(t.parent_id, 0), `+subtaskCounts+`
+		FROM todos t
+		WHERE t.id = ? AND t.deleted_at IS NULL
+	`, id).Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt,
+		&todo.PromotedFrom, &todo.Repeat, &todo.Priority, &todo.ParentID, &todo.Subtasks, &todo.SubtasksDone)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -51,12 +77,28 @@ func GetTodoByID(db *sql.DB, id int) (*models.Todo, error) {
 		return nil, err
 	}
 
+	if err := openContent(&todo.Content); err != nil {
+		return nil, err
+	}
+
 	tags, err := GetTagsForTodo(db, id)
 	if err != nil {
 		return nil, err
 	}
 	todo.Tags = tags
 
+	attachments, err := ListAttachments(db, "todo", id)
+	if err != nil {
+		return nil, err
+	}
+	todo.Attachments = attachments
+
+	blocked := []models.Todo{todo}
+	if err := attachBlockers(db, blocked); err != nil {
+		return nil, err
+	}
+	todo.BlockedBy = blocked[0].BlockedBy
+
 	return &todo, nil
 }
 
@@ -65,15 +107,36 @@ type TodoListOptions struct {
 	Incomplete bool
 	Tags       []string
 	Overdue    bool
+	// Priority limits the listing to todos of this priority, P0 to P3.
+	Priority string
+	// Actionable limits the listing to open todos that are neither blocked
+	// nor waiting on open subtasks.
+	Actionable bool
+	// Day limits the listing to todos created, completed or due on this day.
+	Day *time.Time
+
+	// Sort is one of due (the default), created, updated or priority.
+	Sort    string
+	Reverse bool
+	Limit   int
+	Offset  int
+	// AfterID continues a listing after the todo with this ID.
+	AfterID int
 }
 
-func ListTodos(db *sql.DB, opts TodoListOptions) ([]models.Todo, error) {
+func ListTodos(db database.DBTX, opts TodoListOptions) ([]models.Todo, error) {
+	sortKeys, err := resolveSort(todoSorts, opts.Sort, "due", "t.id", opts.Reverse)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT DISTINCT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at
+		SELECT DISTINCT t.id, t.content, t.is_complete, t.due_date, t.created_at, t.updated_at, t.completed_at,
+			COALESCE(t.repeat, ''), COALESCE(t.priority, ''), COALESCE(t.parent_id, 0), ` + subtaskCounts + `
 		FROM todos t
 	`
 
-	var conditions []string
+	conditions := []string{"t.deleted_at IS NULL"}
 	var args []interface{}
 
 	if len(opts.Tags) > 0 {
@@ -101,15 +164,47 @@ func ListTodos(db *sql.DB, opts TodoListOptions) ([]models.Todo, error) {
 		conditions = append(conditions, "t.due_date IS NOT NULL AND DATE(t.due_date) < DATE('now') AND t.is_complete = 0")
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if opts.Actionable {
+		conditions = append(conditions, `t.is_complete = 0 AND NOT EXISTS (
+			SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocked_by
+			WHERE d.todo_id = t.id AND b.is_complete = 0 AND b.deleted_at IS NULL
+		) AND NOT EXISTS (
+			SELECT 1 FROM todos s WHERE s.parent_id = t.id AND s.is_complete = 0 AND s.deleted_at IS NULL
+		)`)
+	}
+
+	if opts.Priority != "" {
+		conditions = append(conditions, "t.priority = ?")
+		args = append(args, opts.Priority)
 	}
 
+	if opts.Day != nil {
+		var onDay []string
+		for _, column := range []string{"t.created_at", "t.completed_at", "t.due_date"} {
+			dayConditions, dayArgs := createdDateRange(column, opts.Day, opts.Day)
+			onDay = append(onDay, "("+strings.Join(dayConditions, " AND ")+")")
+			args = append(args, dayArgs...)
+		}
+		conditions = append(conditions, "("+strings.Join(onDay, " OR ")+")")
+	}
+
+	if opts.AfterID > 0 {
+		condition, cursorArgs, err := afterCondition(db, "todos", "t", sortKeys, "Todo", opts.AfterID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
 	if len(opts.Tags) > 0 {
 		query += fmt.Sprintf(" GROUP BY t.id HAVING COUNT(DISTINCT tg.name) = %d", len(opts.Tags))
 	}
 
-	query += " ORDER BY t.due_date IS NULL, t.due_date, t.created_at"
+	query += orderByClause(sortKeys)
+	query += limitClause(opts.Limit, opts.Offset)
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -120,91 +215,311 @@ func ListTodos(db *sql.DB, opts TodoListOptions) ([]models.Todo, error) {
 	var todos []models.Todo
 	for rows.Next() {
 		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt); err != nil {
+		if err := rows.Scan(&todo.ID, &todo.Content, &todo.IsComplete, &todo.DueDate, &todo.CreatedAt, &todo.UpdatedAt, &todo.CompletedAt,
+			&todo.Repeat, &todo.Priority, &todo.ParentID, &todo.Subtasks, &todo.SubtasksDone); err != nil {
 			return nil, err
 		}
 
-		tags, err := GetTagsForTodo(db, todo.ID)
-		if err != nil {
+		if err := openContent(&todo.Content); err != nil {
 			return nil, err
 		}
-		todo.Tags = tags
 
 		todos = append(todos, todo)
 	}
 
-	return todos, rows.Err()
-}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-func CompleteTodo(db *sql.DB, id int) error {
-	now := time.Now()
-	_, err := db.Exec(`
-		UPDATE todos
-		SET is_complete = 1, completed_at = ?, updated_at = ?
-		WHERE id = ?
-	`, now, now, id)
-	return err
-}
+	if err := attachTodoTags(db, todos); err != nil {
+		return nil, err
+	}
 
-func UncompleteTodo(db *sql.DB, id int) error {
-	now := time.Now()
-	_, err := db.Exec(`
-		UPDATE todos
-		SET is_complete = 0, completed_at = NULL, updated_at = ?
-		WHERE id = ?
-	`, now, id)
-	return err
+	if err := attachBlockers(db, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
 }
 
-func UpdateTodo(db *sql.DB, id int, content *string, tags []string, dueDate *time.Time, clearDueDate bool) error {
-	now := time.Now()
+// CompleteTodo marks a todo complete. Completing a recurring todo also
+// creates its next instance, which is returned, and moves the recurrence to
+// it; otherwise the returned todo is nil.
+func CompleteTodo(db database.DBTX, id int) (*models.Todo, error) {
+	var next *models.Todo
 
-	if content != nil {
-		_, err := db.Exec(`
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		next = nil
+
+		now := time.Now()
+		_, err := tx.Exec(`
 			UPDATE todos
-			SET content = ?, updated_at = ?
-			WHERE id = ?
-		`, *content, now, id)
+			SET is_complete = 1, completed_at = ?, updated_at = ?
+			WHERE id = ? AND deleted_at IS NULL
+		`, now, now, id)
 		if err != nil {
 			return err
 		}
-	}
 
-	if clearDueDate {
-		_, err := db.Exec(`
-			UPDATE todos
-			SET due_date = NULL, updated_at = ?
-			WHERE id = ?
-		`, now, id)
+		todo, err := GetTodoByID(tx, id)
+		if err != nil || todo.Repeat == "" {
+			return err
+		}
+
+		nextDue, err := nextDueDate(todo, now)
 		if err != nil {
 			return err
 		}
-	} else if dueDate != nil {
-		_, err := db.Exec(`
-			UPDATE todos
-			SET due_date = ?, updated_at = ?
-			WHERE id = ?
-		`, dueDate.Format("2006-01-02"), now, id)
+
+		next, err = CreateTodo(tx, todo.Content, todo.Tags, &nextDue)
 		if err != nil {
 			return err
 		}
-	}
 
-	if len(tags) > 0 {
-		if err := ReplaceTodoTags(db, id, tags); err != nil {
+		if err := SetTodoRepeat(tx, next.ID, todo.Repeat); err != nil {
 			return err
 		}
-		_, err := db.Exec("UPDATE todos SET updated_at = ? WHERE id = ?", now, id)
+		next.Repeat = todo.Repeat
+
+		if todo.Priority != "" {
+			if err := SetTodoPriority(tx, next.ID, todo.Priority); err != nil {
+				return err
+			}
+			next.Priority = todo.Priority
+		}
+
+		// The next instance stays under the parent only while the parent is
+		// open, so completing a parent never leaves an open subtask behind.
+		if todo.ParentID != 0 {
+			var parentOpen bool
+			err := tx.QueryRow(`
+				SELECT COUNT(*) > 0 FROM todos
+				WHERE id = ? AND is_complete = 0 AND deleted_at IS NULL
+			`, todo.ParentID).Scan(&parentOpen)
+			if err != nil {
+				return err
+			}
+
+			if parentOpen {
+				if err := SetTodoParent(tx, next.ID, todo.ParentID); err != nil {
+					return err
+				}
+				next.ParentID = todo.ParentID
+			}
+		}
+
+		return SetTodoRepeat(tx, id, "")
+	})
+
+	return next, err
+}
+
+// SkipTodo moves a recurring todo on to its next due date without completing
+// it, and returns that date.
+func SkipTodo(db database.DBTX, id int) (time.Time, error) {
+	var nextDue time.Time
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		todo, err := GetTodoByID(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if todo.Repeat == "" {
+			return fmt.Errorf("Todo #%d does not repeat", id)
+		}
+
+		nextDue, err = nextDueDate(todo, time.Now())
 		if err != nil {
 			return err
 		}
+
+		return UpdateTodo(tx, id, nil, nil, &nextDue, false)
+	})
+
+	return nextDue, err
+}
+
+// SetTodoRepeat sets how often a todo recurs, as read by
+// dateparse.ParseRepeat. An empty repeat stops it recurring.
+func SetTodoRepeat(db database.DBTX, id int, repeat string) error {
+	var value interface{}
+	if repeat != "" {
+		value = repeat
 	}
 
-	return nil
+	_, err := db.Exec(`
+		UPDATE todos
+		SET repeat = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, value, time.Now(), id)
+	return err
+}
+
+// SetTodoPriority sets a todo's priority, P0 to P3. An empty priority
+// clears it.
+func SetTodoPriority(db database.DBTX, id int, priority string) error {
+	var value interface{}
+	if priority != "" {
+		value = priority
+	}
+
+	_, err := db.Exec(`
+		UPDATE todos
+		SET priority = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, value, time.Now(), id)
+	return err
+}
+
+// SetTodoParent makes a todo a subtask of another.
+func SetTodoParent(db database.DBTX, id, parentID int) error {
+	_, err := db.Exec(`
+		UPDATE todos
+		SET parent_id = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, parentID, time.Now(), id)
+	return err
+}
+
+// OpenSubtasks returns the incomplete subtasks of a todo, their subtasks and
+// so on, each after its parent.
+func OpenSubtasks(db database.DBTX, id int) ([]models.Todo, error) {
+	rows, err := db.Query(`
+		WITH RECURSIVE subtasks(id, path) AS (
+			SELECT id, printf('%010d', id) FROM todos WHERE parent_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id, s.path || printf('%010d', t.id)
+			FROM todos t
+			JOIN subtasks s ON t.parent_id = s.id
+			WHERE t.deleted_at IS NULL
+		)
+		SELECT s.id
+		FROM subtasks s
+		JOIN todos t ON t.id = s.id
+		WHERE t.is_complete = 0
+		ORDER BY s.path
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var subtaskID int
+		if err := rows.Scan(&subtaskID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, subtaskID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	todos := make([]models.Todo, 0, len(ids))
+	for _, subtaskID := range ids {
+		todo, err := GetTodoByID(db, subtaskID)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, nil
+}
+
+// nextDueDate follows a recurring todo on from its due date, or from today
+// when it has none.
+func nextDueDate(todo *models.Todo, now time.Time) (time.Time, error) {
+	repeat, err := dateparse.ParseRepeat(todo.Repeat)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	due := now
+	if todo.DueDate.Valid {
+		d := todo.DueDate.Time
+		due = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, now.Location())
+	}
+
+	return repeat.Advance(due, now), nil
+}
+
+func UncompleteTodo(db database.DBTX, id int) error {
+	now := time.Now()
+	_, err := db.Exec(`
+		UPDATE todos
+		SET is_complete = 0, completed_at = NULL, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`, now, id)
+	return err
+}
+
+func UpdateTodo(db database.DBTX, id int, content *string, tags []string, dueDate *time.Time, clearDueDate bool) error {
+	return database.WithTx(db, func(tx database.DBTX) error {
+		if err := ensureBaseRevision(tx, "todo", id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		if content != nil {
+			stored, err := sealContent(*content)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`
+				UPDATE todos
+				SET content = ?, updated_at = ?
+				WHERE id = ? AND deleted_at IS NULL
+			`, stored, now, id)
+			if err != nil {
+				return err
+			}
+
+			if err := syncLinks(tx, "todo", id, *content); err != nil {
+				return err
+			}
+		}
+
+		if clearDueDate {
+			_, err := tx.Exec(`
+				UPDATE todos
+				SET due_date = NULL, updated_at = ?
+				WHERE id = ? AND deleted_at IS NULL
+			`, now, id)
+			if err != nil {
+				return err
+			}
+		} else if dueDate != nil {
+			_, err := tx.Exec(`
+				UPDATE todos
+				SET due_date = ?, updated_at = ?
+				WHERE id = ? AND deleted_at IS NULL
+			`, dueDate.Format("2006-01-02"), now, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(tags) > 0 {
+			if err := ReplaceTodoTags(tx, id, tags); err != nil {
+				return err
+			}
+			_, err := tx.Exec("UPDATE todos SET updated_at = ? WHERE id = ?", now, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordRevision(tx, "todo", id)
+	})
 }
 
-func DeleteTodo(db *sql.DB, id int) error {
-	result, err := db.Exec("DELETE FROM todos WHERE id = ?", id)
+func DeleteTodo(db database.DBTX, id int) error {
+	result, err := db.Exec("UPDATE todos SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/nathan-nicholson/note/internal/models"
 )
 
 func TestCreateTodo(t *testing.T) {
@@ -75,10 +78,13 @@ func TestCompleteTodo(t *testing.T) {
 		t.Fatalf("Setup failed: %v", err)
 	}
 
-	err = CompleteTodo(db, todo.ID)
+	next, err := CompleteTodo(db, todo.ID)
 	if err != nil {
 		t.Fatalf("CompleteTodo() error = %v", err)
 	}
+	if next != nil {
+		t.Errorf("CompleteTodo() created %+v for a todo that does not repeat", next)
+	}
 
 	updated, err := GetTodoByID(db, todo.ID)
 	if err != nil {
@@ -94,6 +100,244 @@ func TestCompleteTodo(t *testing.T) {
 	}
 }
 
+func TestCompleteTodo_Recurring(t *testing.T) {
+	db := setupTestDB(t)
+
+	due := time.Now().AddDate(0, 0, 10)
+	todo, err := CreateTodo(db, "Water the plants", []string{"home"}, &due)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := SetTodoRepeat(db, todo.ID, "weekly"); err != nil {
+		t.Fatalf("SetTodoRepeat() error = %v", err)
+	}
+
+	next, err := CompleteTodo(db, todo.ID)
+	if err != nil {
+		t.Fatalf("CompleteTodo() error = %v", err)
+	}
+	if next == nil {
+		t.Fatal("CompleteTodo() did not create the next instance")
+	}
+
+	next, err = GetTodoByID(db, next.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+
+	if next.IsComplete || next.Content != "Water the plants" || len(next.Tags) != 1 || next.Tags[0] != "home" {
+		t.Errorf("next instance = %+v, want an incomplete copy of the todo", next)
+	}
+	if want := due.AddDate(0, 0, 7).Format("2006-01-02"); next.DueDate.Time.Format("2006-01-02") != want {
+		t.Errorf("next instance due %s, want %s", next.DueDate.Time.Format("2006-01-02"), want)
+	}
+	if next.Repeat != "weekly" {
+		t.Errorf("next instance repeat = %q, want weekly", next.Repeat)
+	}
+
+	completed, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if !completed.IsComplete || completed.Repeat != "" {
+		t.Errorf("completed todo = %+v, want it complete and no longer repeating", completed)
+	}
+}
+
+func TestSkipTodo(t *testing.T) {
+	db := setupTestDB(t)
+
+	due := time.Now().AddDate(0, 0, 3)
+	todo, err := CreateTodo(db, "Pay rent", []string{}, &due)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := SkipTodo(db, todo.ID); err == nil {
+		t.Error("SkipTodo() should fail for a todo that does not repeat")
+	}
+
+	if err := SetTodoRepeat(db, todo.ID, "every 2 days"); err != nil {
+		t.Fatalf("SetTodoRepeat() error = %v", err)
+	}
+
+	nextDue, err := SkipTodo(db, todo.ID)
+	if err != nil {
+		t.Fatalf("SkipTodo() error = %v", err)
+	}
+
+	want := due.AddDate(0, 0, 2).Format("2006-01-02")
+	if nextDue.Format("2006-01-02") != want {
+		t.Errorf("SkipTodo() = %s, want %s", nextDue.Format("2006-01-02"), want)
+	}
+
+	skipped, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if skipped.IsComplete || skipped.DueDate.Time.Format("2006-01-02") != want || skipped.Repeat != "every 2 days" {
+		t.Errorf("skipped todo = %+v, want it still open, due %s and repeating", skipped, want)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	db := setupTestDB(t)
+
+	parent, err := CreateTodo(db, "Launch site", []string{"work"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	var ids []int
+	for _, content := range []string{"Write copy", "Deploy", "Announce"} {
+		todo, err := CreateTodo(db, content, []string{"work"}, nil)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := SetTodoParent(db, todo.ID, parent.ID); err != nil {
+			t.Fatalf("SetTodoParent() error = %v", err)
+		}
+		ids = append(ids, todo.ID)
+	}
+
+	// A subtask of a subtask, outside the project.
+	nested, err := CreateTodo(db, "Check DNS", []string{"home"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := SetTodoParent(db, nested.ID, ids[1]); err != nil {
+		t.Fatalf("SetTodoParent() error = %v", err)
+	}
+
+	if _, err := CompleteTodo(db, ids[0]); err != nil {
+		t.Fatalf("CompleteTodo() error = %v", err)
+	}
+
+	got, err := GetTodoByID(db, parent.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if got.Subtasks != 3 || got.SubtasksDone != 1 {
+		t.Errorf("subtask counts = %d/%d, want 1/3", got.SubtasksDone, got.Subtasks)
+	}
+
+	open, err := OpenSubtasks(db, parent.ID)
+	if err != nil {
+		t.Fatalf("OpenSubtasks() error = %v", err)
+	}
+	var openIDs []int
+	for _, todo := range open {
+		openIDs = append(openIDs, todo.ID)
+	}
+	if want := []int{ids[1], nested.ID, ids[2]}; !slices.Equal(openIDs, want) {
+		t.Errorf("OpenSubtasks() = %v, want %v", openIDs, want)
+	}
+
+	incomplete, err := GetIncompleteTodosForProject(db, "work")
+	if err != nil {
+		t.Fatalf("GetIncompleteTodosForProject() error = %v", err)
+	}
+	if len(incomplete) != 4 || !slices.ContainsFunc(incomplete, func(todo models.Todo) bool { return todo.ID == nested.ID && todo.ParentID == ids[1] }) {
+		t.Errorf("GetIncompleteTodosForProject() = %+v, want the open todos and their subtasks", incomplete)
+	}
+}
+
+func TestSubtasks_Recurring(t *testing.T) {
+	db := setupTestDB(t)
+
+	parent, err := CreateTodo(db, "Keep the garden", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	due := time.Now().AddDate(0, 0, 1)
+	sub, err := CreateTodo(db, "Water the plants", []string{}, &due)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := SetTodoParent(db, sub.ID, parent.ID); err != nil {
+		t.Fatalf("SetTodoParent() error = %v", err)
+	}
+	if err := SetTodoRepeat(db, sub.ID, "weekly"); err != nil {
+		t.Fatalf("SetTodoRepeat() error = %v", err)
+	}
+
+	next, err := CompleteTodo(db, sub.ID)
+	if err != nil || next == nil {
+		t.Fatalf("CompleteTodo() = %v, %v, want the next instance", next, err)
+	}
+	if next.ParentID != parent.ID {
+		t.Errorf("next instance parent = %d, want #%d while the parent is open", next.ParentID, parent.ID)
+	}
+
+	// Completing the parent first, as --cascade does, leaves the next
+	// instance on its own instead of under a completed parent.
+	if _, err := CompleteTodo(db, parent.ID); err != nil {
+		t.Fatalf("CompleteTodo() error = %v", err)
+	}
+	next, err = CompleteTodo(db, next.ID)
+	if err != nil || next == nil {
+		t.Fatalf("CompleteTodo() = %v, %v, want the next instance", next, err)
+	}
+	if next.ParentID != 0 {
+		t.Errorf("next instance parent = %d, want none once the parent is complete", next.ParentID)
+	}
+
+	open, err := OpenSubtasks(db, parent.ID)
+	if err != nil {
+		t.Fatalf("OpenSubtasks() error = %v", err)
+	}
+	if len(open) != 0 {
+		t.Errorf("OpenSubtasks() = %+v, want none under the completed parent", open)
+	}
+}
+
+func TestListTodos_Priority(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, todo := range []struct {
+		content  string
+		priority string
+	}{
+		{"Plain", ""},
+		{"Low", "P3"},
+		{"Urgent", "P1"},
+		{"Outage", "P0"},
+		{"Also urgent", "P1"},
+	} {
+		created, err := CreateTodo(db, todo.content, []string{}, nil)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := SetTodoPriority(db, created.ID, todo.priority); err != nil {
+			t.Fatalf("SetTodoPriority() error = %v", err)
+		}
+	}
+
+	urgent, err := ListTodos(db, TodoListOptions{Priority: "P1"})
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+	if len(urgent) != 2 || urgent[0].Content != "Urgent" || urgent[1].Priority != "P1" {
+		t.Errorf("ListTodos(P1) = %+v, want the two P1 todos", urgent)
+	}
+
+	// Todos have no important flag, so important sorts by priority.
+	for _, sort := range []string{"priority", "important"} {
+		sorted, err := ListTodos(db, TodoListOptions{Sort: sort})
+		if err != nil {
+			t.Fatalf("ListTodos() error = %v", err)
+		}
+		var got []string
+		for _, todo := range sorted {
+			got = append(got, todo.Content)
+		}
+		if want := []string{"Outage", "Urgent", "Also urgent", "Low", "Plain"}; !slices.Equal(got, want) {
+			t.Errorf("ListTodos(sort %s) = %v, want %v", sort, got, want)
+		}
+	}
+}
+
 func TestUncompleteTodo(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -102,7 +346,7 @@ func TestUncompleteTodo(t *testing.T) {
 		t.Fatalf("Setup failed: %v", err)
 	}
 
-	if err := CompleteTodo(db, todo.ID); err != nil {
+	if _, err := CompleteTodo(db, todo.ID); err != nil {
 		t.Fatalf("Setup failed: %v", err)
 	}
 
@@ -217,3 +461,103 @@ func TestDeleteTodo(t *testing.T) {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+func TestListTodosPaging(t *testing.T) {
+	db := setupTestDB(t)
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	nextWeek := time.Now().AddDate(0, 0, 7)
+
+	undated, _ := CreateTodo(db, "Undated", nil, nil)
+	later, _ := CreateTodo(db, "Later", nil, &nextWeek)
+	soon, _ := CreateTodo(db, "Soon", nil, &tomorrow)
+	alsoSoon, _ := CreateTodo(db, "Also soon", nil, &tomorrow)
+
+	tests := []struct {
+		name string
+		opts TodoListOptions
+		want []int
+	}{
+		{name: "due order", opts: TodoListOptions{}, want: []int{soon.ID, alsoSoon.ID, later.ID, undated.ID}},
+		{name: "after in same due date", opts: TodoListOptions{AfterID: soon.ID}, want: []int{alsoSoon.ID, later.ID, undated.ID}},
+		{name: "after into no due date", opts: TodoListOptions{AfterID: later.ID}, want: []int{undated.ID}},
+		{name: "reversed", opts: TodoListOptions{Reverse: true, Limit: 2}, want: []int{undated.ID, later.ID}},
+		{name: "created", opts: TodoListOptions{Sort: "created", Offset: 1, Limit: 2}, want: []int{later.ID, soon.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, err := ListTodos(db, tt.opts)
+			if err != nil {
+				t.Fatalf("ListTodos() error = %v", err)
+			}
+
+			var got []int
+			for _, todo := range todos {
+				got = append(got, todo.ID)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListTodos() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("ListTodos() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := ListTodos(db, TodoListOptions{Sort: "size"}); err == nil {
+		t.Error("ListTodos() expected error for sort 'size'")
+	}
+
+	if err := DeleteTodo(db, later.ID); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+	if _, err := ListTodos(db, TodoListOptions{AfterID: later.ID}); err == nil {
+		t.Error("ListTodos() expected error for a trashed cursor todo")
+	}
+}
+
+func TestListTodosDay(t *testing.T) {
+	db := setupTestDB(t)
+
+	today := time.Now()
+	earlier := today.AddDate(0, 0, -3)
+
+	old, _ := CreateTodo(db, "Old", nil, nil)
+	due, _ := CreateTodo(db, "Due today", nil, &today)
+	completed, _ := CreateTodo(db, "Completed today", nil, nil)
+	for _, id := range []int{old.ID, due.ID, completed.ID} {
+		if _, err := db.Exec("UPDATE todos SET created_at = ?, updated_at = ? WHERE id = ?", earlier, earlier, id); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+	if _, err := CompleteTodo(db, completed.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	created, _ := CreateTodo(db, "Created today", nil, nil)
+
+	todos, err := ListTodos(db, TodoListOptions{Day: &today, Sort: "created"})
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+
+	var got []int
+	for _, todo := range todos {
+		got = append(got, todo.ID)
+	}
+	want := []int{due.ID, completed.ID, created.ID}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("ListTodos(Day) = %v, want %v", got, want)
+	}
+
+	todos, err = ListTodos(db, TodoListOptions{Day: &earlier})
+	if err != nil {
+		t.Fatalf("ListTodos() error = %v", err)
+	}
+	if len(todos) != 3 {
+		t.Errorf("ListTodos(Day) for the earlier day returned %d todos, want 3", len(todos))
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/database"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+var trashTables = map[string]string{
+	"note":    "notes",
+	"todo":    "todos",
+	"project": "projects",
+}
+
+func ListTrash(db database.DBTX) ([]models.TrashItem, error) {
+	rows, err := db.Query(`
+		SELECT 'note', id, content, deleted_at FROM notes WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'todo', id, content, deleted_at FROM todos WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'project', id, name, deleted_at FROM projects WHERE deleted_at IS NOT NULL
+		ORDER BY 4 DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.TrashItem
+	for rows.Next() {
+		var item models.TrashItem
+		if err := rows.Scan(&item.Kind, &item.ID, &item.Title, &item.DeletedAt); err != nil {
+			return nil, err
+		}
+
+		if err := openContent(&item.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func RestoreNote(db database.DBTX, id int) error {
+	return restore(db, "note", id)
+}
+
+func RestoreTodo(db database.DBTX, id int) error {
+	return restore(db, "todo", id)
+}
+
+func RestoreProject(db database.DBTX, id int) error {
+	return restore(db, "project", id)
+}
+
+func GetTrashedProjectID(db database.DBTX, name string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM projects WHERE name = ? AND deleted_at IS NOT NULL", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("Project '%s' is not in the trash", name)
+	}
+	return id, err
+}
+
+func restore(db database.DBTX, kind string, id int) error {
+	table, ok := trashTables[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind '%s' (expected note, todo or project)", kind)
+	}
+
+	result, err := db.Exec("UPDATE "+table+" SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s #%d is not in the trash", kind, id)
+	}
+
+	return nil
+}
+
+// EmptyTrash permanently deletes trashed rows that were deleted before the
+// cutoff. A zero cutoff empties the whole trash.
+func EmptyTrash(db database.DBTX, cutoff time.Time) (int, error) {
+	var total int
+
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		total = 0
+		for _, trashed := range []struct{ table, kind string }{
+			{"notes", "note"},
+			{"todos", "todo"},
+			{"projects", "project"},
+		} {
+			where := " WHERE deleted_at IS NOT NULL"
+			var args []interface{}
+
+			if !cutoff.IsZero() {
+				where += " AND deleted_at <= ?"
+				args = append(args, cutoff)
+			}
+
+			// Revisions only exist for notes and todos, and go with them.
+			if trashed.kind != "project" {
+				_, err := tx.Exec(`
+					DELETE FROM revisions
+					WHERE entity_kind = ? AND entity_id IN (SELECT id FROM `+trashed.table+where+`)
+				`, append([]interface{}{trashed.kind}, args...)...)
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := tx.Exec("DELETE FROM "+trashed.table+where, args...)
+			if err != nil {
+				return err
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			total += int(rowsAffected)
+		}
+		if err := pruneLinks(tx); err != nil {
+			return err
+		}
+		return pruneAttachments(tx)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashAndRestoreNote(t *testing.T) {
+	db := setupTestDB(t)
+
+	note, err := CreateNote(db, "Accidentally deleted", []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteNote(db, note.ID); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+
+	t.Run("hidden from list and show", func(t *testing.T) {
+		if _, err := GetNoteByID(db, note.ID); err == nil {
+			t.Error("GetNoteByID() returned a trashed note")
+		}
+
+		notes, err := ListNotes(db, NoteListOptions{Tags: []string{"work"}})
+		if err != nil {
+			t.Fatalf("ListNotes() error = %v", err)
+		}
+		if len(notes) != 0 {
+			t.Errorf("ListNotes() returned %d notes, want 0", len(notes))
+		}
+	})
+
+	t.Run("listed in trash", func(t *testing.T) {
+		items, err := ListTrash(db)
+		if err != nil {
+			t.Fatalf("ListTrash() error = %v", err)
+		}
+
+		if len(items) != 1 || items[0].Kind != "note" || items[0].ID != note.ID {
+			t.Fatalf("ListTrash() = %+v, want the trashed note", items)
+		}
+
+		if items[0].DeletedAt.IsZero() {
+			t.Error("ListTrash() DeletedAt is zero")
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		if err := RestoreNote(db, note.ID); err != nil {
+			t.Fatalf("RestoreNote() error = %v", err)
+		}
+
+		restored, err := GetNoteByID(db, note.ID)
+		if err != nil {
+			t.Fatalf("GetNoteByID() error = %v", err)
+		}
+
+		if len(restored.Tags) != 1 || restored.Tags[0] != "work" {
+			t.Errorf("RestoreNote() tags = %v, want [work]", restored.Tags)
+		}
+	})
+
+	t.Run("restore item not in trash", func(t *testing.T) {
+		if err := RestoreNote(db, note.ID); err == nil {
+			t.Error("RestoreNote() expected error for item not in trash, got nil")
+		}
+	})
+}
+
+func TestTrashTodoHiddenFromProjectTodos(t *testing.T) {
+	db := setupTestDB(t)
+
+	todo, err := CreateTodo(db, "Trashed task", []string{"work"}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteTodo(db, todo.ID); err != nil {
+		t.Fatalf("DeleteTodo() error = %v", err)
+	}
+
+	incomplete, err := GetIncompleteTodosForProject(db, "work")
+	if err != nil {
+		t.Fatalf("GetIncompleteTodosForProject() error = %v", err)
+	}
+
+	if len(incomplete) != 0 {
+		t.Errorf("GetIncompleteTodosForProject() returned %d todos, want 0", len(incomplete))
+	}
+
+	if err := RestoreTodo(db, todo.ID); err != nil {
+		t.Fatalf("RestoreTodo() error = %v", err)
+	}
+
+	if _, err := GetTodoByID(db, todo.ID); err != nil {
+		t.Errorf("GetTodoByID() after restore error = %v", err)
+	}
+}
+
+func TestTrashProject(t *testing.T) {
+	db := setupTestDB(t)
+
+	project, err := CreateProject(db, "side-quest", []string{})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteProject(db, "side-quest"); err != nil {
+		t.Fatalf("DeleteProject() error = %v", err)
+	}
+
+	if _, err := GetProjectByName(db, "side-quest"); err == nil {
+		t.Error("GetProjectByName() returned a trashed project")
+	}
+
+	if _, err := CreateProject(db, "side-quest", []string{}); err == nil {
+		t.Error("CreateProject() expected error while a project with the same name is trashed, got nil")
+	}
+
+	id, err := GetTrashedProjectID(db, "side-quest")
+	if err != nil {
+		t.Fatalf("GetTrashedProjectID() error = %v", err)
+	}
+	if id != project.ID {
+		t.Errorf("GetTrashedProjectID() = %d, want %d", id, project.ID)
+	}
+
+	if err := RestoreProject(db, id); err != nil {
+		t.Fatalf("RestoreProject() error = %v", err)
+	}
+
+	if _, err := GetProjectByName(db, "side-quest"); err != nil {
+		t.Errorf("GetProjectByName() after restore error = %v", err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	db := setupTestDB(t)
+
+	oldNote, err := CreateNote(db, "Old trash", []string{}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	recentNote, err := CreateNote(db, "Recent trash", []string{}, false)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteNote(db, oldNote.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := DeleteNote(db, recentNote.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE notes SET deleted_at = ? WHERE id = ?", time.Now().AddDate(0, 0, -45), oldNote.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	t.Run("older than cutoff", func(t *testing.T) {
+		count, err := EmptyTrash(db, time.Now().AddDate(0, 0, -30))
+		if err != nil {
+			t.Fatalf("EmptyTrash() error = %v", err)
+		}
+
+		if count != 1 {
+			t.Errorf("EmptyTrash() deleted %d items, want 1", count)
+		}
+
+		if err := RestoreNote(db, recentNote.ID); err != nil {
+			t.Errorf("EmptyTrash() removed an item newer than the cutoff: %v", err)
+		}
+	})
+
+	t.Run("everything", func(t *testing.T) {
+		if err := DeleteNote(db, recentNote.ID); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+
+		count, err := EmptyTrash(db, time.Time{})
+		if err != nil {
+			t.Fatalf("EmptyTrash() error = %v", err)
+		}
+
+		if count != 1 {
+			t.Errorf("EmptyTrash() deleted %d items, want 1", count)
+		}
+
+		items, err := ListTrash(db)
+		if err != nil {
+			t.Fatalf("ListTrash() error = %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("ListTrash() returned %d items after emptying, want 0", len(items))
+		}
+	})
+}
+
+func TestEmptyTrashRemovesRevisions(t *testing.T) {
+	db := setupTestDB(t)
+
+	content := "Edited"
+	var notes []int
+	for _, text := range []string{"Trashed", "Kept"} {
+		note, err := CreateNote(db, text, []string{}, false)
+		if err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		if err := UpdateNote(db, note.ID, &content, nil, nil); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+		notes = append(notes, note.ID)
+	}
+
+	todo, err := CreateTodo(db, "Trashed", []string{}, nil)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := UpdateTodo(db, todo.ID, &content, nil, nil, false); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if err := DeleteNote(db, notes[0]); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := DeleteTodo(db, todo.ID); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	if _, err := EmptyTrash(db, time.Time{}); err != nil {
+		t.Fatalf("EmptyTrash() error = %v", err)
+	}
+
+	var orphaned int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM revisions
+		WHERE (entity_kind = 'note' AND entity_id = ?) OR (entity_kind = 'todo' AND entity_id = ?)
+	`, notes[0], todo.ID).Scan(&orphaned)
+	if err != nil {
+		t.Fatalf("counting revisions: %v", err)
+	}
+	if orphaned != 0 {
+		t.Errorf("EmptyTrash() left %d revisions of purged items", orphaned)
+	}
+
+	revisions, err := ListRevisions(db, "note", notes[1])
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+	if len(revisions) == 0 {
+		t.Error("EmptyTrash() removed the revisions of a note that was not trashed")
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nathan-nicholson/note/internal/database"
+)
+
+// failTagInserts makes every insert into the given join table fail, so that
+// multi-step writes break partway through.
+func failTagInserts(t *testing.T, db database.DBTX, table string) {
+	t.Helper()
+
+	_, err := db.Exec(`
+		CREATE TRIGGER fail_` + table + ` BEFORE INSERT ON ` + table + `
+		BEGIN
+			SELECT RAISE(ABORT, 'tag insert failed');
+		END
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create trigger: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec("DROP TRIGGER IF EXISTS fail_" + table)
+	})
+}
+
+func TestCreateNoteRollsBackOnTagFailure(t *testing.T) {
+	db := setupTestDB(t)
+	failTagInserts(t, db, "note_tags")
+
+	if _, err := CreateNote(db, "Half written", []string{"work"}, false); err == nil {
+		t.Fatal("CreateNote() expected error, got nil")
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM notes").Scan(&count); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the note insert to be rolled back, found %d notes", count)
+	}
+}
+
+func TestUpdateTodoKeepsTagsOnFailure(t *testing.T) {
+	db := setupTestDB(t)
+
+	todo, err := CreateTodo(db, "Ship release", []string{"work", "release"}, nil)
+	if err != nil {
+		t.Fatalf("CreateTodo() error = %v", err)
+	}
+
+	failTagInserts(t, db, "todo_tags")
+
+	content := "Ship release 2.0"
+	if err := UpdateTodo(db, todo.ID, &content, []string{"other"}, nil, false); err == nil {
+		t.Fatal("UpdateTodo() expected error, got nil")
+	}
+
+	got, err := GetTodoByID(db, todo.ID)
+	if err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if got.Content != "Ship release" {
+		t.Errorf("Content = %q, want the original content", got.Content)
+	}
+	if len(got.Tags) != 2 {
+		t.Errorf("Tags = %v, want the original tags", got.Tags)
+	}
+}
+
+func TestWritesJoinCallerTransaction(t *testing.T) {
+	db := setupTestDB(t)
+
+	errAbort := errors.New("abort")
+	err := database.WithTx(db, func(tx database.DBTX) error {
+		if _, err := CreateNote(tx, "Inside", []string{"a"}, false); err != nil {
+			return err
+		}
+		if _, err := CreateTodo(tx, "Inside", []string{"b"}, nil); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("WithTx() error = %v, want %v", err, errAbort)
+	}
+
+	var notes, todos int
+	db.QueryRow("SELECT COUNT(*) FROM notes").Scan(&notes)
+	db.QueryRow("SELECT COUNT(*) FROM todos").Scan(&todos)
+	if notes != 0 || todos != 0 {
+		t.Errorf("Expected nothing to be written, found %d notes and %d todos", notes, todos)
+	}
+}
@@ -0,0 +1,191 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nathan-nicholson/note/internal/editor"
+	"github.com/nathan-nicholson/note/internal/models"
+)
+
+// Templates are plain text with placeholders. {{name}} is replaced by a
+// variable: one of the built-ins below or one given with --var. {{prompt
+// "Label"}} asks for a value when the note is created, unless a variable
+// named after the label was given. Names are not case-sensitive.
+//
+// Template files live in ~/.note/templates as <name>.md and use the same
+// layout as the editor: an optional header with tags and important fields,
+// a --- line, then the content.
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*(?:prompt\s+"([^"]*)"|([A-Za-z][A-Za-z0-9_-]*))\s*\}\}`)
+
+// Builtins returns the variables every template can use.
+func Builtins(now time.Time, project string) map[string]string {
+	return map[string]string{
+		"date":    now.Format("2006-01-02"),
+		"time":    now.Format("15:04"),
+		"weekday": now.Weekday().String(),
+		"project": project,
+	}
+}
+
+// ParseVars turns --var name=value assignments into variables.
+func ParseVars(assignments []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable '%s' (expected name=value)", assignment)
+		}
+		vars[strings.ToLower(name)] = value
+	}
+	return vars, nil
+}
+
+// Render fills in the placeholders in content. Variables override built-ins
+// of the same name. prompt is called once for each distinct prompt label that
+// no variable answers.
+func Render(content string, vars map[string]string, prompt func(label string) (string, error)) (string, error) {
+	lookup := map[string]string{}
+	for name, value := range vars {
+		lookup[strings.ToLower(name)] = value
+	}
+
+	var firstErr error
+	rendered := placeholderRegex.ReplaceAllStringFunc(content, func(placeholder string) string {
+		if firstErr != nil {
+			return placeholder
+		}
+
+		match := placeholderRegex.FindStringSubmatch(placeholder)
+		if match[2] != "" {
+			value, ok := lookup[strings.ToLower(match[2])]
+			if !ok {
+				firstErr = fmt.Errorf("unknown variable {{%s}} (set it with --var %s=...)", match[2], match[2])
+			}
+			return value
+		}
+
+		label := match[1]
+		if value, ok := lookup[strings.ToLower(label)]; ok {
+			return value
+		}
+
+		value, err := prompt(label)
+		if err != nil {
+			firstErr = err
+			return placeholder
+		}
+		lookup[strings.ToLower(label)] = value
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	return rendered, nil
+}
+
+// Parse reads a template file. The header is optional.
+func Parse(name, text string) (*models.Template, error) {
+	t := &models.Template{Name: name}
+
+	if !hasHeader(text) {
+		t.Content = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+		return t, nil
+	}
+
+	fields, content, err := editor.Parse(text, []string{"tags", "important"})
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+
+	t.Content = content
+	t.Tags = editor.SplitTags(fields["tags"])
+	if value := fields["important"]; value != "" {
+		t.IsImportant, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("template '%s': invalid important value '%s' (expected true or false)", name, value)
+		}
+	}
+
+	return t, nil
+}
+
+// Load reads the template file called name from dir, returning nil if there
+// is none.
+func Load(dir, name string) (*models.Template, error) {
+	if err := models.ValidateTemplateName(name); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, name+".md")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := Parse(name, string(data))
+	if err != nil {
+		return nil, err
+	}
+	t.Path = path
+	return t, nil
+}
+
+// LoadDir reads every template file in dir, ordered by name. Files whose
+// names are not valid template names are skipped.
+func LoadDir(dir string) ([]models.Template, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	var result []models.Template
+	for _, path := range matches {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		if models.ValidateTemplateName(name) != nil {
+			continue
+		}
+
+		t, err := Load(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *t)
+	}
+
+	return result, nil
+}
+
+// hasHeader reports whether text starts with header fields followed by the
+// separator, as opposed to content that merely contains a --- line.
+func hasHeader(text string) bool {
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == editor.Separator:
+			return true
+		case line == "":
+			continue
+		case !strings.Contains(line, ":"):
+			return false
+		}
+		name, _, _ := strings.Cut(line, ":")
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "tags" && name != "important" {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	vars := Builtins(time.Date(2025, 11, 3, 9, 30, 0, 0, time.Local), "work")
+	vars["attendees"] = "a, b"
+
+	var asked []string
+	prompt := func(label string) (string, error) {
+		asked = append(asked, label)
+		return "answer to " + label, nil
+	}
+
+	got, err := Render(`{{date}} {{ time }} {{Weekday}} in {{project}}
+With: {{attendees}}
+Agenda: {{prompt "Agenda"}}
+Again: {{prompt "Agenda"}}
+Notes: {{prompt "Notes"}}`, vars, prompt)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `2025-11-03 09:30 Monday in work
+With: a, b
+Agenda: answer to Agenda
+Again: answer to Agenda
+Notes: answer to Notes`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	if !reflect.DeepEqual(asked, []string{"Agenda", "Notes"}) {
+		t.Errorf("prompted for %v, want each label once", asked)
+	}
+}
+
+func TestRender_VariablesAnswerPrompts(t *testing.T) {
+	prompt := func(label string) (string, error) {
+		t.Fatalf("prompted for %q although a variable was given", label)
+		return "", nil
+	}
+
+	got, err := Render(`Agenda: {{prompt "Agenda"}}`, map[string]string{"AGENDA": "ship it"}, prompt)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got != "Agenda: ship it" {
+		t.Errorf("Render() = %q, want %q", got, "Agenda: ship it")
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	if _, err := Render("{{missing}}", nil, nil); err == nil {
+		t.Error("Render() with an unknown variable succeeded, want an error")
+	}
+
+	errNoTerminal := errors.New("no terminal")
+	_, err := Render(`{{prompt "Agenda"}}`, nil, func(string) (string, error) { return "", errNoTerminal })
+	if !errors.Is(err, errNoTerminal) {
+		t.Errorf("Render() error = %v, want the prompt error", err)
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	vars, err := ParseVars([]string{"Attendees=a,b", "empty=", "eq=x=y"})
+	if err != nil {
+		t.Fatalf("ParseVars() error = %v", err)
+	}
+
+	want := map[string]string{"attendees": "a,b", "empty": "", "eq": "x=y"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("ParseVars() = %v, want %v", vars, want)
+	}
+
+	if _, err := ParseVars([]string{"novalue"}); err == nil {
+		t.Error("ParseVars() without = succeeded, want an error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantContent   string
+		wantTags      []string
+		wantImportant bool
+	}{
+		{
+			name:          "with header",
+			text:          "tags: incident, #ops\nimportant: true\n---\n# Incident\n",
+			wantContent:   "# Incident",
+			wantTags:      []string{"incident", "ops"},
+			wantImportant: true,
+		},
+		{
+			name:        "content only",
+			text:        "Standup\n\n---\n\nBlockers:\n",
+			wantContent: "Standup\n\n---\n\nBlockers:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("test", tt.text)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Content != tt.wantContent || !reflect.DeepEqual(got.Tags, tt.wantTags) || got.IsImportant != tt.wantImportant {
+				t.Errorf("Parse() = %+v, want content %q, tags %v, important %v", got, tt.wantContent, tt.wantTags, tt.wantImportant)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range map[string]string{
+		"standup.md":    "Standup {{date}}",
+		"one-on-one.md": "tags: 1on1\n---\nAgenda",
+		"Not Valid.md":  "skipped",
+		"notes.txt":     "skipped",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0600); err != nil {
+			t.Fatalf("Setup failed: %v", err)
+		}
+	}
+
+	list, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	if len(list) != 2 || list[0].Name != "one-on-one" || list[1].Name != "standup" {
+		t.Fatalf("LoadDir() = %+v, want one-on-one and standup", list)
+	}
+	if list[0].Path != filepath.Join(dir, "one-on-one.md") {
+		t.Errorf("LoadDir()[0].Path = %q", list[0].Path)
+	}
+
+	missing, err := Load(dir, "absent")
+	if err != nil || missing != nil {
+		t.Errorf("Load() of a missing template = %+v, %v, want nil, nil", missing, err)
+	}
+}
@@ -0,0 +1,78 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	EnvPassphrase = "NOTE_PASSPHRASE"
+	EnvKeyFile    = "NOTE_KEY_FILE"
+)
+
+// ReadPassphrase finds the passphrase in order of precedence: the keyFile
+// argument, $NOTE_KEY_FILE, $NOTE_PASSPHRASE, and finally a prompt on the
+// terminal. With confirm set, a prompted passphrase has to be typed twice.
+func ReadPassphrase(keyFile string, confirm bool) (string, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv(EnvKeyFile)
+	}
+	if keyFile != "" {
+		return readKeyFile(keyFile)
+	}
+
+	if passphrase := os.Getenv(EnvPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("the database is encrypted: set %s or %s, or run note from a terminal", EnvPassphrase, EnvKeyFile)
+	}
+
+	passphrase, err := prompt(fd, "Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+
+	if confirm {
+		again, err := prompt(fd, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+func readKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read key file: %w", err)
+	}
+
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+func prompt(fd int, label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	input, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("could not read passphrase: %w", err)
+	}
+	return string(input), nil
+}
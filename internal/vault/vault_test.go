@@ -0,0 +1,149 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testParams keep key derivation fast; the cost parameters do not affect
+// correctness.
+var testParams = Params{Time: 1, MemoryKiB: 1024, Threads: 1}
+
+func newTestCipher(t *testing.T, passphrase string, salt []byte) *Cipher {
+	t.Helper()
+
+	c, err := NewCipher(DeriveKey(passphrase, salt, testParams))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	return c
+}
+
+func TestSealOpen(t *testing.T) {
+	salt, err := NewSalt()
+	if err != nil {
+		t.Fatalf("NewSalt() error = %v", err)
+	}
+	c := newTestCipher(t, "correct horse", salt)
+
+	for _, plaintext := range []string{"", "Quarterly review", "Ünïcödé ✓\nsecond line"} {
+		sealed, err := c.Seal(plaintext)
+		if err != nil {
+			t.Fatalf("Seal(%q) error = %v", plaintext, err)
+		}
+
+		if !IsSealed(sealed) {
+			t.Errorf("IsSealed(%q) = false, want true", sealed)
+		}
+
+		opened, err := c.Open(sealed)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if opened != plaintext {
+			t.Errorf("Open() = %q, want %q", opened, plaintext)
+		}
+	}
+}
+
+func TestSealUsesFreshNonce(t *testing.T) {
+	c := newTestCipher(t, "correct horse", []byte("0123456789abcdef"))
+
+	first, _ := c.Seal("same text")
+	second, _ := c.Seal("same text")
+	if first == second {
+		t.Error("Seal() returned identical output twice; nonces must not repeat")
+	}
+}
+
+func TestOpenRejectsWrongKeyAndTampering(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	sealed, err := newTestCipher(t, "correct horse", salt).Seal("secret")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	if _, err := newTestCipher(t, "wrong horse", salt).Open(sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() with wrong key error = %v, want ErrDecrypt", err)
+	}
+
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := newTestCipher(t, "correct horse", salt).Open(tampered); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() of tampered value error = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := newTestCipher(t, "correct horse", salt).Open("plain text"); err == nil {
+		t.Error("Open() of plaintext expected error, got nil")
+	}
+}
+
+func TestDeriveKeyDependsOnSalt(t *testing.T) {
+	a := DeriveKey("passphrase", []byte("0123456789abcdef"), testParams)
+	b := DeriveKey("passphrase", []byte("fedcba9876543210"), testParams)
+
+	if string(a) == string(b) {
+		t.Error("DeriveKey() returned the same key for different salts")
+	}
+	if len(a) != KeySize {
+		t.Errorf("len(DeriveKey()) = %d, want %d", len(a), KeySize)
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("from file\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	t.Run("key file argument wins", func(t *testing.T) {
+		t.Setenv(EnvKeyFile, "")
+		t.Setenv(EnvPassphrase, "from env")
+
+		got, err := ReadPassphrase(keyFile, false)
+		if err != nil {
+			t.Fatalf("ReadPassphrase() error = %v", err)
+		}
+		if got != "from file" {
+			t.Errorf("ReadPassphrase() = %q, want %q", got, "from file")
+		}
+	})
+
+	t.Run("key file from environment", func(t *testing.T) {
+		t.Setenv(EnvKeyFile, keyFile)
+		t.Setenv(EnvPassphrase, "from env")
+
+		got, err := ReadPassphrase("", false)
+		if err != nil {
+			t.Fatalf("ReadPassphrase() error = %v", err)
+		}
+		if got != "from file" {
+			t.Errorf("ReadPassphrase() = %q, want %q", got, "from file")
+		}
+	})
+
+	t.Run("passphrase from environment", func(t *testing.T) {
+		t.Setenv(EnvKeyFile, "")
+		t.Setenv(EnvPassphrase, "from env")
+
+		got, err := ReadPassphrase("", true)
+		if err != nil {
+			t.Fatalf("ReadPassphrase() error = %v", err)
+		}
+		if got != "from env" {
+			t.Errorf("ReadPassphrase() = %q, want %q", got, "from env")
+		}
+	})
+
+	t.Run("empty key file", func(t *testing.T) {
+		empty := filepath.Join(t.TempDir(), "empty")
+		if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+
+		if _, err := ReadPassphrase(empty, false); err == nil {
+			t.Error("ReadPassphrase() expected error for empty key file, got nil")
+		}
+	})
+}
@@ -9,13 +9,10 @@ import (
 )
 
 func main() {
-	if err := database.InitDB(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-	defer database.CloseDB()
+	err := cmd.Execute()
+	database.CloseDB()
 
-	if err := cmd.Execute(); err != nil {
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}